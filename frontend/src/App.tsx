@@ -20,6 +20,9 @@ import type {
   ModeChangePayload,
   StreamInjectPayload,
   ActivationReadyPayload,
+  ActivationQueuedPayload,
+  ActivationCancelledPayload,
+  ActivationFailedPayload,
   DemoControlPayload,
   FocusEventPayload,
   ScrollUpdatePayload,
@@ -73,6 +76,12 @@ function App() {
     setDecisions((prev) => [decision, ...prev].slice(0, 100))
   }, [])
 
+  const handleDecisionUpdated = useCallback((decision: AIDecision) => {
+    setDecisions((prev) =>
+      prev.map((d) => (d.decision_id === decision.decision_id ? decision : d))
+    )
+  }, [])
+
   const handleContainerStateChange = useCallback((payload: ContainerStateChangePayload) => {
     console.log("Container state change:", payload)
     setContainerStates((prev) => ({
@@ -138,6 +147,21 @@ function App() {
     setActiveContentId(payload.content_id)
   }, [])
 
+  const handleActivationQueued = useCallback((payload: ActivationQueuedPayload) => {
+    console.log(
+      `Activation queued: ${payload.content_id} at ${payload.position}/${payload.queue_length}, ETA ${Math.round(payload.eta_ms / 1000)}s`
+    )
+  }, [])
+
+  const handleActivationCancelled = useCallback((payload: ActivationCancelledPayload) => {
+    console.log("Activation cancelled:", payload)
+  }, [])
+
+  const handleActivationFailed = useCallback((payload: ActivationFailedPayload) => {
+    console.warn(`Activation failed: ${payload.content_id} (${payload.reason}), fallback ${payload.fallback}`)
+    setActiveContentId((prev) => (prev === payload.content_id ? null : prev))
+  }, [])
+
   const handleActivationSpine = useCallback((event: ActivationSpineEvent) => {
     setActivationSpine((prev) => [event, ...prev].slice(0, 200))
   }, [])
@@ -176,12 +200,16 @@ function App() {
     url: wsUrl,
     onConnectionEstablished: handleConnectionEstablished,
     onDecisionMade: handleDecisionMade,
+    onDecisionUpdated: handleDecisionUpdated,
     onContainerStateChange: handleContainerStateChange,
     onScoreUpdate: handleScoreUpdate,
     onModeChange: handleModeChange,
     onStreamInject: handleStreamInject,
     onResourceUpdate: handleResourceUpdate,
     onActivationReady: handleActivationReady,
+    onActivationQueued: handleActivationQueued,
+    onActivationCancelled: handleActivationCancelled,
+    onActivationFailed: handleActivationFailed,
     onActivationSpine: handleActivationSpine,
     onSocialEvent: handleSocialEvent,
     onEngagementUpdate: handleEngagementUpdate,
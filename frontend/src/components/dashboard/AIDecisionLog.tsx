@@ -56,6 +56,11 @@ export function AIDecisionLog({ decisions, maxItems = 20 }: AIDecisionLogProps)
                 {displayDecisions.map((decision, index) => {
                   const ActionIcon = actionIcons[decision.resulting_action]
                   const triggerConfig = triggerTypeConfig[decision.trigger_type]
+                  const outcome = !decision.completed_at
+                    ? { border: "border-muted", bg: "bg-muted", text: "text-muted-foreground" }
+                    : decision.success
+                    ? { border: "border-accent-success", bg: "bg-accent-success/10", text: "text-accent-success" }
+                    : { border: "border-destructive", bg: "bg-destructive/10", text: "text-destructive" }
 
                   return (
                     <motion.div
@@ -70,19 +75,13 @@ export function AIDecisionLog({ decisions, maxItems = 20 }: AIDecisionLogProps)
                         stiffness: 300,
                         damping: 25
                       }}
-                      className={`bg-elevated rounded-lg p-3 border-l-4 transition-colors hover:bg-overlay ${
-                        decision.success ? "border-accent-success" : "border-destructive"
-                      }`}
+                      className={`bg-elevated rounded-lg p-3 border-l-4 transition-colors hover:bg-overlay ${outcome.border}`}
                     >
                       {/* Header */}
                       <div className="flex items-center justify-between mb-2">
                         <div className="flex items-center gap-2">
-                          <div className={`h-7 w-7 rounded-md flex items-center justify-center ${
-                            decision.success ? "bg-accent-success/10" : "bg-destructive/10"
-                          }`}>
-                            <ActionIcon className={`h-4 w-4 ${
-                              decision.success ? "text-accent-success" : "text-destructive"
-                            }`} />
+                          <div className={`h-7 w-7 rounded-md flex items-center justify-center ${outcome.bg}`}>
+                            <ActionIcon className={`h-4 w-4 ${outcome.text}`} />
                           </div>
                           <Badge
                             variant="outline"
@@ -90,6 +89,11 @@ export function AIDecisionLog({ decisions, maxItems = 20 }: AIDecisionLogProps)
                           >
                             {triggerConfig.label}
                           </Badge>
+                          {decision.explanation && (
+                            <span className="text-[10px] font-mono text-muted-foreground">
+                              {decision.explanation.rule_id}
+                            </span>
+                          )}
                         </div>
                         <div className="flex items-center gap-1 text-[10px] text-muted-foreground">
                           <ClockIcon className="h-3 w-3" />
@@ -101,6 +105,11 @@ export function AIDecisionLog({ decisions, maxItems = 20 }: AIDecisionLogProps)
                       <p className="text-sm text-foreground/80 mb-2">
                         {decision.reasoning_text}
                       </p>
+                      {decision.error && (
+                        <p className="text-xs text-destructive mb-2 break-words">
+                          {decision.error}
+                        </p>
+                      )}
 
                       {/* Footer */}
                       <div className="flex items-center justify-between gap-2 text-[10px]">
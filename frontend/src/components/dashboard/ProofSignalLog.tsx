@@ -22,6 +22,8 @@ const eventConfig: Record<ProofEventType, { label: string; color: string; bgColo
   execution_ready: { label: "Exec Ready", color: "text-emerald-600 dark:text-emerald-400", bgColor: "bg-emerald-500/20" },
   restore_start: { label: "Restore", color: "text-cyan-600 dark:text-cyan-400", bgColor: "bg-cyan-500/20" },
   restore_complete: { label: "Restored", color: "text-cyan-500 dark:text-cyan-300", bgColor: "bg-cyan-500/20" },
+  cooling_start: { label: "Cooling", color: "text-blue-600 dark:text-blue-400", bgColor: "bg-blue-500/20" },
+  cold_state_entered: { label: "Cold", color: "text-slate-600 dark:text-slate-400", bgColor: "bg-slate-500/20" },
 }
 
 function formatTimestamp(tsMs: number): string {
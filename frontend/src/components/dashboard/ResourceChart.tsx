@@ -1,13 +1,19 @@
 import { LineChart, Line, XAxis, YAxis, Tooltip, ResponsiveContainer, Legend } from "recharts"
 import { Card, CardHeader, CardTitle, CardContent } from "@/components/ui/card"
 import { PulseIcon } from "@/components/icons"
-import type { ResourceAllocation } from "@/types"
+import type { DeploymentResources, ResourceAllocation } from "@/types"
 
 interface ResourceChartProps {
   history: ResourceAllocation[]
   maxPoints?: number
 }
 
+const tierColors: Record<DeploymentResources["tier"], string> = {
+  active: "text-hot",
+  warm: "text-warm",
+  background: "text-cold",
+}
+
 export function ResourceChart({ history, maxPoints = 30 }: ResourceChartProps) {
   const data = history.slice(-maxPoints).map((r) => ({
     time: new Date(r.timestamp).toLocaleTimeString(),
@@ -124,6 +130,24 @@ export function ResourceChart({ history, maxPoints = 30 }: ResourceChartProps) {
                 <p className="text-muted-foreground text-[10px]">Background</p>
               </div>
             </div>
+
+            {/* Per-deployment breakdown (cluster data only) */}
+            {latestData?.deployments && latestData.deployments.length > 0 && (
+              <div className="mt-3 space-y-1">
+                {latestData.deployments.map((d) => (
+                  <div key={d.deployment} className="flex items-center justify-between text-[11px]">
+                    <span className={tierColors[d.tier]}>{d.deployment}</span>
+                    <span className="text-muted-foreground font-mono">
+                      {latestData.source === "usage" ? `${d.cpu_usage_millis ?? 0}m used / ` : ""}
+                      {d.cpu_request_millis}m req, {d.replicas}x
+                    </span>
+                  </div>
+                ))}
+                <p className="text-muted-foreground text-[10px] text-right">
+                  Share of CPU {latestData.source === "usage" ? "usage" : "requests"}
+                </p>
+              </div>
+            )}
           </>
         )}
       </CardContent>
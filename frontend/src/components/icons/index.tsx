@@ -144,7 +144,10 @@ export {
 export const statusIcons: Record<ContainerStatus, LucideIcon> = {
   HOT: Flame,
   WARM: Thermometer,
+  WARMING: Loader2,
+  COOLING: Snowflake,
   COLD: Snowflake,
+  FAILED: AlertCircle,
 }
 
 // Content type icon mapping
@@ -159,6 +162,7 @@ export const modeIcons: Record<OperationalMode, LucideIcon> = {
   MIXED_STREAM_BROWSING: Layers,
   GAME_FOCUS_MODE: Gamepad2,
   AI_SERVICE_MODE: Brain,
+  VIDEO_WATCHING_MODE: Video,
 }
 
 // Action type icon mapping
@@ -168,6 +172,9 @@ export const actionIcons: Record<ActionType, LucideIcon> = {
   SCALE_HOT: Zap,
   THROTTLE_BACKGROUND: Pause,
   CHANGE_MODE: RefreshCw,
+  RETRY_WARM: RefreshCw,
+  COOL_DOWN: Snowflake,
+  MARK_UNAVAILABLE: XCircle,
 }
 
 // Trigger type config with colors
@@ -180,6 +187,7 @@ export const triggerTypeConfig: Record<TriggerType, { label: string; className:
   INITIAL_WARM: { label: "Initial Warm", className: "text-accent-success bg-accent-success/10" },
   LOOKAHEAD_WARM: { label: "Lookahead Warm", className: "text-accent-primary bg-accent-primary/10" },
   MANUAL: { label: "Manual", className: "text-muted-foreground bg-muted-foreground/10" },
+  WORKLOAD_FAILURE: { label: "Workload Failure", className: "text-destructive bg-destructive/10" },
 }
 
 // Status config with labels and colors
@@ -194,11 +202,26 @@ export const statusConfig: Record<ContainerStatus, { label: string; description:
     description: "Standby - 1 replica",
     className: "text-warm",
   },
+  WARMING: {
+    label: "Warming",
+    description: "Scaling up - preview not ready",
+    className: "text-warm",
+  },
+  COOLING: {
+    label: "Cooling",
+    description: "Scaling down",
+    className: "text-cold",
+  },
   COLD: {
     label: "Cold",
     description: "Scaled Down - 0 replicas",
     className: "text-cold",
   },
+  FAILED: {
+    label: "Failed",
+    description: "Workload could not start",
+    className: "text-destructive",
+  },
 }
 
 // Mode config with labels and gradients
@@ -218,6 +241,11 @@ export const modeConfig: Record<OperationalMode, { label: string; description: s
     description: "Using AI services",
     gradient: "from-accent-success/80 to-accent-secondary/60",
   },
+  VIDEO_WATCHING_MODE: {
+    label: "Video",
+    description: "Watching video content",
+    gradient: "from-cold/80 to-accent-secondary/60",
+  },
 }
 
 // Content type config
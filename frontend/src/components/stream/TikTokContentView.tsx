@@ -35,8 +35,11 @@ function ContentLoadingState({
 
   const statusMessages = {
     COLD: "Spinning up container...",
+    WARMING: "Spinning up container...",
     WARM: "Almost ready...",
     HOT: "Loading content...",
+    COOLING: "Spinning up container...",
+    FAILED: "Container failed to start",
   }
 
   return (
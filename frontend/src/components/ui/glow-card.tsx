@@ -13,6 +13,9 @@ const glowCardVariants = cva(
         HOT: "border-hot/30 hover:border-hot/50",
         WARM: "border-warm/30 hover:border-warm/50",
         COLD: "border-cold/30 hover:border-cold/50",
+        WARMING: "border-warm/20 hover:border-warm/40",
+        COOLING: "border-cold/20 hover:border-cold/40",
+        FAILED: "border-destructive/30 hover:border-destructive/50",
         none: "border-border hover:border-border/80",
       },
       glow: {
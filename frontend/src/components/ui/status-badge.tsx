@@ -1,6 +1,6 @@
 import * as React from "react"
 import { cva, type VariantProps } from "class-variance-authority"
-import { Flame, Thermometer, Snowflake, type LucideIcon } from "lucide-react"
+import { Flame, Thermometer, Snowflake, Loader2, AlertCircle, type LucideIcon } from "lucide-react"
 
 import { cn } from "@/lib/utils"
 import type { ContainerStatus } from "@/types"
@@ -13,6 +13,9 @@ const statusBadgeVariants = cva(
         HOT: "bg-hot/20 text-hot border border-hot/30",
         WARM: "bg-warm/20 text-warm border border-warm/30",
         COLD: "bg-cold/20 text-cold border border-cold/30",
+        WARMING: "bg-warm/10 text-warm border border-warm/20",
+        COOLING: "bg-cold/10 text-cold border border-cold/20",
+        FAILED: "bg-destructive/20 text-destructive border border-destructive/30",
       },
       glow: {
         true: "",
@@ -47,12 +50,18 @@ const statusIconMap: Record<ContainerStatus, LucideIcon> = {
   HOT: Flame,
   WARM: Thermometer,
   COLD: Snowflake,
+  WARMING: Loader2,
+  COOLING: Snowflake,
+  FAILED: AlertCircle,
 }
 
 const statusLabelMap: Record<ContainerStatus, string> = {
   HOT: "Hot (Active)",
   WARM: "Warm (Standby)",
   COLD: "Cold (Scaled Down)",
+  WARMING: "Warming (Scaling Up)",
+  COOLING: "Cooling (Scaling Down)",
+  FAILED: "Failed",
 }
 
 export interface StatusBadgeProps
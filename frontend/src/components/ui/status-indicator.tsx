@@ -13,6 +13,9 @@ const statusIndicatorVariants = cva(
         HOT: "bg-hot",
         WARM: "bg-warm",
         COLD: "bg-cold",
+        WARMING: "bg-warm/60",
+        COOLING: "bg-cold/60",
+        FAILED: "bg-destructive",
       },
       size: {
         sm: "h-2 w-2",
@@ -53,6 +56,31 @@ const pulseVariants = {
       duration: 0,
     },
   },
+  WARMING: {
+    scale: [1, 1.2, 1],
+    opacity: [0.6, 1, 0.6],
+    transition: {
+      duration: 1,
+      repeat: Infinity,
+      ease: "easeInOut" as const,
+    },
+  },
+  COOLING: {
+    scale: 1,
+    opacity: [1, 0.6, 1],
+    transition: {
+      duration: 2,
+      repeat: Infinity,
+      ease: "easeInOut" as const,
+    },
+  },
+  FAILED: {
+    scale: 1,
+    opacity: 1,
+    transition: {
+      duration: 0,
+    },
+  },
 }
 
 const glowVariants = {
@@ -83,6 +111,15 @@ const glowVariants = {
   COLD: {
     boxShadow: "0 0 4px hsl(var(--status-cold))",
   },
+  WARMING: {
+    boxShadow: "0 0 3px hsl(var(--status-warm))",
+  },
+  COOLING: {
+    boxShadow: "0 0 3px hsl(var(--status-cold))",
+  },
+  FAILED: {
+    boxShadow: "0 0 4px hsl(var(--destructive))",
+  },
 }
 
 export interface StatusIndicatorProps
@@ -103,6 +140,9 @@ function StatusIndicator({
     HOT: "Active",
     WARM: "Standby",
     COLD: "Scaled Down",
+    WARMING: "Scaling Up",
+    COOLING: "Scaling Down",
+    FAILED: "Failed",
   }
 
   if (!animate) {
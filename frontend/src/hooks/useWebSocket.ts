@@ -9,6 +9,9 @@ import type {
   StreamInjectPayload,
   ResourceAllocation,
   ActivationReadyPayload,
+  ActivationQueuedPayload,
+  ActivationCancelledPayload,
+  ActivationFailedPayload,
   ActivationSpineEvent,
   ErrorPayload,
   ScrollUpdatePayload,
@@ -22,16 +25,23 @@ import type {
   TelemetrySnapshot,
 } from '../types';
 
+// Must stay well under the server's LEASE_TIMEOUT_MS
+const HEARTBEAT_INTERVAL_MS = 15000;
+
 interface UseWebSocketOptions {
   url: string;
   onConnectionEstablished?: (payload: ConnectionEstablishedPayload) => void;
   onDecisionMade?: (payload: AIDecision) => void;
+  onDecisionUpdated?: (payload: AIDecision) => void;
   onContainerStateChange?: (payload: ContainerStateChangePayload) => void;
   onScoreUpdate?: (payload: ScoreUpdatePayload) => void;
   onModeChange?: (payload: ModeChangePayload) => void;
   onStreamInject?: (payload: StreamInjectPayload) => void;
   onResourceUpdate?: (payload: ResourceAllocation) => void;
   onActivationReady?: (payload: ActivationReadyPayload) => void;
+  onActivationQueued?: (payload: ActivationQueuedPayload) => void;
+  onActivationCancelled?: (payload: ActivationCancelledPayload) => void;
+  onActivationFailed?: (payload: ActivationFailedPayload) => void;
   onActivationSpine?: (payload: ActivationSpineEvent) => void;
   onSocialEvent?: (payload: SocialEvent) => void;
   onEngagementUpdate?: (payload: EngagementSummary) => void;
@@ -46,12 +56,16 @@ export function useWebSocket(options: UseWebSocketOptions) {
     url,
     onConnectionEstablished,
     onDecisionMade,
+    onDecisionUpdated,
     onContainerStateChange,
     onScoreUpdate,
     onModeChange,
     onStreamInject,
     onResourceUpdate,
     onActivationReady,
+    onActivationQueued,
+    onActivationCancelled,
+    onActivationFailed,
     onActivationSpine,
     onSocialEvent,
     onEngagementUpdate,
@@ -116,6 +130,10 @@ export function useWebSocket(options: UseWebSocketOptions) {
         onDecisionMade?.(message.payload as AIDecision);
         break;
 
+      case 'decision_updated':
+        onDecisionUpdated?.(message.payload as AIDecision);
+        break;
+
       case 'container_state_change':
         onContainerStateChange?.(message.payload as ContainerStateChangePayload);
         break;
@@ -140,6 +158,18 @@ export function useWebSocket(options: UseWebSocketOptions) {
         onActivationReady?.(message.payload as ActivationReadyPayload);
         break;
 
+      case 'activation_queued':
+        onActivationQueued?.(message.payload as ActivationQueuedPayload);
+        break;
+
+      case 'activation_cancelled':
+        onActivationCancelled?.(message.payload as ActivationCancelledPayload);
+        break;
+
+      case 'activation_failed':
+        onActivationFailed?.(message.payload as ActivationFailedPayload);
+        break;
+
       case 'activation_spine':
         onActivationSpine?.(message.payload as ActivationSpineEvent);
         break;
@@ -174,12 +204,16 @@ export function useWebSocket(options: UseWebSocketOptions) {
   }, [
     onConnectionEstablished,
     onDecisionMade,
+    onDecisionUpdated,
     onContainerStateChange,
     onScoreUpdate,
     onModeChange,
     onStreamInject,
     onResourceUpdate,
     onActivationReady,
+    onActivationQueued,
+    onActivationCancelled,
+    onActivationFailed,
     onActivationSpine,
     onSocialEvent,
     onEngagementUpdate,
@@ -210,6 +244,13 @@ export function useWebSocket(options: UseWebSocketOptions) {
     }
   }, []);
 
+  // Keep the server's leases on activated content alive while connected
+  useEffect(() => {
+    if (!connected) return;
+    const interval = window.setInterval(() => send('heartbeat', {}), HEARTBEAT_INTERVAL_MS);
+    return () => clearInterval(interval);
+  }, [connected, send]);
+
   const sendScrollUpdate = useCallback((payload: ScrollUpdatePayload) => {
     send('scroll_update', payload);
   }, [send]);
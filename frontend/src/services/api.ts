@@ -10,10 +10,18 @@ import type {
 
 const API_BASE = '/api/v1';
 
+interface LeaderStatus {
+  enabled: boolean;
+  identity?: string;
+  leader?: string;
+  is_leader: boolean;
+}
+
 interface HealthResponse {
   status: string;
   redis: string;
   kubernetes: string;
+  leader: LeaderStatus;
 }
 
 interface ContainersResponse {
@@ -1,9 +1,9 @@
 // Content Types
-export type ContentType = 'GAME' | 'AI_SERVICE';
-export type ContainerStatus = 'COLD' | 'WARM' | 'HOT';
-export type OperationalMode = 'MIXED_STREAM_BROWSING' | 'GAME_FOCUS_MODE' | 'AI_SERVICE_MODE';
-export type TriggerType = 'CROSS_DOMAIN' | 'SWARM_BOOST' | 'PROACTIVE_WARM' | 'MODE_CHANGE' | 'RESOURCE_THROTTLE' | 'INITIAL_WARM' | 'LOOKAHEAD_WARM' | 'MANUAL';
-export type ActionType = 'INJECT_CONTENT' | 'SCALE_WARM' | 'SCALE_HOT' | 'THROTTLE_BACKGROUND' | 'CHANGE_MODE';
+export type ContentType = 'GAME' | 'AI_SERVICE' | 'VIDEO';
+export type ContainerStatus = 'COLD' | 'WARMING' | 'WARM' | 'HOT' | 'COOLING' | 'FAILED';
+export type OperationalMode = 'MIXED_STREAM_BROWSING' | 'GAME_FOCUS_MODE' | 'AI_SERVICE_MODE' | 'VIDEO_WATCHING_MODE';
+export type TriggerType = 'CROSS_DOMAIN' | 'SWARM_BOOST' | 'PROACTIVE_WARM' | 'MODE_CHANGE' | 'RESOURCE_THROTTLE' | 'INITIAL_WARM' | 'LOOKAHEAD_WARM' | 'MANUAL' | 'WORKLOAD_FAILURE';
+export type ActionType = 'INJECT_CONTENT' | 'SCALE_WARM' | 'SCALE_HOT' | 'THROTTLE_BACKGROUND' | 'CHANGE_MODE' | 'RETRY_WARM' | 'COOL_DOWN' | 'MARK_UNAVAILABLE';
 
 // Activation Spine Types
 export type ActivationPhase =
@@ -53,15 +53,32 @@ export interface InputScores {
   combined_score: number;
 }
 
+export interface DecisionCandidate {
+  content_id: string;
+  score: number;
+  selected: boolean;
+  reason?: string;
+}
+
+export interface DecisionExplanation {
+  rule_id: string;
+  thresholds?: Record<string, number>;
+  features?: Record<string, number>;
+  candidates?: DecisionCandidate[];
+}
+
 export interface AIDecision {
   decision_id: string;
   timestamp: string;
   trigger_type: TriggerType;
   affected_content_id: string;
   reasoning_text: string;
+  explanation?: DecisionExplanation;
   input_scores: InputScores;
   resulting_action: ActionType;
   success: boolean;
+  error?: string;
+  completed_at?: string; // unset while the action is pending
 }
 
 export interface TrendScore {
@@ -72,12 +89,26 @@ export interface TrendScore {
   manual_override: boolean;
 }
 
+export interface DeploymentResources {
+  deployment: string;
+  tier: 'active' | 'warm' | 'background';
+  replicas: number;
+  cpu_request_millis: number;
+  cpu_limit_millis: number;
+  memory_request_bytes: number;
+  memory_limit_bytes: number;
+  cpu_usage_millis?: number;
+  memory_usage_bytes?: number;
+}
+
 export interface ResourceAllocation {
   timestamp: string;
   active_allocation: number;
   warm_allocation: number;
   background_allocation: number;
   mode: string;
+  source: 'default' | 'requests' | 'usage';
+  deployments?: DeploymentResources[];
 }
 
 // WebSocket Event Types
@@ -150,6 +181,31 @@ export interface ActivationReadyPayload {
   content_id: string;
   endpoint_url: string;
   status: ContainerStatus;
+  instance_id?: string;
+  session_token?: string; // Sent as X-Session-Token to endpoint_url
+}
+
+export interface ActivationQueuedPayload {
+  content_id: string;
+  position: number;
+  queue_length: number;
+  eta_ms: number;
+}
+
+export interface ActivationCancelledPayload {
+  content_id: string;
+  reason: string;
+}
+
+export interface ActivationFailedPayload {
+  content_id: string;
+  reason: string;
+  message?: string;
+  pod_name?: string;
+  restarts: number;
+  fallback: 'RETRY_WARM' | 'COOL_DOWN' | 'MARK_UNAVAILABLE';
+  attempt?: number;
+  retry_in_ms?: number;
 }
 
 export interface ErrorPayload {
@@ -203,9 +259,11 @@ export type ProofEventType =
   | 'hot_state_entered'
   | 'execution_ready'
   | 'restore_start'
-  | 'restore_complete';
+  | 'restore_complete'
+  | 'cooling_start'
+  | 'cold_state_entered';
 
-export type ActivationPathType = 'COLD_PATH' | 'PREWARM_PATH' | 'RESTORE_PATH';
+export type ActivationPathType = 'COLD_PATH' | 'PREWARM_PATH' | 'POOL_PATH' | 'RESTORE_PATH';
 
 export interface ProofSignalEvent {
   event_id: string;
@@ -254,18 +312,25 @@ export interface WebSocketMessage<T = unknown> {
 export type WSEventType =
   | 'connection_established'
   | 'decision_made'
+  | 'decision_updated'
   | 'container_state_change'
   | 'score_update'
   | 'mode_change'
   | 'stream_inject'
   | 'resource_update'
   | 'activation_ready'
+  | 'activation_queued'
+  | 'activation_cancelled'
+  | 'activation_failed'
   | 'activation_spine'
   | 'social_event'
   | 'engagement_update'
   | 'user_activity'
   | 'proof_signal'
   | 'telemetry_update'
+  | 'content_added'
+  | 'content_updated'
+  | 'content_removed'
   | 'error';
 
 // Application State
@@ -72,6 +72,7 @@ export default function FeedScreen() {
     updateScore,
     setCurrentMode,
     addDecision,
+    updateDecision,
     setResources,
     setSessionId,
     setConnected,
@@ -152,6 +153,9 @@ export default function FeedScreen() {
     onDecisionMade: (decision) => {
       addDecision(decision);
     },
+    onDecisionUpdated: (decision) => {
+      updateDecision(decision);
+    },
     onStreamInject: (payload) => {
       injectContent(payload.content, payload.insert_position);
     },
@@ -161,6 +165,9 @@ export default function FeedScreen() {
     onActivationReady: (payload) => {
       updateContainerState(payload.content_id, payload.status);
     },
+    onActivationQueued: (payload) => {
+      console.log(`Activation queued: ${payload.content_id} at ${payload.position}/${payload.queue_length}`);
+    },
   });
 
   // Expose WebSocket send globally for screen tracking
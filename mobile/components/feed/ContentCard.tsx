@@ -30,8 +30,11 @@ const typeIcons: Record<string, keyof typeof Ionicons.glyphMap> = {
 
 const statusColors: Record<ContainerStatus, string> = {
   COLD: '#60a5fa',
+  WARMING: '#fcd34d',
   WARM: '#fbbf24',
   HOT: '#34d399',
+  COOLING: '#93c5fd',
+  FAILED: '#f87171',
 };
 
 function LoadingState({
@@ -119,7 +122,7 @@ export function ContentCard({
   const orchItem = item.data;
   const status = containerStatus || orchItem.container_status;
   const isReady = status === 'HOT';
-  const isLoading = status === 'COLD' || status === 'WARM';
+  const isLoading = status !== 'HOT' && status !== 'FAILED';
   const [timedOut, setTimedOut] = useState(false);
   const [overlayVisible, setOverlayVisible] = useState(true);
   const hideTimerRef = useRef<ReturnType<typeof setTimeout>>();
@@ -11,6 +11,9 @@ import type {
   StreamInjectPayload,
   ResourceAllocation,
   ActivationReadyPayload,
+  ActivationQueuedPayload,
+  ActivationCancelledPayload,
+  ActivationFailedPayload,
   ErrorPayload,
   ScrollUpdatePayload,
   FocusEventPayload,
@@ -18,15 +21,22 @@ import type {
   DemoControlPayload,
 } from '@/types';
 
+// Must stay well under the server's LEASE_TIMEOUT_MS
+const HEARTBEAT_INTERVAL_MS = 15000;
+
 interface UseWebSocketOptions {
   onConnectionEstablished?: (payload: ConnectionEstablishedPayload) => void;
   onDecisionMade?: (payload: AIDecision) => void;
+  onDecisionUpdated?: (payload: AIDecision) => void;
   onContainerStateChange?: (payload: ContainerStateChangePayload) => void;
   onScoreUpdate?: (payload: ScoreUpdatePayload) => void;
   onModeChange?: (payload: ModeChangePayload) => void;
   onStreamInject?: (payload: StreamInjectPayload) => void;
   onResourceUpdate?: (payload: ResourceAllocation) => void;
   onActivationReady?: (payload: ActivationReadyPayload) => void;
+  onActivationQueued?: (payload: ActivationQueuedPayload) => void;
+  onActivationCancelled?: (payload: ActivationCancelledPayload) => void;
+  onActivationFailed?: (payload: ActivationFailedPayload) => void;
   onError?: (payload: ErrorPayload) => void;
 }
 
@@ -53,6 +63,9 @@ export function useWebSocket(options: UseWebSocketOptions) {
         case 'decision_made':
           opts.onDecisionMade?.(message.payload as AIDecision);
           break;
+        case 'decision_updated':
+          opts.onDecisionUpdated?.(message.payload as AIDecision);
+          break;
         case 'container_state_change':
           opts.onContainerStateChange?.(
             message.payload as ContainerStateChangePayload
@@ -73,6 +86,15 @@ export function useWebSocket(options: UseWebSocketOptions) {
         case 'activation_ready':
           opts.onActivationReady?.(message.payload as ActivationReadyPayload);
           break;
+        case 'activation_queued':
+          opts.onActivationQueued?.(message.payload as ActivationQueuedPayload);
+          break;
+        case 'activation_cancelled':
+          opts.onActivationCancelled?.(message.payload as ActivationCancelledPayload);
+          break;
+        case 'activation_failed':
+          opts.onActivationFailed?.(message.payload as ActivationFailedPayload);
+          break;
         case 'error':
           opts.onError?.(message.payload as ErrorPayload);
           break;
@@ -139,6 +161,13 @@ export function useWebSocket(options: UseWebSocketOptions) {
     }
   }, []);
 
+  // Keep the server's leases on activated content alive while connected
+  useEffect(() => {
+    if (!connected) return;
+    const interval = setInterval(() => send('heartbeat', {}), HEARTBEAT_INTERVAL_MS);
+    return () => clearInterval(interval);
+  }, [connected, send]);
+
   const sendScrollUpdate = useCallback(
     (payload: ScrollUpdatePayload) => {
       send('scroll_update', payload);
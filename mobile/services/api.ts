@@ -24,10 +24,18 @@ function getBaseUrl(): string {
 
 const API_BASE = getBaseUrl();
 
+interface LeaderStatus {
+  enabled: boolean;
+  identity?: string;
+  leader?: string;
+  is_leader: boolean;
+}
+
 interface HealthResponse {
   status: string;
   redis: string;
   kubernetes: string;
+  leader: LeaderStatus;
 }
 
 interface ContainersResponse {
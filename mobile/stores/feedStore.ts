@@ -38,6 +38,7 @@ interface FeedState {
   updateScore: (contentId: string, scores: InputScores) => void;
   setCurrentMode: (mode: OperationalMode) => void;
   addDecision: (decision: AIDecision) => void;
+  updateDecision: (decision: AIDecision) => void;
   setResources: (resources: ResourceAllocation) => void;
   setActiveContentId: (id: string | null) => void;
   setCurrentIndex: (index: number) => void;
@@ -89,6 +90,13 @@ export const useFeedStore = create<FeedState>()(
         decisions: [decision, ...state.decisions].slice(0, 100),
       })),
 
+    updateDecision: (decision) =>
+      set((state) => ({
+        decisions: state.decisions.map((d) =>
+          d.decision_id === decision.decision_id ? decision : d
+        ),
+      })),
+
     setResources: (resources) => set({ resources }),
     setActiveContentId: (id) => set({ activeContentId: id }),
     setCurrentIndex: (index) => set({ currentIndex: index }),
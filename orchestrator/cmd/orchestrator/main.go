@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 	"os"
@@ -14,7 +16,10 @@ import (
 	"github.com/gavigo/orchestrator/internal/engine"
 	"github.com/gavigo/orchestrator/internal/k8s"
 	"github.com/gavigo/orchestrator/internal/models"
+	"github.com/gavigo/orchestrator/internal/orchestrator"
+	"github.com/gavigo/orchestrator/internal/replay"
 	"github.com/gavigo/orchestrator/internal/websocket"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 func main() {
@@ -25,378 +30,358 @@ func main() {
 	log.Printf("Configuration loaded: port=%s", cfg.Port)
 
 	// Initialize K8s client and throttler (optional - for K8s environments)
+	var k8sClient *k8s.Client
 	var throttler *k8s.Throttler
 	namespace := os.Getenv("K8S_NAMESPACE")
 	if namespace == "" {
 		namespace = "gavigo"
 	}
 
-	if k8sClient, err := k8s.NewClient(namespace); err != nil {
+	if client, err := k8s.NewClient(namespace); err != nil {
 		log.Printf("K8s client not available (running in local mode): %v", err)
 	} else {
 		log.Println("K8s client initialized successfully")
-		throttler = k8s.NewThrottler(k8sClient, k8s.DefaultThrottleConfig())
+		k8sClient = client
+
+		// Resource settings are validated here so a bad value fails startup
+		// rather than the first throttle
+		throttleConfig := k8s.DefaultThrottleConfig()
+		if cfg.ThrottleConfigFile != "" {
+			if throttleConfig, err = k8s.LoadThrottleConfig(cfg.ThrottleConfigFile); err != nil {
+				log.Fatalf("Invalid throttle config: %v", err)
+			}
+		}
+		if cfg.ThrottleResizePolicy != "" {
+			throttleConfig.ResizePolicy = cfg.ThrottleResizePolicy
+		}
+		if cfg.ThrottleBackend != "" {
+			throttleConfig.DefaultBackend = cfg.ThrottleBackend
+		}
+		if throttler, err = k8s.NewThrottler(k8sClient, throttleConfig); err != nil {
+			log.Fatalf("Failed to create throttler: %v", err)
+		}
 	}
 
-	// Define workload deployment names
-	workloadDeployments := []string{"ai-service"}
-
-	// Initialize scorer
-	scorer := engine.NewScorer(nil)
-	scorer.StartDecay()
-
-	// Initialize rules engine
-	rulesEngine := engine.NewRulesEngine(nil)
-
 	// Initialize WebSocket hub
 	hub := websocket.NewHub()
 	go hub.Run()
 
-	// Initialize API handlers
-	handlers := api.NewHandlers(scorer)
-
-	// Initialize social store and handlers
-	socialStore := models.NewSocialStore()
-	socialHandlers := api.NewSocialHandlers(socialStore, hub)
-
-	// Initialize message handler
-	msgHandler := websocket.NewMessageHandler(hub)
-	msgHandler.Setup()
-
-	// Initialize activation spine
-	spine := engine.NewActivationSpine(func(event *models.ActivationSpineEvent) {
-		hub.BroadcastActivationSpine(event)
-	})
+	// Background informers run until shutdown
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	defer stopWatching()
+
+	// Elect one replica to scale and throttle workloads (LEADER_ELECTION_ENABLED);
+	// followers keep serving WebSocket and REST traffic
+	var elector *k8s.LeaderElector
+	if k8sClient != nil && cfg.LeaderElectionEnabled {
+		electionConfig := k8s.DefaultLeaderElectionConfig()
+		electionConfig.LeaseName = cfg.LeaderElectionLease
+		electionConfig.Identity = cfg.LeaderElectionIdentity
+		electionConfig.LeaseDuration = time.Duration(cfg.LeaderLeaseDurationMs) * time.Millisecond
+		electionConfig.RenewDeadline = time.Duration(cfg.LeaderRenewDeadlineMs) * time.Millisecond
+		electionConfig.RetryPeriod = time.Duration(cfg.LeaderRetryPeriodMs) * time.Millisecond
+		electionConfig.IntentTTL = time.Duration(cfg.LeaderIntentTTLMs) * time.Millisecond
+		var err error
+		if elector, err = k8s.NewLeaderElector(k8sClient, electionConfig); err != nil {
+			log.Fatalf("Failed to create leader elector: %v", err)
+		}
+	}
 
-	// Initialize proof signal manager
-	proofManager := engine.NewProofSignalManager(cfg, func(event *models.ProofSignalEvent) {
-		hub.BroadcastProofSignal(event)
-	}, func(snapshot *models.TelemetrySnapshot) {
-		hub.BroadcastTelemetryUpdate(snapshot)
-	})
-	handlers.SetProofManager(proofManager)
+	// Declare content with OrchestratedContent resources (CONTENT_CRD_ENABLED);
+	// their deployments are reconciled and their pods labelled for the watcher
+	var contentController *k8s.ContentController
+	if k8sClient != nil && cfg.ContentCRDEnabled {
+		podLabels, err := labels.ConvertSelectorToLabelsMap(cfg.WatchPodSelector)
+		if err != nil {
+			log.Fatalf("WATCH_POD_SELECTOR must be equality-based to label declared pods: %v", err)
+		}
+		controllerConfig := k8s.DefaultContentControllerConfig()
+		controllerConfig.PodLabels = podLabels
+		controllerConfig.DeploymentLabel = cfg.WatchDeploymentLabel
+		if contentController, err = k8s.NewContentController(k8sClient, controllerConfig); err != nil {
+			log.Fatalf("Failed to create content controller: %v", err)
+		}
+		if throttler != nil {
+			contentController.OnResources = throttler.SetDeploymentResources
+		}
+		if elector != nil {
+			contentController.IsLeader = elector.IsLeader
+		}
+		if err := contentController.Start(watchCtx); err != nil {
+			log.Fatalf("Failed to start content controller: %v", err)
+		}
+	}
 
-	// Wire up callbacks
-	rulesEngine.OnDecision = func(decision *models.AIDecision) {
-		handlers.AddDecision(decision)
-		hub.BroadcastDecision(decision)
-		proofManager.OnDecisionMade(decision)
+	// Initialize content catalog (embedded content unless a catalog file or
+	// OrchestratedContent resources are configured)
+	var catalogSource models.CatalogSource
+	switch {
+	case contentController != nil:
+		catalogSource = contentController
+	case cfg.CatalogFile != "":
+		catalogSource = &models.FileSource{Path: cfg.CatalogFile}
+	}
+	catalog, err := models.NewContentCatalog(catalogSource)
+	if err != nil {
+		log.Fatalf("Failed to load content catalog: %v", err)
 	}
+	log.Printf("Content catalog loaded: %d items", len(catalog.List()))
 
-	rulesEngine.OnScaleAction = func(contentID string, targetState models.ContainerStatus) {
-		// Read old state before updating
-		oldState := models.StatusCold
-		if content := handlers.GetContentByID(contentID); content != nil {
-			oldState = content.ContainerStatus
-		}
+	// Initialize decision audit log (in memory unless an audit file is configured)
+	decisionLog, err := models.NewDecisionAuditLog(cfg.DecisionAuditFile, cfg.DecisionAuditMaxEntries)
+	if err != nil {
+		log.Fatalf("Failed to open decision audit log: %v", err)
+	}
 
-		handlers.UpdateContainerState(contentID, targetState)
-		hub.BroadcastContainerStateChange(contentID, oldState, targetState)
-		proofManager.OnContainerStateChange(contentID, oldState, targetState)
+	// Engine randomness (RANDOM_SEED makes simulated delays reproducible)
+	rng := engine.NewTimeSeededRand()
+	if cfg.RandomSeed != 0 {
+		rng = engine.NewRand(cfg.RandomSeed)
+	}
 
-		// Spine: record phase based on target state
-		if targetState == models.StatusWarm {
-			spine.RecordPhase(contentID, "", models.PhasePreWarm, "scale_action", models.WeightPreview, false)
+	// Initialize the orchestration service (scoring, rules, container lifecycle)
+	service := orchestrator.NewService(cfg, catalog.List(), hub, &orchestrator.Options{
+		Rand:      rng,
+		Decisions: decisionLog,
+	})
 
-			// Schedule simulated PREVIEW_READY after startup delay
-			content := handlers.GetContentByID(contentID)
-			contentType := models.ContentTypeGame
-			if content != nil {
-				contentType = content.Type
+	// Wire up resource throttling (only if K8s is available)
+	if throttler != nil {
+		service.Throttle = func(ctx context.Context, mode models.OperationalMode, activeDeployment string) error {
+			// Workloads opt in with the gavigo.io/managed=true label
+			workloadDeployments, err := throttler.ManagedDeployments(ctx)
+			if err != nil {
+				return err
 			}
-			go func() {
-				delay := engine.SimulatedStartupDelay(contentType)
-				time.Sleep(delay)
-				spine.RecordPhase(contentID, "", models.PhasePreviewReady, "container_ready_simulated", models.WeightPreview, true)
-				proofManager.OnPreviewReady(contentID)
-			}()
-		} else if targetState == models.StatusHot {
-			spine.RecordPhase(contentID, "", models.PhaseHot, "scale_action", models.WeightFull, false)
+			if mode == models.ModeGameFocus || mode == models.ModeAIServiceFocus {
+				// Throttle background workloads when entering game or AI focus
+				return throttler.ThrottleForForeground(ctx, activeDeployment, workloadDeployments)
+			}
+			// Restore resources when returning to mixed browsing or watching video
+			return throttler.RestoreResources(ctx, workloadDeployments)
 		}
 	}
 
-	rulesEngine.OnModeChange = func(oldMode, newMode models.OperationalMode, reason string) {
-		handlers.SetMode(newMode)
-		hub.BroadcastModeChange(oldMode, newMode, reason)
+	// Report resource allocation from the workloads' real requests, limits and
+	// (RESOURCE_METRICS_ENABLED) usage
+	if k8sClient != nil {
+		service.Resources = func(ctx context.Context, deployments []string) ([]models.DeploymentResources, bool, error) {
+			return k8sClient.DeploymentResources(ctx, deployments, cfg.ResourceMetricsEnabled)
+		}
 	}
 
-	rulesEngine.OnInject = func(content *models.ContentItem, position int, reason string) {
-		hub.BroadcastStreamInject(content, position, reason)
+	// Give each session its own workload instance (INSTANCE_MODE=dedicated|pooled)
+	var instances *k8s.InstanceAllocator
+	if cfg.InstanceMode != "" && cfg.InstanceMode != models.InstanceDedicated && cfg.InstanceMode != models.InstancePooled {
+		log.Fatalf("Invalid INSTANCE_MODE %q: want %q or %q", cfg.InstanceMode, models.InstanceDedicated, models.InstancePooled)
+	}
+	if k8sClient != nil && cfg.InstanceMode != "" {
+		instanceConfig := k8s.DefaultInstanceConfig()
+		instanceConfig.Mode = cfg.InstanceMode
+		instanceConfig.SlotsPerPod = cfg.InstanceSlotsPerPod
+		instances = k8s.NewInstanceAllocator(k8sClient, instanceConfig)
+		service.Instances = instances
+		log.Printf("Per-session workload instances enabled: mode=%s", cfg.InstanceMode)
 	}
 
-	// Wire up resource throttling (only if K8s is available)
-	rulesEngine.OnThrottleAction = func(activeContentID string, mode models.OperationalMode) {
-		if throttler == nil {
-			log.Printf("Throttle action requested but K8s not available (simulated mode)")
-			// Broadcast resource allocation update for visualization
-			allocation := models.DefaultResourceAllocation(mode)
-			hub.BroadcastResourceUpdate(&allocation)
-			return
+	// Keep generic pods warm for cold activations (POOL_TEMPLATES=runtime=deployment,...)
+	var warmPool *k8s.WarmPool
+	if k8sClient != nil && cfg.PoolTemplates != "" {
+		templates, err := k8s.ParsePoolTemplates(cfg.PoolTemplates)
+		if err != nil {
+			log.Fatalf("Invalid POOL_TEMPLATES: %v", err)
 		}
+		warmPool = k8s.NewWarmPool(k8sClient, k8s.WarmPoolConfig{Templates: templates})
+		service.Pool = warmPool
+		log.Printf("Warm pool enabled for %d runtime classes", len(templates))
+	}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
-
-		if mode == models.ModeGameFocus || mode == models.ModeAIServiceFocus {
-			// Throttle background workloads when entering focused mode
-			content := handlers.GetContentByID(activeContentID)
-			deploymentName := ""
-			if content != nil {
-				deploymentName = content.DeploymentName
+	// Scale declared deployments with the state of their content, and pooled
+	// deployments up for the instance slots sessions hold
+	if contentController != nil || instances != nil {
+		service.Scale = func(ctx context.Context, deployment string, status models.ContainerStatus, slots int) error {
+			var minReplicas int32
+			if instances != nil {
+				minReplicas = instances.ReplicasForSlots(slots)
 			}
-			if err := throttler.ThrottleForForeground(ctx, deploymentName, workloadDeployments); err != nil {
-				log.Printf("Warning: throttle action failed: %v", err)
-			}
-		} else {
-			// Restore resources when returning to mixed browsing
-			if err := throttler.RestoreResources(ctx, workloadDeployments); err != nil {
-				log.Printf("Warning: restore resources failed: %v", err)
+			if contentController != nil {
+				return contentController.Scale(ctx, deployment, status, minReplicas)
 			}
+			return k8sClient.EnsureReplicas(ctx, deployment, minReplicas)
 		}
-
-		// Broadcast resource allocation update
-		allocation := models.DefaultResourceAllocation(mode)
-		hub.BroadcastResourceUpdate(&allocation)
-		log.Printf("Resource throttling applied for mode: %s", mode)
 	}
 
-	scorer.OnScoreUpdate = func(contentID string, scores *models.InputScores) {
-		content := handlers.GetContentByID(contentID)
-		var currentState models.ContainerStatus
-		if content != nil {
-			currentState = content.ContainerStatus
+	// Every replica shares the workload state it wants; the leader scales and
+	// throttles for all of them, also once it is elected
+	if elector != nil {
+		service.IsLeader = elector.IsLeader
+		service.Publish = elector.Publish
+		service.Intents = elector.Intents
+		elector.OnIntents = service.HandleIntents
+		elector.OnElected = func(ctx context.Context) {
+			if contentController != nil {
+				contentController.Resync()
+			}
+			service.HandleElected()
 		}
-		rulesEngine.ProcessScoreUpdate(contentID, scores, currentState)
-		hub.BroadcastScoreUpdate(contentID, scores)
 	}
 
-	// Engagement tracking state for throttled broadcasting
-	type engagementState struct {
-		focusCount      int
-		themeFocusTimes map[string]int
-		scrollPosition  int
-		scrollVelocity  float64
-	}
-	engagementStates := make(map[string]*engagementState) // sessionID -> state
-
-	// Wire up message handlers
-	msgHandler.OnScrollUpdate = func(client *websocket.Client, position int, velocity float64, visibleContent []string) {
-		// Track scroll state for engagement broadcasts
-		if state, ok := engagementStates[client.SessionID]; ok {
-			state.scrollPosition = position
-			state.scrollVelocity = velocity
+	// Cache workload pods and deployments from the cluster, and report pods
+	// whose containers cannot run into the activation flow
+	if k8sClient != nil {
+		watcher := k8s.NewWatcher(k8sClient, k8s.WatcherConfig{
+			PodSelector:        cfg.WatchPodSelector,
+			DeploymentSelector: cfg.WatchDeploymentSelector,
+			DeploymentLabel:    cfg.WatchDeploymentLabel,
+			ResyncPeriod:       time.Duration(cfg.WatchResyncMs) * time.Millisecond,
+		})
+		watcher.OnPodStatus = func(status k8s.PodStatus) {
+			switch {
+			case status.Deleted:
+			case status.Failure != "":
+				service.HandlePodFailure(models.PodFailure{
+					Deployment: status.DeploymentName,
+					Pod:        status.PodName,
+					Reason:     status.Failure,
+					Message:    status.FailureMessage,
+					Restarts:   status.Restarts,
+				})
+			case status.Ready:
+				service.HandlePodReady(status.DeploymentName, status.PodName, status.Restarts)
+			}
 		}
-
-		// Convert content to pointers for rules engine
-		allContent := handlers.GetContent()
-		contentPtrs := make([]*models.ContentItem, len(allContent))
-		for i := range allContent {
-			contentPtrs[i] = &allContent[i]
+		if err := k8sClient.StartWatcher(watchCtx, watcher); err != nil {
+			log.Printf("Pod watcher not ready, reading pods directly until it syncs: %v", err)
 		}
+	}
 
-		// Process scroll update for lookahead warming
-		rulesEngine.ProcessScrollUpdate(visibleContent, contentPtrs)
-
-		log.Printf("Scroll update: position=%d, velocity=%.2f, visible=%d items",
-			position, velocity, len(visibleContent))
+	// Initialize API handlers
+	handlers := api.NewHandlers(service.Scorer(), catalog, service.State(), service.StateMachine())
+	handlers.SetDecisionLog(decisionLog)
+	handlers.SetProofManager(service.ProofManager())
+	handlers.GetSession = service.Session
+	handlers.GetLeaseCounts = service.LeaseCounts
+	handlers.GetUnavailable = service.Unavailable
+	handlers.GetPoolStatus = service.PoolStatus
+	handlers.GetResourceAllocation = service.ResourceAllocation
+	if throttler != nil {
+		handlers.GetResourceDrift = throttler.Drift
+	}
+	if k8sClient != nil {
+		handlers.GetPodStatuses = k8sClient.GetAllPodStatuses
+	}
+	if instances != nil {
+		handlers.GetInstances = instances.Instances
+		handlers.RouteInstance = instances.Route
+	}
+	if warmPool != nil {
+		handlers.RoutePooled = warmPool.Route
+	}
+	if elector != nil {
+		handlers.GetLeaderStatus = elector.Status
+	}
+	handlers.OnTrendSpike = func(contentID string, viralScore float64) {
+		service.HandleTrendSpike(contentID, viralScore, nil)
 	}
 
-	msgHandler.OnFocusEvent = func(client *websocket.Client, contentID string, durationMS int, theme string) {
-		scores := scorer.RecordFocusEvent(client.SessionID, contentID, durationMS, theme)
+	// Initialize social store and handlers
+	socialStore := models.NewSocialStore()
+	socialHandlers := api.NewSocialHandlers(socialStore, hub)
+	socialHandlers.ContentItemHandler = handlers.HandleContentItem
 
-		// Get content and session for rules processing
-		content := handlers.GetContentByID(contentID)
-		if content == nil {
-			log.Printf("Content not found: %s", contentID)
-			return
-		}
+	// Initialize message handler
+	msgHandler := websocket.NewMessageHandler(hub)
+	msgHandler.Setup()
 
-		// Spine: record INTENT if combined score > 0.3 and no INTENT yet
-		if scores.CombinedScore > 0.3 && !spine.HasIntent(contentID) {
-			spine.RecordPhase(contentID, client.SessionID, models.PhaseIntent, "focus_engagement", models.WeightIdle, false)
-			proofManager.OnIntentDetected(contentID)
-		}
-
-		// Track engagement and broadcast every 3rd focus event (throttle: ~3s)
-		state, ok := engagementStates[client.SessionID]
-		if !ok {
-			state = &engagementState{themeFocusTimes: make(map[string]int)}
-			engagementStates[client.SessionID] = state
-		}
-		state.focusCount++
-		state.themeFocusTimes[theme] += durationMS
-
-		if state.focusCount%3 == 0 {
-			hub.BroadcastEngagement(&models.EngagementSummary{
-				SessionID:          client.SessionID,
-				ActiveContentID:    contentID,
-				ActiveContentTitle: content.Title,
-				FocusDurationMs:    durationMS,
-				Theme:              theme,
-				ScrollPosition:     state.scrollPosition,
-				ScrollVelocity:     state.scrollVelocity,
-				ThemeFocusTimes:    state.themeFocusTimes,
-				Timestamp:          time.Now(),
-			})
+	// Record inbound events, disconnects and catalog changes for offline replay (see cmd/simulator)
+	var recorder *replay.Recorder
+	if cfg.ReplayRecordFile != "" {
+		recorder, err = replay.NewRecorder(cfg.ReplayRecordFile, service.State().Content())
+		if err != nil {
+			log.Fatalf("Failed to open replay recording: %v", err)
 		}
-
-		// Create a simple session for rules processing
-		session := models.NewSession(client.SessionID)
-
-		// Convert content slice to pointer slice
-		allContent := handlers.GetContent()
-		contentPtrs := make([]*models.ContentItem, len(allContent))
-		for i := range allContent {
-			contentPtrs[i] = &allContent[i]
+		msgHandler.OnMessage = func(client *websocket.Client, messageType string, payload json.RawMessage) {
+			if err := recorder.Record(client.SessionID, messageType, payload); err != nil {
+				log.Printf("Error recording %s: %v", messageType, err)
+			}
 		}
+		log.Printf("Recording WebSocket events, disconnects and catalog changes to %s", cfg.ReplayRecordFile)
+	}
 
-		// Get all scores
-		allScores := scorer.GetAllScores(client.SessionID)
+	// Drop queued activations of clients that went away
+	hub.SetDisconnectHandler(func(client *websocket.Client) {
+		if recorder != nil {
+			if err := recorder.RecordDisconnect(client.SessionID); err != nil {
+				log.Printf("Error recording disconnect: %v", err)
+			}
+		}
+		service.Disconnect(client.SessionID)
+	})
+	handlers.OnReset = func() {
+		service.Reset()
+		if recorder != nil {
+			if err := recorder.RecordCatalog(service.State().Content()); err != nil {
+				log.Printf("Error recording catalog: %v", err)
+			}
+		}
+	}
 
-		rulesEngine.ProcessFocusEvent(session, content, durationMS, contentPtrs, allScores)
+	// Wire up message handlers
+	msgHandler.OnScrollUpdate = func(client *websocket.Client, position int, velocity float64, visibleContent []string) {
+		service.HandleScroll(client.SessionID, position, velocity, visibleContent)
+	}
 
-		log.Printf("Focus event processed: session=%s, content=%s, duration=%dms, score=%.2f",
-			client.SessionID, contentID, durationMS, scores.CombinedScore)
+	msgHandler.OnFocusEvent = func(client *websocket.Client, contentID string, durationMS int, theme string) {
+		if err := service.HandleFocus(client.SessionID, contentID, durationMS, theme); err != nil {
+			log.Printf("Focus event ignored: %v", err)
+		}
 	}
 
 	msgHandler.OnActivationRequest = func(client *websocket.Client, contentID string) {
-		content := handlers.GetContentByID(contentID)
-		if content == nil {
+		err := service.Activate(client.SessionID, client.UserID, contentID)
+		if errors.Is(err, orchestrator.ErrUnknownContent) {
 			log.Printf("Content not found for activation: %s", contentID)
 			return
 		}
-
-		// Record activation request in proof manager (classifies path)
-		proofManager.OnActivationRequest(contentID, content.ContainerStatus)
-
-		// Spine: check if this is a restore or fresh activation
-		isRestore := spine.IsPreviousHot(contentID)
-		if isRestore {
-			spine.RecordPhase(contentID, client.SessionID, models.PhaseRestoreStart, "session_reactivation", models.WeightFull, false)
-			proofManager.OnRestoreStart(contentID)
-		} else {
-			spine.RecordPhase(contentID, client.SessionID, models.PhaseActivating, "user_activation", models.WeightFull, false)
-		}
-
-		// Read old state before updating
-		oldState := content.ContainerStatus
-
-		// Scale to HOT
-		handlers.UpdateContainerState(contentID, models.StatusHot)
-		hub.BroadcastContainerStateChange(contentID, oldState, models.StatusHot)
-		proofManager.OnContainerStateChange(contentID, oldState, models.StatusHot)
-
-		// Spine: record completion phase
-		if isRestore {
-			go func() {
-				time.Sleep(engine.SimulatedRestoreDelay())
-				spine.RecordPhase(contentID, client.SessionID, models.PhaseRestoreComplete, "restore_complete", models.WeightFull, true)
-				proofManager.OnRestoreComplete(contentID)
-			}()
-		} else {
-			spine.RecordPhase(contentID, client.SessionID, models.PhaseHot, "activation_complete", models.WeightFull, false)
-		}
-
-		// Send activation ready
-		client.Send(websocket.Message{
-			Type: "activation_ready",
-			Payload: map[string]interface{}{
-				"content_id":   contentID,
-				"endpoint_url": "/workloads/" + content.DeploymentName,
-				"status":       models.StatusHot,
-			},
-		})
-
-		// Mark execution ready for non-restore paths
-		if !isRestore {
-			proofManager.OnExecutionReady(contentID)
+		if errors.Is(err, orchestrator.ErrContentUnavailable) {
+			log.Printf("Activation of unavailable content rejected: %s", contentID)
+			client.Send(websocket.Message{
+				Type: "error",
+				Payload: map[string]interface{}{
+					"code":    "CONTENT_UNAVAILABLE",
+					"message": "Content cannot be started until its workload is fixed",
+					"details": err.Error(),
+				},
+			})
+			return
+		}
+		if err != nil {
+			log.Printf("Activation rejected: %v", err)
+			client.Send(websocket.Message{
+				Type: "error",
+				Payload: map[string]interface{}{
+					"code":    "ACTIVATION_REJECTED",
+					"message": "Content cannot be activated in its current state",
+					"details": err.Error(),
+				},
+			})
 		}
-
-		log.Printf("Content activated: %s", contentID)
 	}
 
 	msgHandler.OnDeactivation = func(client *websocket.Client, contentID string) {
-		// Read old state before updating
-		oldState := models.StatusHot
-		if content := handlers.GetContentByID(contentID); content != nil {
-			oldState = content.ContainerStatus
+		if err := service.Deactivate(client.SessionID, contentID); err != nil {
+			log.Printf("Deactivation ignored: %v", err)
 		}
+	}
 
-		// Scale back to WARM
-		handlers.UpdateContainerState(contentID, models.StatusWarm)
-		hub.BroadcastContainerStateChange(contentID, oldState, models.StatusWarm)
-
-		// Spine: record deactivation and cooling
-		spine.RecordPhase(contentID, client.SessionID, models.PhaseDeactivating, "user_left", models.WeightPreview, false)
-		spine.RecordPhase(contentID, client.SessionID, models.PhaseCooling, "scaling_back", models.WeightIdle, false)
-		spine.MarkPreviousHot(contentID)
-		proofManager.OnDeactivation(contentID)
-
-		log.Printf("Content deactivated: %s", contentID)
+	msgHandler.OnHeartbeat = func(client *websocket.Client) {
+		service.Heartbeat(client.SessionID)
 	}
 
 	msgHandler.OnDemoControl = func(client *websocket.Client, action, targetContentID string, value float64) {
-		switch action {
-		case "trigger_trend_spike":
-			scorer.SetTrendScore(targetContentID, value, "RISING")
-			scores := scorer.GetScores(client.SessionID, targetContentID)
-			content := handlers.GetContentByID(targetContentID)
-			if content != nil {
-				rulesEngine.ProcessTrendSpike(targetContentID, value, scores, content.ContainerStatus)
-			}
-
-			// Emit a MANUAL decision for the trend spike
-			trendScores := models.InputScores{}
-			if scores != nil {
-				trendScores = *scores
-			}
-			trendDecision := models.NewDecision(
-				models.TriggerManual,
-				targetContentID,
-				"Manual demo control: trigger trend spike",
-				trendScores,
-				models.ActionScaleWarm,
-			)
-			trendDecision.Success = true
-			handlers.AddDecision(trendDecision)
-			hub.BroadcastDecision(trendDecision)
-			proofManager.OnDecisionMade(trendDecision)
-		case "reset_demo":
-			handlers.OnReset()
-			spine.Reset()
-			proofManager.Reset()
-		case "force_warm":
-			oldState := models.StatusCold
-			if c := handlers.GetContentByID(targetContentID); c != nil {
-				oldState = c.ContainerStatus
-			}
-			handlers.UpdateContainerState(targetContentID, models.StatusWarm)
-			hub.BroadcastContainerStateChange(targetContentID, oldState, models.StatusWarm)
-			proofManager.OnContainerStateChange(targetContentID, oldState, models.StatusWarm)
-
-			// Emit a MANUAL decision for the force-warm action
-			decision := models.NewDecision(
-				models.TriggerManual,
-				targetContentID,
-				"Manual demo control: force warm",
-				models.InputScores{},
-				models.ActionScaleWarm,
-			)
-			decision.Success = true
-			handlers.AddDecision(decision)
-			hub.BroadcastDecision(decision)
-			proofManager.OnDecisionMade(decision)
-		case "force_cold":
-			oldState := models.StatusCold
-			if c := handlers.GetContentByID(targetContentID); c != nil {
-				oldState = c.ContainerStatus
-			}
-			handlers.UpdateContainerState(targetContentID, models.StatusCold)
-			hub.BroadcastContainerStateChange(targetContentID, oldState, models.StatusCold)
-			proofManager.InvalidateAttempt(targetContentID)
+		if err := service.HandleDemoControl(client.SessionID, action, targetContentID, value); err != nil {
+			log.Printf("Demo control rejected: %v", err)
 		}
-
-		log.Printf("Demo control: action=%s, target=%s, value=%.2f", action, targetContentID, value)
 	}
 
 	msgHandler.OnScreenView = func(client *websocket.Client, screenName string) {
@@ -421,34 +406,43 @@ func main() {
 		log.Printf("User action: session=%s, action=%s, screen=%s, value=%s", client.SessionID, action, screen, value)
 	}
 
-	handlers.OnTrendSpike = func(contentID string, viralScore float64) {
-		scores := scorer.GetScores("default", contentID)
-		content := handlers.GetContentByID(contentID)
-		if content != nil {
-			rulesEngine.ProcessTrendSpike(contentID, viralScore, scores, content.ContainerStatus)
+	catalog.OnContentAdded = func(item models.ContentItem) {
+		if recorder != nil {
+			if err := recorder.RecordContentAdded(item); err != nil {
+				log.Printf("Error recording content_added: %v", err)
+			}
+		}
+		service.AddContent(item)
+	}
+	catalog.OnContentUpdated = func(item models.ContentItem) {
+		if recorder != nil {
+			if err := recorder.RecordContentUpdated(item); err != nil {
+				log.Printf("Error recording content_updated: %v", err)
+			}
 		}
+		service.UpdateContent(item)
+	}
+	catalog.OnContentRemoved = func(contentID string) {
+		if recorder != nil {
+			if err := recorder.RecordContentRemoved(contentID); err != nil {
+				log.Printf("Error recording content_removed: %v", err)
+			}
+		}
+		service.RemoveContent(contentID)
+	}
+	catalog.OnReloadError = func(err error) {
+		log.Printf("Catalog reload failed, keeping current catalog: %v", err)
 	}
 
-	handlers.OnReset = func() {
-		scorer.Reset()
-		spine.Reset()
-		proofManager.Reset()
-		log.Println("Full reset triggered via API")
+	// Start score decay, idle cool-down sweeps and initial warming, then
+	// campaign for leadership
+	service.Start()
+	if elector != nil {
+		elector.Start(watchCtx)
 	}
 
-	// Trigger initial warming for first 2 content items
-	// This ensures users see "Activate" instead of "Not Ready" on page load
-	go func() {
-		// Small delay to ensure everything is initialized
-		time.Sleep(100 * time.Millisecond)
-		allContent := handlers.GetContent()
-		contentPtrs := make([]*models.ContentItem, len(allContent))
-		for i := range allContent {
-			contentPtrs[i] = &allContent[i]
-		}
-		rulesEngine.ProcessInitialLoad(contentPtrs, 2)
-		log.Println("Initial warming completed for first 2 content items")
-	}()
+	// Pick up catalog file and OrchestratedContent edits without a restart
+	catalog.StartAutoReload(time.Duration(cfg.CatalogReloadMs) * time.Millisecond)
 
 	// Set up HTTP server
 	mux := http.NewServeMux()
@@ -461,7 +455,7 @@ func main() {
 
 	// WebSocket endpoint
 	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
-		websocket.ServeWs(hub, w, r, handlers.GetContent(), handlers.GetCurrentMode())
+		websocket.ServeWs(hub, w, r, service.State().Content(), service.State().Mode())
 	})
 
 	// Serve static files for frontend (in production)
@@ -482,6 +476,21 @@ func main() {
 		<-sigChan
 
 		log.Println("Shutting down server...")
+		service.Stop()
+		if elector != nil {
+			// Release the lease so another replica takes over right away
+			elector.Stop()
+		}
+		stopWatching()
+		catalog.StopAutoReload()
+		if err := decisionLog.Close(); err != nil {
+			log.Printf("Error closing decision audit log: %v", err)
+		}
+		if recorder != nil {
+			if err := recorder.Close(); err != nil {
+				log.Printf("Error closing replay recording: %v", err)
+			}
+		}
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
@@ -0,0 +1,165 @@
+// Command simulator replays a recorded WebSocket event stream through the
+// scoring and rules engines with alternative configurations and reports how
+// decisions, warm hit rate and container-hours would have changed.
+//
+// Record traffic by starting the orchestrator with REPLAY_RECORD_FILE set, then:
+//
+//	simulator -recording events.ndjson -scenario eager.json
+//
+// A scenario file overrides fields of the default configs by Go field name
+// (durations are in nanoseconds):
+//
+//	{"name": "eager", "engine": {"WarmThreshold": 0.4}, "scorer": {"DecayRate": 0.005}}
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gavigo/orchestrator/internal/engine"
+	"github.com/gavigo/orchestrator/internal/models"
+	"github.com/gavigo/orchestrator/internal/replay"
+)
+
+// scenarioFiles collects repeated -scenario flags
+type scenarioFiles []string
+
+func (s *scenarioFiles) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *scenarioFiles) Set(path string) error {
+	*s = append(*s, path)
+	return nil
+}
+
+func main() {
+	var scenarios scenarioFiles
+	recordingPath := flag.String("recording", "", "recording file written via REPLAY_RECORD_FILE")
+	seed := flag.Int64("seed", 1, "seed for simulated container startup delays")
+	asJSON := flag.Bool("json", false, "print results as JSON")
+	verbose := flag.Bool("v", false, "show engine logs")
+	flag.Var(&scenarios, "scenario", "scenario config file (repeatable)")
+	flag.Parse()
+
+	if *recordingPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	rec, err := replay.LoadRecording(*recordingPath)
+	if err != nil {
+		log.Fatalf("Failed to load recording: %v", err)
+	}
+
+	runs := []replay.Scenario{{Name: "baseline"}}
+	for _, path := range scenarios {
+		scenario, err := loadScenario(path)
+		if err != nil {
+			log.Fatalf("Failed to load scenario: %v", err)
+		}
+		runs = append(runs, scenario)
+	}
+
+	opts := replay.Options{Seed: *seed}
+	results := make([]*replay.Result, len(runs))
+	for i, scenario := range runs {
+		if !*verbose {
+			log.SetOutput(io.Discard)
+		}
+		results[i], err = replay.Simulate(rec, scenario, opts)
+		log.SetOutput(os.Stderr)
+		if err != nil {
+			log.Fatalf("Scenario %s failed: %v", scenario.Name, err)
+		}
+	}
+
+	if *asJSON {
+		printJSON(results)
+		return
+	}
+	printReport(rec, results)
+}
+
+// loadScenario reads a scenario file on top of the default configs
+func loadScenario(path string) (replay.Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return replay.Scenario{}, err
+	}
+
+	file := struct {
+		Name   string               `json:"name"`
+		Engine *engine.EngineConfig `json:"engine"`
+		Scorer *engine.ScorerConfig `json:"scorer"`
+	}{
+		Engine: engine.DefaultConfig(),
+		Scorer: engine.DefaultScorerConfig(),
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return replay.Scenario{}, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if file.Name == "" {
+		file.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return replay.Scenario{Name: file.Name, Engine: file.Engine, Scorer: file.Scorer}, nil
+}
+
+func printJSON(results []*replay.Result) {
+	type comparison struct {
+		Scenario string                `json:"scenario"`
+		Diff     []replay.DecisionDiff `json:"decision_diff"`
+	}
+	out := struct {
+		Results     []*replay.Result `json:"results"`
+		Comparisons []comparison     `json:"comparisons"`
+	}{Results: results, Comparisons: []comparison{}}
+	for _, result := range results[1:] {
+		out.Comparisons = append(out.Comparisons, comparison{
+			Scenario: result.Scenario,
+			Diff:     replay.DiffDecisions(results[0], result),
+		})
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		log.Fatalf("Failed to write results: %v", err)
+	}
+}
+
+func printReport(rec *replay.Recording, results []*replay.Result) {
+	fmt.Printf("Recording: %d events over %s\n\n", len(rec.Events), rec.End().Sub(rec.Start()).Round(time.Millisecond))
+
+	fmt.Printf("%-20s %10s %12s %10s %10s %10s %10s\n",
+		"SCENARIO", "DECISIONS", "ACTIVATIONS", "WARM HITS", "HOT HRS", "WARM HRS", "TOTAL HRS")
+	for _, r := range results {
+		fmt.Printf("%-20s %10d %12d %9.1f%% %10.3f %10.3f %10.3f\n",
+			r.Scenario, len(r.Decisions), r.Activations, 100*r.WarmHitRate(),
+			r.ContainerHours[models.StatusHot], r.ContainerHours[models.StatusWarm], r.TotalContainerHours())
+	}
+
+	baseline := results[0]
+	for _, r := range results[1:] {
+		fmt.Printf("\n%s vs %s:\n", r.Scenario, baseline.Scenario)
+		fmt.Printf("  warm hit rate   %+.1f pts\n", 100*(r.WarmHitRate()-baseline.WarmHitRate()))
+		fmt.Printf("  container-hours %+.3f\n", r.TotalContainerHours()-baseline.TotalContainerHours())
+
+		diffs := replay.DiffDecisions(baseline, r)
+		if len(diffs) == 0 {
+			fmt.Println("  decisions       identical")
+			continue
+		}
+		fmt.Println("  decisions       (baseline -> scenario)")
+		for _, d := range diffs {
+			fmt.Printf("    %3d -> %-3d %s\n", d.Baseline, d.Scenario, d.Key)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gavigo/orchestrator/internal/models"
+)
+
+// HandleContentItem handles GET/POST/PUT/DELETE /api/v1/content/:id
+func (h *Handlers) HandleContentItem(w http.ResponseWriter, r *http.Request, contentID string) {
+	switch r.Method {
+	case http.MethodGet:
+		content := h.GetContentByID(contentID)
+		if content == nil {
+			http.Error(w, models.ErrContentNotFound.Error(), http.StatusNotFound)
+			return
+		}
+		h.writeJSON(w, content)
+
+	case http.MethodPost, http.MethodPut:
+		var item models.ContentItem
+		if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if item.ID == "" {
+			item.ID = contentID
+		}
+		if item.ID != contentID {
+			http.Error(w, "Content ID in body does not match path", http.StatusBadRequest)
+			return
+		}
+
+		var saved models.ContentItem
+		var err error
+		if r.Method == http.MethodPost {
+			saved, err = h.catalog.Add(item)
+		} else {
+			saved, err = h.catalog.Update(item)
+		}
+		if err != nil {
+			h.writeCatalogError(w, err)
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			log.Printf("Content added: %s (%s)", saved.ID, saved.Type)
+			w.WriteHeader(http.StatusCreated)
+		} else {
+			log.Printf("Content updated: %s", saved.ID)
+		}
+		h.writeJSON(w, saved)
+
+	case http.MethodDelete:
+		if err := h.catalog.Remove(contentID); err != nil {
+			h.writeCatalogError(w, err)
+			return
+		}
+		log.Printf("Content removed: %s", contentID)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handlers) handleCatalogReload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	added, updated, removed, err := h.catalog.Reload()
+	if err != nil {
+		log.Printf("Catalog reload failed: %v", err)
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	log.Printf("Catalog reloaded: added=%d, updated=%d, removed=%d", added, updated, removed)
+	h.writeJSON(w, map[string]int{
+		"added":   added,
+		"updated": updated,
+		"removed": removed,
+		"total":   len(h.catalog.List()),
+	})
+}
+
+// writeCatalogError maps catalog errors to HTTP status codes
+func (h *Handlers) writeCatalogError(w http.ResponseWriter, err error) {
+	var validationErr *models.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, models.ErrContentNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, models.ErrContentExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("Catalog error: %v", err)
+		http.Error(w, "Failed to save catalog", http.StatusInternalServerError)
+	}
+}
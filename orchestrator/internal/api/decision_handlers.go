@@ -0,0 +1,100 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gavigo/orchestrator/internal/models"
+)
+
+// defaultDecisionLogEntries is the in-memory audit history kept when no log is configured
+const defaultDecisionLogEntries = 10000
+
+// defaultDecisionLimit is the number of decisions returned by /api/v1/decisions without a limit
+const defaultDecisionLimit = 50
+
+// SetDecisionLog replaces the decision audit log
+func (h *Handlers) SetDecisionLog(decisions *models.DecisionAuditLog) {
+	h.decisions = decisions
+}
+
+// handleDecisions handles GET /api/v1/decisions, returning the latest version of
+// each matching decision, newest first. Query parameters: content_id,
+// trigger_type, action, since, until (RFC 3339 or Unix milliseconds) and limit.
+func (h *Handlers) handleDecisions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := parseDecisionFilter(r, defaultDecisionLimit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.writeJSON(w, h.decisions.Query(filter))
+}
+
+// handleDecisionExport handles GET /api/v1/decisions/export, streaming the matching
+// audit log entries (every recorded version of each decision) as NDJSON
+func (h *Handlers) handleDecisionExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	filter, err := parseDecisionFilter(r, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="decisions.ndjson"`)
+	if err := h.decisions.Export(w, filter); err != nil {
+		log.Printf("Error exporting decisions: %v", err)
+	}
+}
+
+// parseDecisionFilter reads decision filters from the query string
+func parseDecisionFilter(r *http.Request, defaultLimit int) (models.DecisionFilter, error) {
+	q := r.URL.Query()
+	filter := models.DecisionFilter{
+		ContentID: q.Get("content_id"),
+		Trigger:   models.TriggerType(q.Get("trigger_type")),
+		Action:    models.ActionType(q.Get("action")),
+		Limit:     defaultLimit,
+	}
+
+	if limitStr := q.Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 0 {
+			return filter, fmt.Errorf("invalid limit %q", limitStr)
+		}
+		filter.Limit = l
+	}
+
+	var err error
+	if filter.Since, err = parseFilterTime(q.Get("since")); err != nil {
+		return filter, fmt.Errorf("invalid since: %w", err)
+	}
+	if filter.Until, err = parseFilterTime(q.Get("until")); err != nil {
+		return filter, fmt.Errorf("invalid until: %w", err)
+	}
+	return filter, nil
+}
+
+// parseFilterTime parses an RFC 3339 timestamp or Unix milliseconds ("" is the zero time)
+func parseFilterTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -1,27 +1,44 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gavigo/orchestrator/internal/engine"
+	"github.com/gavigo/orchestrator/internal/k8s"
 	"github.com/gavigo/orchestrator/internal/models"
 )
 
 // Handlers provides HTTP handlers for the REST API
 type Handlers struct {
-	content         []models.ContentItem
-	containerStates map[string]models.ContainerStatus
-	decisions       []*models.AIDecision
-	scorer          *engine.Scorer
-	currentMode     models.OperationalMode
-	proofManager    *engine.ProofSignalManager
+	catalog      *models.ContentCatalog
+	state        *models.StateStore
+	machine      *models.ContainerStateMachine
+	scorer       *engine.Scorer
+	proofManager *engine.ProofSignalManager
+	decisions    *models.DecisionAuditLog
+	feedRanker   *engine.FeedRanker
 
 	// Dependencies
-	OnTrendSpike func(contentID string, viralScore float64)
-	OnReset      func()
+	OnTrendSpike          func(contentID string, viralScore float64)
+	OnReset               func()
+	GetSession            func(sessionID string) *models.UserSession                                                                  // Returns a snapshot, or nil if unknown
+	GetLeaseCounts        func() map[string]int                                                                                       // Sessions holding each HOT content item
+	GetUnavailable        func() map[string]string                                                                                    // Failure reason of content that cannot start
+	GetInstances          func() []models.WorkloadInstance                                                                            // Per-session workload instances; nil when disabled
+	GetPoolStatus         func() []engine.PoolStatus                                                                                  // Warm pool sizing per runtime class
+	GetResourceDrift      func(ctx context.Context) ([]models.ResourceDrift, error)                                                   // Throttled resources changed since applied; nil when not running on Kubernetes
+	GetResourceAllocation func(ctx context.Context) models.ResourceAllocation                                                         // Resource split across workloads; nil reports the mode's default percentages
+	GetPodStatuses        func(ctx context.Context) ([]k8s.PodStatus, error)                                                          // Workload pods; nil when not running on Kubernetes
+	GetLeaderStatus       func() k8s.LeaderStatus                                                                                     // Leader election state; nil when every replica leads
+	RouteInstance         func(ctx context.Context, deployment, instanceID, token string) (*models.WorkloadInstance, *url.URL, error) // Verifies a session token and returns where its instance is served; nil when instances are disabled
+	RoutePooled           func(ctx context.Context, podName string) (*url.URL, error)                                                 // Returns where a claimed warm pool pod is served; nil when there is no warm pool
 }
 
 // SetProofManager sets the proof signal manager reference
@@ -29,63 +46,54 @@ func (h *Handlers) SetProofManager(pm *engine.ProofSignalManager) {
 	h.proofManager = pm
 }
 
-// NewHandlers creates new API handlers
-func NewHandlers(scorer *engine.Scorer) *Handlers {
-	content := models.DefaultContent()
-	states := make(map[string]models.ContainerStatus)
-	for _, c := range content {
-		states[c.ID] = c.ContainerStatus
-	}
-
+// NewHandlers creates API handlers for the catalog and the served content state
+func NewHandlers(scorer *engine.Scorer, catalog *models.ContentCatalog, state *models.StateStore, machine *models.ContainerStateMachine) *Handlers {
+	decisions, _ := models.NewDecisionAuditLog("", defaultDecisionLogEntries) // In-memory logs cannot fail
 	return &Handlers{
-		content:         content,
-		containerStates: states,
-		decisions:       make([]*models.AIDecision, 0),
-		scorer:          scorer,
-		currentMode:     models.ModeMixedStreamBrowsing,
+		catalog:    catalog,
+		state:      state,
+		machine:    machine,
+		decisions:  decisions,
+		scorer:     scorer,
+		feedRanker: engine.NewFeedRanker(nil),
 	}
 }
 
-// AddDecision adds a decision to the history
-func (h *Handlers) AddDecision(decision *models.AIDecision) {
-	h.decisions = append([]*models.AIDecision{decision}, h.decisions...)
-	if len(h.decisions) > 100 {
-		h.decisions = h.decisions[:100]
-	}
+// StateMachine returns the state machine that owns container state changes
+func (h *Handlers) StateMachine() *models.ContainerStateMachine {
+	return h.machine
 }
 
-// UpdateContainerState updates the state of a container
-func (h *Handlers) UpdateContainerState(contentID string, state models.ContainerStatus) {
-	h.containerStates[contentID] = state
-	for i := range h.content {
-		if h.content[i].ID == contentID {
-			h.content[i].ContainerStatus = state
-			break
-		}
-	}
+// GetContainerState returns the state of a single container
+func (h *Handlers) GetContainerState(contentID string) models.ContainerStatus {
+	return h.state.ContainerState(contentID)
+}
+
+// GetContainerStates returns a copy of all container states
+func (h *Handlers) GetContainerStates() map[string]models.ContainerStatus {
+	return h.state.ContainerStates()
 }
 
 // SetMode sets the current operational mode
 func (h *Handlers) SetMode(mode models.OperationalMode) {
-	h.currentMode = mode
+	h.state.SetMode(mode)
 }
 
 // GetCurrentMode returns the current operational mode
 func (h *Handlers) GetCurrentMode() models.OperationalMode {
-	return h.currentMode
+	return h.state.Mode()
 }
 
-// GetContent returns all content items
+// GetContent returns a snapshot of all content items
 func (h *Handlers) GetContent() []models.ContentItem {
-	return h.content
+	return h.state.Content()
 }
 
-// GetContentByID returns a content item by ID
+// GetContentByID returns a snapshot of a content item by ID, or nil if unknown.
+// Changes to the returned item are not stored.
 func (h *Handlers) GetContentByID(id string) *models.ContentItem {
-	for i := range h.content {
-		if h.content[i].ID == id {
-			return &h.content[i]
-		}
+	if content, ok := h.state.ContentByID(id); ok {
+		return &content
 	}
 	return nil
 }
@@ -94,15 +102,24 @@ func (h *Handlers) GetContentByID(id string) *models.ContentItem {
 func (h *Handlers) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/api/v1/health", h.handleHealth)
 	mux.HandleFunc("/api/v1/content", h.handleContent)
+	mux.HandleFunc("/api/v1/feed", h.handleFeed)
 	mux.HandleFunc("/api/v1/containers", h.handleContainers)
+	mux.HandleFunc("/api/v1/containers/", h.handleContainerHistory)
+	mux.HandleFunc("/api/v1/instances", h.handleInstances)
+	mux.HandleFunc("/api/v1/pool", h.handlePool)
+	mux.HandleFunc("/api/v1/pods", h.handlePods)
 	mux.HandleFunc("/api/v1/decisions", h.handleDecisions)
+	mux.HandleFunc("/api/v1/decisions/export", h.handleDecisionExport)
 	mux.HandleFunc("/api/v1/scores", h.handleScores)
 	mux.HandleFunc("/api/v1/mode", h.handleMode)
 	mux.HandleFunc("/api/v1/resources", h.handleResources)
+	mux.HandleFunc("/api/v1/resources/drift", h.handleResourceDrift)
 	mux.HandleFunc("/api/v1/demo/reset", h.handleDemoReset)
 	mux.HandleFunc("/api/v1/demo/trend-spike", h.handleTrendSpike)
 	mux.HandleFunc("/api/v1/telemetry", h.handleTelemetry)
 	mux.HandleFunc("/api/v1/proof-signals", h.handleProofSignals)
+	mux.HandleFunc("/api/v1/catalog/reload", h.handleCatalogReload)
+	mux.HandleFunc("/workloads/", h.handleWorkload)
 }
 
 func (h *Handlers) handleHealth(w http.ResponseWriter, r *http.Request) {
@@ -111,10 +128,17 @@ func (h *Handlers) handleHealth(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response := map[string]string{
+	// Without leader election this replica scales and throttles on its own
+	leader := k8s.LeaderStatus{IsLeader: true}
+	if h.GetLeaderStatus != nil {
+		leader = h.GetLeaderStatus()
+	}
+
+	response := map[string]interface{}{
 		"status":     "healthy",
 		"redis":      "connected",
 		"kubernetes": "connected",
+		"leader":     leader,
 	}
 
 	h.writeJSON(w, response)
@@ -126,15 +150,58 @@ func (h *Handlers) handleContent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Update scores in content items
-	for i := range h.content {
-		scores := h.scorer.GetScores("default", h.content[i].ID)
-		h.content[i].PersonalScore = scores.PersonalScore
-		h.content[i].GlobalScore = scores.GlobalScore
-		h.content[i].CombinedScore = scores.CombinedScore
+	// Fill in current scores on the snapshot
+	content := h.state.Content()
+	for i := range content {
+		scores := h.scorer.GetScores("default", content[i].ID)
+		content[i].PersonalScore = scores.PersonalScore
+		content[i].GlobalScore = scores.GlobalScore
+		content[i].CombinedScore = scores.CombinedScore
+	}
+
+	h.writeJSON(w, content)
+}
+
+func (h *Handlers) handleFeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	sessionID := query.Get("session_id")
+	if sessionID == "" {
+		sessionID = "default"
+	}
+
+	limit := 0
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	var session *models.UserSession
+	if h.GetSession != nil {
+		session = h.GetSession(sessionID)
+	}
+	if session == nil {
+		session = models.NewSession(sessionID)
 	}
 
-	h.writeJSON(w, h.content)
+	ranked := h.feedRanker.Rank(h.GetContent(), h.scorer.GetAllScores(sessionID), session)
+	items, nextCursor, err := h.feedRanker.Page(ranked, query.Get("cursor"), limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.writeJSON(w, models.FeedPage{
+		SessionID:  sessionID,
+		Items:      items,
+		NextCursor: nextCursor,
+		Total:      len(ranked),
+	})
 }
 
 func (h *Handlers) handleContainers(w http.ResponseWriter, r *http.Request) {
@@ -143,40 +210,117 @@ func (h *Handlers) handleContainers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var leases map[string]int
+	if h.GetLeaseCounts != nil {
+		leases = h.GetLeaseCounts()
+	}
+	var unavailable map[string]string
+	if h.GetUnavailable != nil {
+		unavailable = h.GetUnavailable()
+	}
+
 	response := make(map[string]interface{})
-	for _, c := range h.content {
+	for _, c := range h.state.Content() {
+		replicas := 0
+		if c.Type.UsesWorkload() {
+			replicas = h.getReplicasForState(c.ContainerStatus)
+		}
+		lastChange := ""
+		if t, ok := h.machine.LastTransition(c.ID); ok {
+			lastChange = t.Timestamp.Format(time.RFC3339)
+		}
 		response[c.ID] = map[string]interface{}{
 			"content_id":        c.ID,
-			"status":            h.containerStates[c.ID],
+			"type":              c.Type,
+			"status":            c.ContainerStatus,
 			"deployment_name":   c.DeploymentName,
-			"replicas":          h.getReplicasForState(h.containerStates[c.ID]),
-			"ready_replicas":    h.getReplicasForState(h.containerStates[c.ID]),
-			"last_state_change": "",
+			"replicas":          replicas,
+			"ready_replicas":    replicas,
+			"last_state_change": lastChange,
+			"lease_count":       leases[c.ID],
+			"unavailable":       unavailable[c.ID], // Failure reason; empty when the content can start
 		}
 	}
 
 	h.writeJSON(w, response)
 }
 
-func (h *Handlers) handleDecisions(w http.ResponseWriter, r *http.Request) {
+// handleInstances handles GET /api/v1/instances
+func (h *Handlers) handleInstances(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	limit := 50
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
+	instances := []models.WorkloadInstance{}
+	if h.GetInstances != nil {
+		instances = h.GetInstances()
+	}
+	h.writeJSON(w, map[string]interface{}{
+		"instances": instances,
+		"count":     len(instances),
+	})
+}
+
+// handlePool handles GET /api/v1/pool
+func (h *Handlers) handlePool(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	pools := []engine.PoolStatus{}
+	if h.GetPoolStatus != nil {
+		pools = h.GetPoolStatus()
+	}
+	h.writeJSON(w, map[string]interface{}{
+		"pools": pools,
+	})
+}
+
+// handlePods handles GET /api/v1/pods
+func (h *Handlers) handlePods(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	pods := []k8s.PodStatus{}
+	if h.GetPodStatuses != nil {
+		var err error
+		if pods, err = h.GetPodStatuses(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
 		}
 	}
+	h.writeJSON(w, map[string]interface{}{
+		"pods":  pods,
+		"count": len(pods),
+	})
+}
+
+// handleContainerHistory handles GET /api/v1/containers/:id/history
+func (h *Handlers) handleContainerHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
 
-	decisions := h.decisions
-	if len(decisions) > limit {
-		decisions = decisions[:limit]
+	contentID, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/containers/"), "/history")
+	if !ok || contentID == "" || strings.Contains(contentID, "/") {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+	if h.GetContentByID(contentID) == nil {
+		http.Error(w, models.ErrContentNotFound.Error(), http.StatusNotFound)
+		return
 	}
 
-	h.writeJSON(w, decisions)
+	h.writeJSON(w, map[string]interface{}{
+		"content_id":  contentID,
+		"status":      h.state.ContainerState(contentID),
+		"transitions": h.machine.History(contentID),
+	})
 }
 
 func (h *Handlers) handleScores(w http.ResponseWriter, r *http.Request) {
@@ -186,7 +330,7 @@ func (h *Handlers) handleScores(w http.ResponseWriter, r *http.Request) {
 	}
 
 	response := make(map[string]interface{})
-	for _, c := range h.content {
+	for _, c := range h.state.Content() {
 		scores := h.scorer.GetScores("default", c.ID)
 		response[c.ID] = map[string]interface{}{
 			"personal_score":     scores.PersonalScore,
@@ -206,15 +350,12 @@ func (h *Handlers) handleMode(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var activeContentID *string
-	for _, c := range h.content {
-		if h.containerStates[c.ID] == models.StatusHot {
-			activeContentID = &c.ID
-			break
-		}
+	if id, ok := h.state.ActiveContentID(); ok {
+		activeContentID = &id
 	}
 
 	response := map[string]interface{}{
-		"current_mode":      h.currentMode,
+		"current_mode":      h.state.Mode(),
 		"active_content_id": activeContentID,
 		"since":             "",
 	}
@@ -228,32 +369,44 @@ func (h *Handlers) handleResources(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	allocation := models.DefaultResourceAllocation(h.currentMode)
+	if h.GetResourceAllocation != nil {
+		h.writeJSON(w, h.GetResourceAllocation(r.Context()))
+		return
+	}
+	allocation := models.DefaultResourceAllocation(h.state.Mode())
 	h.writeJSON(w, allocation)
 }
 
-func (h *Handlers) handleDemoReset(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
+// handleResourceDrift handles GET /api/v1/resources/drift
+func (h *Handlers) handleResourceDrift(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Reset all container states to COLD
-	for id := range h.containerStates {
-		h.containerStates[id] = models.StatusCold
+	drift := []models.ResourceDrift{}
+	if h.GetResourceDrift != nil {
+		var err error
+		if drift, err = h.GetResourceDrift(r.Context()); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
 	}
+	h.writeJSON(w, map[string]interface{}{
+		"drift": drift,
+		"count": len(drift),
+	})
+}
 
-	// Reset content
-	h.content = models.DefaultContent()
-	for _, c := range h.content {
-		h.containerStates[c.ID] = c.ContainerStatus
+func (h *Handlers) handleDemoReset(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	// Reset decisions
-	h.decisions = make([]*models.AIDecision, 0)
-
-	// Reset mode
-	h.currentMode = models.ModeMixedStreamBrowsing
+	// Reset content, container states, transition history, decisions and mode
+	h.state.Reset(h.catalog.List())
+	h.machine.Reset()
 
 	// Reset scorer
 	h.scorer.Reset()
@@ -345,7 +498,7 @@ func (h *Handlers) getReplicasForState(state models.ContainerStatus) int {
 	switch state {
 	case models.StatusCold:
 		return 0
-	case models.StatusWarm:
+	case models.StatusWarm, models.StatusWarming:
 		return 1
 	case models.StatusHot:
 		return 2
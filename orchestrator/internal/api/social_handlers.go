@@ -15,6 +15,9 @@ import (
 type SocialHandlers struct {
 	store *models.SocialStore
 	hub   *websocket.Hub
+
+	// ContentItemHandler serves /api/v1/content/:id itself (shares the route prefix)
+	ContentItemHandler func(w http.ResponseWriter, r *http.Request, contentID string)
 }
 
 // NewSocialHandlers creates new social API handlers
@@ -104,7 +107,12 @@ func (h *SocialHandlers) handleContentSocial(w http.ResponseWriter, r *http.Requ
 	parts := strings.SplitN(path, "/", 2)
 
 	if len(parts) < 2 {
-		// This is a GET /api/v1/content/{id} - let the main handler handle it
+		// This is /api/v1/content/{id} - let the main handler handle it
+		if parts[0] == "" || h.ContentItemHandler == nil {
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		}
+		h.ContentItemHandler(w, r, parts[0])
 		return
 	}
 
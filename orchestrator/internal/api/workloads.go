@@ -0,0 +1,129 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gavigo/orchestrator/internal/k8s"
+	"github.com/gavigo/orchestrator/internal/models"
+)
+
+// Headers on requests to workload instances
+const (
+	HeaderSessionToken = "X-Session-Token"
+	HeaderInstanceID   = "X-Gavigo-Instance"
+	HeaderSessionID    = "X-Gavigo-Session"
+	HeaderInstanceSlot = "X-Gavigo-Slot"
+)
+
+// PoolPath is the first segment of the endpoint URL of content served by a
+// claimed warm pool pod, /workloads/pool/:pod/*
+const PoolPath = "pool"
+
+// handleWorkload handles /workloads/:deployment/:instance/*, the endpoint URL
+// in activation_ready. The session token, sent in the X-Session-Token header
+// or the token query parameter, is verified before the request is proxied to
+// the instance's pod, or to its deployment with the slot in X-Gavigo-Slot.
+// Claimed warm pool pods serve their content to every session, so requests
+// to them are proxied without a token.
+func (h *Handlers) handleWorkload(w http.ResponseWriter, r *http.Request) {
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/workloads/"), "/", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		http.NotFound(w, r)
+		return
+	}
+	deployment, instanceID, rest := parts[0], parts[1], ""
+	if len(parts) == 3 {
+		rest = parts[2]
+	}
+	if deployment == PoolPath {
+		h.handlePooledWorkload(w, r, instanceID, rest)
+		return
+	}
+	if h.RouteInstance == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	token := r.Header.Get(HeaderSessionToken)
+	if token == "" {
+		token = r.URL.Query().Get("token")
+	}
+	if token == "" {
+		http.Error(w, "Session token required", http.StatusUnauthorized)
+		return
+	}
+
+	instance, target, err := h.RouteInstance(r.Context(), deployment, instanceID, token)
+	switch {
+	case errors.Is(err, k8s.ErrInstanceUnauthorized):
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	case errors.Is(err, k8s.ErrInstanceNotReady):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	proxyWorkload(w, r, target, rest, "instance "+instanceID+" of "+deployment, func(header http.Header) {
+		// The instance can check the token against the one it was given
+		header.Set(HeaderSessionToken, token)
+		header.Set(HeaderInstanceID, instance.ID)
+		header.Set(HeaderSessionID, instance.SessionID)
+		if instance.Mode == models.InstancePooled {
+			header.Set(HeaderInstanceSlot, strconv.Itoa(instance.Slot))
+		}
+	})
+}
+
+// handlePooledWorkload proxies /workloads/pool/:pod/* to a claimed warm pool pod
+func (h *Handlers) handlePooledWorkload(w http.ResponseWriter, r *http.Request, podName, rest string) {
+	if h.RoutePooled == nil {
+		http.NotFound(w, r)
+		return
+	}
+	target, err := h.RoutePooled(r.Context(), podName)
+	switch {
+	case errors.Is(err, k8s.ErrPoolPodNotClaimed):
+		http.NotFound(w, r)
+		return
+	case errors.Is(err, k8s.ErrInstanceNotReady):
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	proxyWorkload(w, r, target, rest, "pool pod "+podName, nil)
+}
+
+// proxyWorkload proxies a request to a workload at target, with rest as its
+// path and without the token query parameter
+func proxyWorkload(w http.ResponseWriter, r *http.Request, target *url.URL, rest, name string, setHeaders func(header http.Header)) {
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.SetXForwarded()
+			pr.Out.URL.Path = "/" + rest
+			pr.Out.URL.RawPath = ""
+			query := pr.Out.URL.Query()
+			query.Del("token")
+			pr.Out.URL.RawQuery = query.Encode()
+			if setHeaders != nil {
+				setHeaders(pr.Out.Header)
+			}
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			log.Printf("Workload %s unreachable: %v", name, err)
+			http.Error(w, "Workload instance unreachable", http.StatusBadGateway)
+		},
+	}
+	proxy.ServeHTTP(w, r)
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gavigo/orchestrator/internal/k8s"
+	"github.com/gavigo/orchestrator/internal/models"
+)
+
+func TestWorkloadRouteVerifiesSessionToken(t *testing.T) {
+	var got *http.Request
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		io.WriteString(w, "instance")
+	}))
+	defer upstream.Close()
+	target, _ := url.Parse(upstream.URL)
+
+	instance := &models.WorkloadInstance{ID: "0123456789ab", SessionID: "s1", Deployment: "ai-service", Mode: models.InstancePooled, Slot: 3}
+	h := &Handlers{
+		RouteInstance: func(ctx context.Context, deployment, instanceID, token string) (*models.WorkloadInstance, *url.URL, error) {
+			if deployment != instance.Deployment || instanceID != instance.ID || token != "secret" {
+				return nil, nil, k8s.ErrInstanceUnauthorized
+			}
+			return instance, target, nil
+		},
+	}
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		want   int
+	}{
+		{"no token", "/workloads/ai-service/0123456789ab/api/chat", "", http.StatusUnauthorized},
+		{"wrong token", "/workloads/ai-service/0123456789ab/api/chat", "guess", http.StatusForbidden},
+		{"no instance", "/workloads/ai-service", "secret", http.StatusNotFound},
+		{"header token", "/workloads/ai-service/0123456789ab/api/chat?q=1", "secret", http.StatusOK},
+		{"query token", "/workloads/ai-service/0123456789ab/api/chat?q=1&token=secret", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		got = nil
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.header != "" {
+			req.Header.Set(HeaderSessionToken, tt.header)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.want)
+			continue
+		}
+		if tt.want != http.StatusOK {
+			if got != nil {
+				t.Errorf("%s: request reached the instance", tt.name)
+			}
+			continue
+		}
+		if got.URL.Path != "/api/chat" || got.URL.RawQuery != "q=1" {
+			t.Errorf("%s: instance got %s?%s, want /api/chat?q=1", tt.name, got.URL.Path, got.URL.RawQuery)
+		}
+		if got.Header.Get(HeaderInstanceID) != instance.ID || got.Header.Get(HeaderInstanceSlot) != "3" || got.Header.Get(HeaderSessionToken) != "secret" {
+			t.Errorf("%s: instance headers = %v", tt.name, got.Header)
+		}
+	}
+}
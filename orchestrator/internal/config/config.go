@@ -19,6 +19,53 @@ type Config struct {
 	GlobalScoreWeight       float64
 	ProofSignalsEnabled     bool
 	RestoreWindowMs         int64
+	HotIdleTimeoutMs        int64
+	WarmIdleTimeoutMs       int64
+	IdleSweepIntervalMs     int64
+	CatalogFile             string
+	CatalogReloadMs         int64
+	DecisionAuditFile       string
+	DecisionAuditMaxEntries int
+	ReplayRecordFile        string
+	RandomSeed              int64
+	MaxHotPerCluster        int
+	MaxHotPerUser           int
+	ActivationQueueMax      int
+	ActivationQueuePolicy   string
+	ExpectedHoldMs          int64
+	LeaseTimeoutMs          int64
+	LeaseSweepIntervalMs    int64
+	SessionTTLMs            int64
+	InstanceMode            string
+	InstanceSlotsPerPod     int
+	PoolTemplates           string
+	PoolMinSize             int
+	PoolMaxSize             int
+	PoolWindowMs            int64
+	PoolReplenishMs         int64
+	PoolHeadroom            float64
+	PoolResizeIntervalMs    int64
+	ThrottleConfigFile      string
+	ThrottleResizePolicy    string
+	ThrottleBackend         string
+	ResourceMetricsEnabled  bool
+	ResourcePollIntervalMs  int64
+	WatchPodSelector        string
+	WatchDeploymentSelector string
+	WatchDeploymentLabel    string
+	WatchResyncMs           int64
+	FailureRetries          int
+	FailureBackoffMs        int64
+	FailureMaxBackoffMs     int64
+	FailureStableMs         int64
+	ContentCRDEnabled       bool
+	LeaderElectionEnabled   bool
+	LeaderElectionLease     string
+	LeaderElectionIdentity  string
+	LeaderLeaseDurationMs   int64
+	LeaderRenewDeadlineMs   int64
+	LeaderRetryPeriodMs     int64
+	LeaderIntentTTLMs       int64
 }
 
 func Load() *Config {
@@ -36,6 +83,53 @@ func Load() *Config {
 		GlobalScoreWeight:       getEnvFloat("GLOBAL_SCORE_WEIGHT", 0.4),
 		ProofSignalsEnabled:     getEnvBool("PROOF_SIGNALS_ENABLED", true),
 		RestoreWindowMs:         int64(getEnvInt("RESTORE_WINDOW_MS", 120000)),
+		HotIdleTimeoutMs:        int64(getEnvInt("HOT_IDLE_TIMEOUT_MS", 600000)),
+		WarmIdleTimeoutMs:       int64(getEnvInt("WARM_IDLE_TIMEOUT_MS", 180000)),
+		IdleSweepIntervalMs:     int64(getEnvInt("IDLE_SWEEP_INTERVAL_MS", 15000)),
+		CatalogFile:             getEnv("CATALOG_FILE", ""),
+		CatalogReloadMs:         int64(getEnvInt("CATALOG_RELOAD_MS", 10000)),
+		DecisionAuditFile:       getEnv("DECISION_AUDIT_FILE", ""),
+		DecisionAuditMaxEntries: getEnvInt("DECISION_AUDIT_MAX_ENTRIES", 10000),
+		ReplayRecordFile:        getEnv("REPLAY_RECORD_FILE", ""),
+		RandomSeed:              int64(getEnvInt("RANDOM_SEED", 0)),
+		MaxHotPerCluster:        getEnvInt("MAX_HOT_PER_CLUSTER", 0),
+		MaxHotPerUser:           getEnvInt("MAX_HOT_PER_USER", 0),
+		ActivationQueueMax:      getEnvInt("ACTIVATION_QUEUE_MAX", 0),
+		ActivationQueuePolicy:   getEnv("ACTIVATION_QUEUE_POLICY", "priority"),
+		ExpectedHoldMs:          int64(getEnvInt("EXPECTED_HOLD_MS", 120000)),
+		LeaseTimeoutMs:          int64(getEnvInt("LEASE_TIMEOUT_MS", 45000)),
+		LeaseSweepIntervalMs:    int64(getEnvInt("LEASE_SWEEP_INTERVAL_MS", 10000)),
+		SessionTTLMs:            int64(getEnvInt("SESSION_TTL_MS", 600000)),
+		InstanceMode:            getEnv("INSTANCE_MODE", ""),
+		InstanceSlotsPerPod:     getEnvInt("INSTANCE_SLOTS_PER_POD", 4),
+		PoolTemplates:           getEnv("POOL_TEMPLATES", ""),
+		PoolMinSize:             getEnvInt("POOL_MIN_SIZE", 1),
+		PoolMaxSize:             getEnvInt("POOL_MAX_SIZE", 10),
+		PoolWindowMs:            int64(getEnvInt("POOL_WINDOW_MS", 600000)),
+		PoolReplenishMs:         int64(getEnvInt("POOL_REPLENISH_MS", 30000)),
+		PoolHeadroom:            getEnvFloat("POOL_HEADROOM", 2.0),
+		PoolResizeIntervalMs:    int64(getEnvInt("POOL_RESIZE_INTERVAL_MS", 30000)),
+		ThrottleConfigFile:      getEnv("THROTTLE_CONFIG_FILE", ""),
+		ThrottleResizePolicy:    getEnv("THROTTLE_RESIZE_POLICY", ""),
+		ThrottleBackend:         getEnv("THROTTLE_BACKEND", ""),
+		ResourceMetricsEnabled:  getEnvBool("RESOURCE_METRICS_ENABLED", false),
+		ResourcePollIntervalMs:  int64(getEnvInt("RESOURCE_POLL_INTERVAL_MS", 15000)),
+		WatchPodSelector:        getEnv("WATCH_POD_SELECTOR", "type=workload"),
+		WatchDeploymentSelector: getEnv("WATCH_DEPLOYMENT_SELECTOR", ""),
+		WatchDeploymentLabel:    getEnv("WATCH_DEPLOYMENT_LABEL", "app"),
+		WatchResyncMs:           int64(getEnvInt("WATCH_RESYNC_MS", 300000)),
+		FailureRetries:          getEnvInt("FAILURE_RETRIES", 2),
+		FailureBackoffMs:        int64(getEnvInt("FAILURE_BACKOFF_MS", 10000)),
+		FailureMaxBackoffMs:     int64(getEnvInt("FAILURE_MAX_BACKOFF_MS", 120000)),
+		FailureStableMs:         int64(getEnvInt("FAILURE_STABLE_MS", 120000)),
+		ContentCRDEnabled:       getEnvBool("CONTENT_CRD_ENABLED", false),
+		LeaderElectionEnabled:   getEnvBool("LEADER_ELECTION_ENABLED", false),
+		LeaderElectionLease:     getEnv("LEADER_ELECTION_LEASE", "gavigo-orchestrator"),
+		LeaderElectionIdentity:  getEnv("POD_NAME", ""),
+		LeaderLeaseDurationMs:   int64(getEnvInt("LEADER_LEASE_DURATION_MS", 15000)),
+		LeaderRenewDeadlineMs:   int64(getEnvInt("LEADER_RENEW_DEADLINE_MS", 10000)),
+		LeaderRetryPeriodMs:     int64(getEnvInt("LEADER_RETRY_PERIOD_MS", 2000)),
+		LeaderIntentTTLMs:       int64(getEnvInt("LEADER_INTENT_TTL_MS", 30000)),
 	}
 }
 
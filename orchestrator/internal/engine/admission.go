@@ -0,0 +1,358 @@
+package engine
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Admission errors
+var (
+	ErrAtCapacity = errors.New("no HOT capacity available")
+	ErrQueueFull  = errors.New("activation queue is full")
+)
+
+// QueuePolicy orders activations waiting for HOT capacity
+type QueuePolicy string
+
+const (
+	QueueFIFO     QueuePolicy = "fifo"     // Strictly in arrival order
+	QueuePriority QueuePolicy = "priority" // Higher priority first, then arrival order
+)
+
+// AdmissionConfig holds configuration for activation admission control
+type AdmissionConfig struct {
+	MaxHotPerCluster int           // Max concurrent HOT workloads (0 = unlimited)
+	MaxHotPerUser    int           // Max HOT workloads activated by one user (0 = unlimited)
+	MaxQueueLength   int           // Max waiting activations (0 = unlimited)
+	Policy           QueuePolicy   // Queue ordering
+	ExpectedHoldTime time.Duration // Initial estimate of how long an activation stays HOT, for ETAs
+}
+
+// DefaultAdmissionConfig returns default admission configuration (no limits)
+func DefaultAdmissionConfig() *AdmissionConfig {
+	return &AdmissionConfig{
+		Policy:           QueuePriority,
+		ExpectedHoldTime: 2 * time.Minute,
+	}
+}
+
+// ActivationRequest is a user's request to bring content HOT
+type ActivationRequest struct {
+	SessionID  string
+	UserID     string // Requests from one user share the per-user limit
+	ContentID  string
+	Priority   int // Used by QueuePriority; higher is served first
+	EnqueuedAt time.Time
+	Reserved   bool // Set on admission when a new HOT slot was reserved; false if the content already held one
+
+	seq uint64
+}
+
+// QueuedActivation is a waiting request and its place in the queue
+type QueuedActivation struct {
+	Request     ActivationRequest
+	Position    int           // 1-based
+	QueueLength int           // Requests waiting in total
+	ETA         time.Duration // Estimated wait until admission
+}
+
+// holdEWMAWeight is the weight of each observed hold time in the hold estimate
+const holdEWMAWeight = 0.2
+
+// AdmissionController limits how many workloads are HOT at once, cluster-wide and
+// per user, and queues activations that exceed those limits until capacity frees up
+type AdmissionController struct {
+	mu      sync.Mutex
+	config  *AdmissionConfig
+	clock   Clock
+	holders map[string]string    // content ID -> user holding it HOT ("" for engine promotions)
+	since   map[string]time.Time // content ID -> when it was admitted
+	queue   []*ActivationRequest
+	seq     uint64
+	hold    time.Duration // Moving average of observed user hold times
+
+	// Callbacks
+	OnAdmit        func(req ActivationRequest)                // A queued request was admitted
+	OnQueueChanged func(queued []QueuedActivation)            // Queue positions changed
+	OnCancel       func(req ActivationRequest, reason string) // A queued request was dropped
+}
+
+// NewAdmissionController creates a new admission controller
+func NewAdmissionController(config *AdmissionConfig) *AdmissionController {
+	if config == nil {
+		config = DefaultAdmissionConfig()
+	}
+	return &AdmissionController{
+		config:  config,
+		clock:   RealClock{},
+		holders: make(map[string]string),
+		since:   make(map[string]time.Time),
+		hold:    config.ExpectedHoldTime,
+	}
+}
+
+// SetClock sets the clock used for hold times and queue timestamps
+func (a *AdmissionController) SetClock(clock Clock) {
+	a.clock = clock
+}
+
+// Admit admits a request if there is capacity, reserving a HOT slot for it.
+// Otherwise the request is queued and its place in the queue is returned.
+// Content that is already HOT is always admitted without taking another slot.
+// An admitted request is returned with Reserved set if it took a slot, so the
+// caller releases the slot only then.
+func (a *AdmissionController) Admit(req ActivationRequest) (bool, QueuedActivation, error) {
+	a.mu.Lock()
+
+	if _, hot := a.holders[req.ContentID]; hot || a.fits(req.UserID) {
+		req.Reserved = a.reserve(req.ContentID, req.UserID)
+		a.mu.Unlock()
+		return true, QueuedActivation{Request: req}, nil
+	}
+
+	// Asking again keeps the original place in the queue
+	for i, queued := range a.queue {
+		if queued.SessionID == req.SessionID && queued.ContentID == req.ContentID {
+			position := a.snapshotLocked()[i]
+			a.mu.Unlock()
+			a.notifyQueue([]QueuedActivation{position})
+			return false, position, nil
+		}
+	}
+
+	if a.config.MaxQueueLength > 0 && len(a.queue) >= a.config.MaxQueueLength {
+		a.mu.Unlock()
+		return false, QueuedActivation{}, ErrQueueFull
+	}
+
+	a.seq++
+	queued := req
+	queued.seq = a.seq
+	queued.EnqueuedAt = a.clock.Now()
+	a.queue = append(a.queue, &queued)
+	a.sortLocked()
+	snapshot := a.snapshotLocked()
+	a.mu.Unlock()
+
+	var position QueuedActivation
+	for _, q := range snapshot {
+		if q.Request.seq == queued.seq {
+			position = q
+		}
+	}
+	log.Printf("Activation queued: content=%s session=%s position=%d",
+		req.ContentID, req.SessionID, position.Position)
+	a.notifyQueue(snapshot)
+	return false, position, nil
+}
+
+// TryHold reserves a HOT slot for an engine promotion, which is never queued
+func (a *AdmissionController) TryHold(contentID string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, hot := a.holders[contentID]; hot {
+		return nil
+	}
+	if !a.fits("") {
+		return ErrAtCapacity
+	}
+	a.reserve(contentID, "")
+	return nil
+}
+
+// Hold records content that became HOT without going through Admit
+func (a *AdmissionController) Hold(contentID, userID string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, hot := a.holders[contentID]; !hot {
+		a.reserve(contentID, userID)
+	}
+}
+
+// Release frees the HOT slot held by content and admits queued requests that now fit
+func (a *AdmissionController) Release(contentID string) {
+	a.mu.Lock()
+	user, hot := a.holders[contentID]
+	if !hot {
+		a.mu.Unlock()
+		return
+	}
+	if user != "" {
+		held := a.clock.Now().Sub(a.since[contentID])
+		a.hold = time.Duration(holdEWMAWeight*float64(held) + (1-holdEWMAWeight)*float64(a.hold))
+	}
+	delete(a.holders, contentID)
+	delete(a.since, contentID)
+	a.mu.Unlock()
+
+	a.dispatch()
+}
+
+// Cancel drops a session's queued request for content. It returns false if
+// nothing was queued.
+func (a *AdmissionController) Cancel(sessionID, contentID, reason string) bool {
+	return a.cancel(reason, func(req *ActivationRequest) bool {
+		return req.SessionID == sessionID && req.ContentID == contentID
+	}) > 0
+}
+
+// CancelSession drops every queued request of a session and returns how many
+// were dropped
+func (a *AdmissionController) CancelSession(sessionID, reason string) int {
+	return a.cancel(reason, func(req *ActivationRequest) bool {
+		return req.SessionID == sessionID
+	})
+}
+
+// Queue returns the waiting requests in admission order
+func (a *AdmissionController) Queue() []QueuedActivation {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.snapshotLocked()
+}
+
+// HotCount returns how many HOT slots are held
+func (a *AdmissionController) HotCount() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.holders)
+}
+
+// Reset releases every slot and cancels every queued request
+func (a *AdmissionController) Reset() {
+	a.mu.Lock()
+	a.holders = make(map[string]string)
+	a.since = make(map[string]time.Time)
+	a.hold = a.config.ExpectedHoldTime
+	a.mu.Unlock()
+
+	a.cancel("reset", func(*ActivationRequest) bool { return true })
+}
+
+// fits reports whether a new HOT slot can be given to a user
+func (a *AdmissionController) fits(userID string) bool {
+	if a.config.MaxHotPerCluster > 0 && len(a.holders) >= a.config.MaxHotPerCluster {
+		return false
+	}
+	if userID == "" || a.config.MaxHotPerUser <= 0 {
+		return true
+	}
+	held := 0
+	for _, holder := range a.holders {
+		if holder == userID {
+			held++
+		}
+	}
+	return held < a.config.MaxHotPerUser
+}
+
+// reserve gives content a HOT slot and reports whether it did not hold one yet
+func (a *AdmissionController) reserve(contentID, userID string) bool {
+	if _, hot := a.holders[contentID]; hot {
+		return false
+	}
+	a.holders[contentID] = userID
+	a.since[contentID] = a.clock.Now()
+	return true
+}
+
+// dispatch admits queued requests in order while they fit. A request whose user
+// is at their limit is skipped so it does not block other users. Admitted
+// requests are handed to OnAdmit with Reserved set as for Admit.
+func (a *AdmissionController) dispatch() {
+	a.mu.Lock()
+	var admitted []ActivationRequest
+	remaining := a.queue[:0]
+	for _, req := range a.queue {
+		if _, hot := a.holders[req.ContentID]; hot || a.fits(req.UserID) {
+			req.Reserved = a.reserve(req.ContentID, req.UserID)
+			admitted = append(admitted, *req)
+			continue
+		}
+		remaining = append(remaining, req)
+	}
+	a.queue = remaining
+	snapshot := a.snapshotLocked()
+	a.mu.Unlock()
+
+	if len(admitted) == 0 {
+		return
+	}
+	for _, req := range admitted {
+		log.Printf("Activation admitted from queue: content=%s session=%s waited=%s",
+			req.ContentID, req.SessionID, a.clock.Now().Sub(req.EnqueuedAt).Round(time.Millisecond))
+		if a.OnAdmit != nil {
+			a.OnAdmit(req)
+		}
+	}
+	a.notifyQueue(snapshot)
+}
+
+func (a *AdmissionController) cancel(reason string, match func(req *ActivationRequest) bool) int {
+	a.mu.Lock()
+	var cancelled []ActivationRequest
+	remaining := a.queue[:0]
+	for _, req := range a.queue {
+		if match(req) {
+			cancelled = append(cancelled, *req)
+			continue
+		}
+		remaining = append(remaining, req)
+	}
+	a.queue = remaining
+	snapshot := a.snapshotLocked()
+	a.mu.Unlock()
+
+	if len(cancelled) == 0 {
+		return 0
+	}
+	for _, req := range cancelled {
+		log.Printf("Queued activation cancelled: content=%s session=%s (%s)", req.ContentID, req.SessionID, reason)
+		if a.OnCancel != nil {
+			a.OnCancel(req, reason)
+		}
+	}
+	a.notifyQueue(snapshot)
+	return len(cancelled)
+}
+
+func (a *AdmissionController) sortLocked() {
+	if a.config.Policy != QueuePriority {
+		return // Appending keeps arrival order
+	}
+	sort.SliceStable(a.queue, func(i, j int) bool {
+		if a.queue[i].Priority != a.queue[j].Priority {
+			return a.queue[i].Priority > a.queue[j].Priority
+		}
+		return a.queue[i].seq < a.queue[j].seq
+	})
+}
+
+// snapshotLocked returns the queue in admission order with positions and ETAs.
+// Slots are assumed to free up one cluster-capacity batch per average hold time.
+func (a *AdmissionController) snapshotLocked() []QueuedActivation {
+	slots := a.config.MaxHotPerCluster
+	if slots <= 0 {
+		slots = 1 // Only per-user limits apply: each user waits on their own slot
+	}
+	snapshot := make([]QueuedActivation, len(a.queue))
+	for i, req := range a.queue {
+		batches := i/slots + 1
+		snapshot[i] = QueuedActivation{
+			Request:     *req,
+			Position:    i + 1,
+			QueueLength: len(a.queue),
+			ETA:         time.Duration(batches) * a.hold,
+		}
+	}
+	return snapshot
+}
+
+func (a *AdmissionController) notifyQueue(snapshot []QueuedActivation) {
+	if a.OnQueueChanged != nil && len(snapshot) > 0 {
+		a.OnQueueChanged(snapshot)
+	}
+}
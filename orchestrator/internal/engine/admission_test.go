@@ -0,0 +1,305 @@
+package engine
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestAdmission(config *AdmissionConfig) (*AdmissionController, *FakeClock) {
+	clock := NewFakeClock(testStart)
+	admission := NewAdmissionController(config)
+	admission.SetClock(clock)
+	return admission, clock
+}
+
+func TestAdmissionReportsReservedSlots(t *testing.T) {
+	config := DefaultAdmissionConfig()
+	config.MaxHotPerCluster = 1
+	admission, _ := newTestAdmission(config)
+	var admitted []ActivationRequest
+	admission.OnAdmit = func(req ActivationRequest) { admitted = append(admitted, req) }
+
+	// The first activation takes the slot, another one for the same content shares it
+	ok, first, err := admission.Admit(ActivationRequest{SessionID: "s1", UserID: "u1", ContentID: "game-a"})
+	if err != nil || !ok || !first.Request.Reserved {
+		t.Fatalf("Admit = %v, %+v, %v; want a reserved slot", ok, first, err)
+	}
+	ok, shared, err := admission.Admit(ActivationRequest{SessionID: "s2", UserID: "u2", ContentID: "game-a"})
+	if err != nil || !ok || shared.Request.Reserved {
+		t.Fatalf("Admit = %v, %+v, %v; want the held slot shared", ok, shared, err)
+	}
+
+	// A queued request for content promoted meanwhile shares its slot
+	if ok, _, _ := admission.Admit(ActivationRequest{SessionID: "s3", UserID: "u3", ContentID: "video-b"}); ok {
+		t.Fatal("video-b admitted beyond capacity")
+	}
+	admission.Release("game-a")
+	if len(admitted) != 1 || !admitted[0].Reserved {
+		t.Fatalf("admitted %+v, want video-b with a reserved slot", admitted)
+	}
+	if ok, _, _ := admission.Admit(ActivationRequest{SessionID: "s4", UserID: "u4", ContentID: "ai-c"}); ok {
+		t.Fatal("ai-c admitted beyond capacity")
+	}
+	if err := admission.TryHold("ai-c"); err == nil {
+		t.Fatal("TryHold beyond capacity")
+	}
+	admission.Hold("ai-c", "")
+	admission.Release("video-b")
+	if len(admitted) != 2 || admitted[1].ContentID != "ai-c" || admitted[1].Reserved {
+		t.Fatalf("admitted %+v, want ai-c sharing its held slot", admitted)
+	}
+	if admission.HotCount() != 1 {
+		t.Errorf("HotCount = %d, want 1", admission.HotCount())
+	}
+}
+
+// queuedContent returns the content IDs of queued requests in admission order
+func queuedContent(queued []QueuedActivation) []string {
+	ids := make([]string, len(queued))
+	for i, q := range queued {
+		ids[i] = q.Request.ContentID
+	}
+	return ids
+}
+
+func TestAdmissionQueueOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy QueuePolicy
+		want   []string
+	}{
+		{"fifo keeps arrival order", QueueFIFO, []string{"c1", "c2", "c3", "c4"}},
+		{"priority serves restores first", QueuePriority, []string{"c2", "c4", "c1", "c3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultAdmissionConfig()
+			config.MaxHotPerCluster = 1
+			config.Policy = tt.policy
+			admission, clock := newTestAdmission(config)
+			var admitted []string
+			admission.OnAdmit = func(req ActivationRequest) { admitted = append(admitted, req.ContentID) }
+
+			admission.Admit(ActivationRequest{SessionID: "s0", UserID: "u0", ContentID: "c0"})
+			for i, priority := range []int{0, 1, 0, 1} {
+				clock.Advance(time.Second)
+				id := fmt.Sprintf("c%d", i+1)
+				if ok, _, err := admission.Admit(ActivationRequest{SessionID: "s-" + id, UserID: "u-" + id, ContentID: id, Priority: priority}); ok || err != nil {
+					t.Fatalf("Admit %s = %v, %v; want queued", id, ok, err)
+				}
+			}
+			if got := queuedContent(admission.Queue()); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("queue = %v, want %v", got, tt.want)
+			}
+
+			// Asking again keeps the place in the queue
+			_, position, _ := admission.Admit(ActivationRequest{SessionID: "s-c3", UserID: "u-c3", ContentID: "c3"})
+			if want := indexOf(tt.want, "c3") + 1; position.Position != want {
+				t.Errorf("repeated request at position %d, want %d", position.Position, want)
+			}
+
+			previous := "c0"
+			for range tt.want {
+				admission.Release(previous)
+				previous = admitted[len(admitted)-1]
+			}
+			if !reflect.DeepEqual(admitted, tt.want) {
+				t.Errorf("admitted %v, want %v", admitted, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdmissionPerUserLimit(t *testing.T) {
+	tests := []struct {
+		name         string
+		perCluster   int
+		perUser      int
+		requests     [][2]string // user, content
+		wantAdmitted []string
+		wantQueued   []string
+	}{
+		{
+			name:         "user at limit is queued",
+			perUser:      1,
+			requests:     [][2]string{{"u1", "a"}, {"u1", "b"}, {"u2", "c"}},
+			wantAdmitted: []string{"a", "c"},
+			wantQueued:   []string{"b"},
+		},
+		{
+			name:         "content already HOT shares its slot",
+			perUser:      1,
+			requests:     [][2]string{{"u1", "a"}, {"u2", "a"}, {"u2", "b"}},
+			wantAdmitted: []string{"a", "a", "b"},
+		},
+		{
+			name:         "cluster limit applies across users",
+			perCluster:   2,
+			perUser:      2,
+			requests:     [][2]string{{"u1", "a"}, {"u1", "b"}, {"u2", "c"}, {"u1", "d"}},
+			wantAdmitted: []string{"a", "b"},
+			wantQueued:   []string{"c", "d"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultAdmissionConfig()
+			config.MaxHotPerCluster = tt.perCluster
+			config.MaxHotPerUser = tt.perUser
+			admission, _ := newTestAdmission(config)
+
+			admitted := []string{}
+			for i, r := range tt.requests {
+				ok, _, err := admission.Admit(ActivationRequest{SessionID: fmt.Sprintf("s%d", i), UserID: r[0], ContentID: r[1]})
+				if err != nil {
+					t.Fatalf("Admit: %v", err)
+				}
+				if ok {
+					admitted = append(admitted, r[1])
+				}
+			}
+			if !reflect.DeepEqual(admitted, tt.wantAdmitted) {
+				t.Errorf("admitted %v, want %v", admitted, tt.wantAdmitted)
+			}
+			if got := queuedContent(admission.Queue()); !reflect.DeepEqual(got, append([]string{}, tt.wantQueued...)) {
+				t.Errorf("queued %v, want %v", got, tt.wantQueued)
+			}
+		})
+	}
+}
+
+func TestAdmissionSkipsUserAtLimit(t *testing.T) {
+	config := DefaultAdmissionConfig()
+	config.MaxHotPerCluster = 2
+	config.MaxHotPerUser = 1
+	config.Policy = QueueFIFO
+	admission, _ := newTestAdmission(config)
+	var admitted []string
+	admission.OnAdmit = func(req ActivationRequest) { admitted = append(admitted, req.ContentID) }
+
+	admission.Admit(ActivationRequest{SessionID: "s1", UserID: "u1", ContentID: "a"})
+	admission.Admit(ActivationRequest{SessionID: "s2", UserID: "u2", ContentID: "b"})
+	admission.Admit(ActivationRequest{SessionID: "s1", UserID: "u1", ContentID: "c"})
+	admission.Admit(ActivationRequest{SessionID: "s3", UserID: "u3", ContentID: "d"})
+
+	// u1 still holds a, so the freed slot goes to u3 behind them
+	admission.Release("b")
+	if !reflect.DeepEqual(admitted, []string{"d"}) {
+		t.Fatalf("admitted %v, want [d]", admitted)
+	}
+	admission.Release("a")
+	if !reflect.DeepEqual(admitted, []string{"d", "c"}) {
+		t.Errorf("admitted %v, want [d c]", admitted)
+	}
+}
+
+func TestAdmissionETA(t *testing.T) {
+	config := DefaultAdmissionConfig()
+	config.MaxHotPerCluster = 2
+	config.ExpectedHoldTime = 2 * time.Minute
+	admission, clock := newTestAdmission(config)
+
+	for i, id := range []string{"a", "b", "c", "d", "e"} {
+		admission.Admit(ActivationRequest{SessionID: fmt.Sprintf("s%d", i), UserID: fmt.Sprintf("u%d", i), ContentID: id})
+	}
+	tests := []struct {
+		name    string
+		advance time.Duration
+		release string
+		want    []time.Duration
+	}{
+		{"expected hold time per batch", 0, "", []time.Duration{2 * time.Minute, 2 * time.Minute, 4 * time.Minute}},
+		{"short hold lowers the estimate", time.Minute, "a", []time.Duration{108 * time.Second, 108 * time.Second}},
+		{"long hold raises it", 10 * time.Minute, "b", []time.Duration{218400 * time.Millisecond}},
+	}
+	for _, tt := range tests {
+		clock.Advance(tt.advance)
+		if tt.release != "" {
+			admission.Release(tt.release)
+		}
+		queued := admission.Queue()
+		got := make([]time.Duration, len(queued))
+		for i, q := range queued {
+			got[i] = q.ETA
+			if q.Position != i+1 || q.QueueLength != len(queued) {
+				t.Errorf("%s: request %d at position %d of %d", tt.name, i, q.Position, q.QueueLength)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ETAs %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAdmissionCancel(t *testing.T) {
+	tests := []struct {
+		name       string
+		cancel     func(a *AdmissionController) int
+		wantCount  int
+		wantQueued []string
+	}{
+		{
+			name:       "disconnect drops every request of the session",
+			cancel:     func(a *AdmissionController) int { return a.CancelSession("s1", "disconnected") },
+			wantCount:  2,
+			wantQueued: []string{"c"},
+		},
+		{
+			name: "deactivation drops one request",
+			cancel: func(a *AdmissionController) int {
+				if a.Cancel("s1", "d", "deactivated") {
+					return 1
+				}
+				return 0
+			},
+			wantCount:  1,
+			wantQueued: []string{"b", "c"},
+		},
+		{
+			name:       "unknown session drops nothing",
+			cancel:     func(a *AdmissionController) int { return a.CancelSession("s9", "disconnected") },
+			wantQueued: []string{"b", "c", "d"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultAdmissionConfig()
+			config.MaxHotPerCluster = 1
+			config.Policy = QueueFIFO
+			admission, _ := newTestAdmission(config)
+			var cancelled []string
+			admission.OnCancel = func(req ActivationRequest, reason string) {
+				cancelled = append(cancelled, req.ContentID+":"+reason)
+			}
+			var notified []QueuedActivation
+			admission.OnQueueChanged = func(queued []QueuedActivation) { notified = queued }
+
+			admission.Admit(ActivationRequest{SessionID: "s0", UserID: "u0", ContentID: "a"})
+			admission.Admit(ActivationRequest{SessionID: "s1", UserID: "u1", ContentID: "b"})
+			admission.Admit(ActivationRequest{SessionID: "s2", UserID: "u2", ContentID: "c"})
+			admission.Admit(ActivationRequest{SessionID: "s1", UserID: "u1", ContentID: "d"})
+			notified = nil
+
+			if n := tt.cancel(admission); n != tt.wantCount || len(cancelled) != tt.wantCount {
+				t.Fatalf("cancelled %d %v, want %d", n, cancelled, tt.wantCount)
+			}
+			if got := queuedContent(admission.Queue()); !reflect.DeepEqual(got, tt.wantQueued) {
+				t.Errorf("queued %v, want %v", got, tt.wantQueued)
+			}
+			if tt.wantCount > 0 && !reflect.DeepEqual(queuedContent(notified), tt.wantQueued) {
+				t.Errorf("notified positions %v, want %v", queuedContent(notified), tt.wantQueued)
+			}
+		})
+	}
+}
+
+func indexOf(ids []string, id string) int {
+	for i, candidate := range ids {
+		if candidate == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,133 @@
+package engine
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is the source of time for engine components, so they can run on a
+// virtual timeline (see FakeClock) when replaying recorded traffic
+type Clock interface {
+	Now() time.Time
+	// AfterFunc calls f in its own goroutine (RealClock) or synchronously while the
+	// clock is advanced (FakeClock) once d has elapsed
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a pending AfterFunc call
+type Timer interface {
+	// Stop prevents the call from running; it returns false if it already ran or was stopped
+	Stop() bool
+}
+
+// RealClock is the wall clock
+type RealClock struct{}
+
+// Now returns the current wall-clock time
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+// AfterFunc waits for d of wall-clock time and then calls f in its own goroutine
+func (RealClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// FakeClock is a virtual clock that only moves when advanced. Timers run
+// synchronously, in deadline order, on the goroutine calling Advance.
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+	seq    uint64 // orders timers with the same deadline by creation
+}
+
+type fakeTimer struct {
+	clock *FakeClock
+	when  time.Time
+	seq   uint64
+	f     func()
+}
+
+// NewFakeClock creates a virtual clock starting at the given time
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// Now returns the virtual time
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// AfterFunc schedules f to run once the clock has been advanced by d
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.seq++
+	t := &fakeTimer{clock: c, when: c.now.Add(d), seq: c.seq, f: f}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance moves the clock forward by d, running every timer that falls due
+func (c *FakeClock) Advance(d time.Duration) {
+	c.AdvanceTo(c.Now().Add(d))
+}
+
+// AdvanceTo moves the clock forward to target, running every timer due by then,
+// including timers scheduled by the timers it runs. Moving backwards is a no-op.
+func (c *FakeClock) AdvanceTo(target time.Time) {
+	for {
+		c.mu.Lock()
+		next := -1
+		for i, t := range c.timers {
+			if t.when.After(target) {
+				continue
+			}
+			if next < 0 || t.when.Before(c.timers[next].when) ||
+				(t.when.Equal(c.timers[next].when) && t.seq < c.timers[next].seq) {
+				next = i
+			}
+		}
+		if next < 0 {
+			if target.After(c.now) {
+				c.now = target
+			}
+			c.mu.Unlock()
+			return
+		}
+
+		t := c.timers[next]
+		c.timers = append(c.timers[:next], c.timers[next+1:]...)
+		if t.when.After(c.now) {
+			c.now = t.when
+		}
+		c.mu.Unlock()
+
+		t.f()
+	}
+}
+
+// Pending returns the number of timers that have not run yet
+func (c *FakeClock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// Stop removes the timer from its clock
+func (t *fakeTimer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, pending := range c.timers {
+		if pending == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package engine
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gavigo/orchestrator/internal/models"
+)
+
+func TestFakeClockRunsTimersInDeadlineOrder(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	var order []string
+	clock.AfterFunc(2*time.Second, func() { order = append(order, "b") })
+	clock.AfterFunc(time.Second, func() {
+		order = append(order, "a")
+		clock.AfterFunc(500*time.Millisecond, func() { order = append(order, "a+") })
+	})
+	stopped := clock.AfterFunc(1500*time.Millisecond, func() { order = append(order, "stopped") })
+	clock.AfterFunc(2*time.Second, func() { order = append(order, "c") })
+
+	if !stopped.Stop() || stopped.Stop() {
+		t.Fatal("Stop should succeed once")
+	}
+	clock.Advance(2 * time.Second)
+
+	want := []string{"a", "a+", "b", "c"}
+	if !equalIDs(order, want) {
+		t.Errorf("ran %v, want %v", order, want)
+	}
+	if got := clock.Now(); !got.Equal(testStart.Add(2 * time.Second)) {
+		t.Errorf("now = %v, want start + 2s", got)
+	}
+	if clock.Pending() != 0 {
+		t.Errorf("%d timers left", clock.Pending())
+	}
+}
+
+func TestScorerDecayOnFakeClock(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	config := DefaultScorerConfig()
+	scorer := NewScorer(config)
+	scorer.SetClock(clock)
+	scorer.StartDecay()
+
+	scorer.RecordFocusEvent("s1", "game-a", 5000, "puzzle") // +0.5 personal, +0.05 global
+	clock.Advance(config.DecayInterval - time.Millisecond)
+	if got := scorer.GetScores("s1", "game-a").PersonalScore; got != 0.5 {
+		t.Fatalf("personal = %v before the first decay, want 0.5", got)
+	}
+
+	clock.Advance(time.Millisecond + 2*config.DecayInterval) // Three decays
+	factor := math.Pow(1-config.DecayRate, 3)
+	scores := scorer.GetScores("s1", "game-a")
+	if math.Abs(scores.PersonalScore-0.5*factor) > 1e-12 {
+		t.Errorf("personal = %v, want %v", scores.PersonalScore, 0.5*factor)
+	}
+	if math.Abs(scores.GlobalScore-0.05*factor) > 1e-12 {
+		t.Errorf("global = %v, want %v", scores.GlobalScore, 0.05*factor)
+	}
+}
+
+func TestReaperRestoreWindowOnFakeClock(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	reaper := NewIdleReaper(&ReaperConfig{
+		HotIdleTimeout:  10 * time.Minute,
+		WarmIdleTimeout: 3 * time.Minute,
+		RestoreWindow:   5 * time.Minute,
+		SweepInterval:   time.Minute,
+	})
+	reaper.SetClock(clock)
+
+	var demoted []string
+	reaper.OnCoolDown = func(contentID string, oldState, newState models.ContainerStatus, idleFor time.Duration) {
+		demoted = append(demoted, contentID+":"+string(newState)+":"+idleFor.String())
+	}
+	states := map[string]models.ContainerStatus{"game-a": models.StatusWarm}
+	reaper.MarkDeactivated("game-a")
+	reaper.Start(func() map[string]models.ContainerStatus { return states })
+	defer reaper.Stop()
+
+	// Idle past the warm timeout but still inside the restore window
+	clock.Advance(4 * time.Minute)
+	if len(demoted) != 0 {
+		t.Fatalf("demoted inside the restore window: %v", demoted)
+	}
+
+	clock.Advance(time.Minute)
+	want := []string{"game-a:COLD:5m0s"}
+	if !equalIDs(demoted, want) {
+		t.Errorf("demoted = %v, want %v", demoted, want)
+	}
+}
+
+func TestSpineLatencyOnFakeClock(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	var events []models.ActivationSpineEvent
+	spine := NewActivationSpine(func(event *models.ActivationSpineEvent) { events = append(events, *event) })
+	spine.SetClock(clock)
+
+	spine.RecordPhase("game-a", "s1", models.PhaseIntent, "user", models.WeightIdle, false)
+	clock.Advance(1234 * time.Millisecond)
+	spine.RecordPhase("game-a", "", models.PhaseHot, "user", models.WeightFull, false)
+
+	if len(events) != 2 {
+		t.Fatalf("%d events, want 2", len(events))
+	}
+	hot := events[1]
+	if hot.ElapsedFromStart != 1234 || hot.SessionID != "s1" || !hot.Timestamp.Equal(testStart.Add(1234*time.Millisecond)) {
+		t.Errorf("HOT event = %+v, want 1234ms after intent for s1", hot)
+	}
+}
+
+func TestSimulatedDelaysAreSeeded(t *testing.T) {
+	a, b := NewRand(42), NewRand(42)
+	for i := 0; i < 20; i++ {
+		contentType := []models.ContentType{models.ContentTypeGame, models.ContentTypeAIService, models.ContentTypeVideo}[i%3]
+		da, db := SimulatedStartupDelay(a, contentType), SimulatedStartupDelay(b, contentType)
+		if da != db {
+			t.Fatalf("same seed gave %s and %s", da, db)
+		}
+		base, jitter := StartupDelayRange(contentType)
+		if da < base || da >= base+jitter {
+			t.Errorf("%s delay %s outside [%s, %s)", contentType, da, base, base+jitter)
+		}
+		if ra, rb := SimulatedRestoreDelay(a), SimulatedRestoreDelay(b); ra != rb || ra < 200*time.Millisecond || ra >= 500*time.Millisecond {
+			t.Errorf("restore delays %s and %s, want equal and in [200ms, 500ms)", ra, rb)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package engine
+
+import "github.com/gavigo/orchestrator/internal/models"
+
+// Rule IDs recorded in decision explanations
+const (
+	RuleScoreHot           = "score_hot"              // Combined score entered the HOT band
+	RuleScoreWarm          = "score_warm"             // Combined score entered the WARM band
+	RuleScoreHotRelease    = "score_hot_release"      // Score-promoted HOT item left the HOT band
+	RuleSwarmTrend         = "swarm_trend"            // Viral score crossed the swarm threshold
+	RuleCrossDomainInject  = "cross_domain_injection" // Related content injected after long focus
+	RuleCrossDomainPrewarm = "cross_domain_prewarm"   // Injected content warmed ahead of the user
+	RuleEngagementWarm     = "engagement_warm"        // Focused content's score crossed the warm threshold
+	RuleModeFocus          = "mode_focus"             // Long focus switched the operational mode
+	RuleFocusThrottle      = "focus_throttle"         // Background workloads throttled for focus mode
+	RuleBrowseRestore      = "browse_restore"         // Resources restored for mixed browsing
+	RuleInitialLoad        = "initial_load"           // First items warmed on page load
+	RuleScrollLookahead    = "scroll_lookahead"       // Items ahead of the viewport warmed while scrolling
+	RuleManualControl      = "manual_control"         // Demo control issued by an operator
+	RuleWorkloadFailure    = "workload_failure"       // A workload pod could not run
+)
+
+// scoreFeatures returns input scores as explanation features
+func scoreFeatures(scores models.InputScores) map[string]float64 {
+	return map[string]float64{
+		"personal_score": scores.PersonalScore,
+		"global_score":   scores.GlobalScore,
+		"combined_score": scores.CombinedScore,
+	}
+}
+
+// withFeature adds a feature to an explanation feature set
+func withFeature(features map[string]float64, name string, value float64) map[string]float64 {
+	features[name] = value
+	return features
+}
+
+// explain builds a decision explanation
+func explain(ruleID string, thresholds, features map[string]float64) *models.DecisionExplanation {
+	return &models.DecisionExplanation{
+		RuleID:     ruleID,
+		Thresholds: thresholds,
+		Features:   features,
+	}
+}
@@ -0,0 +1,166 @@
+package engine
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gavigo/orchestrator/internal/models"
+)
+
+// FailureConfig holds configuration for the workload failure policy
+type FailureConfig struct {
+	MaxRetries         int           // Re-warms of a failing item before it is cooled down
+	RetryBackoff       time.Duration // Wait before the first re-warm; doubled for each further one
+	MaxRetryBackoff    time.Duration // Upper bound of the wait between re-warms
+	UnavailableReasons []string      // Failures a retry cannot fix; the content is marked unavailable
+	StablePeriod       time.Duration // How long a pod stays ready without restarting before retries are cleared
+}
+
+// DefaultFailureConfig returns default workload failure policy configuration
+func DefaultFailureConfig() *FailureConfig {
+	return &FailureConfig{
+		MaxRetries:      2,
+		RetryBackoff:    10 * time.Second,
+		MaxRetryBackoff: 2 * time.Minute,
+		StablePeriod:    2 * time.Minute,
+		UnavailableReasons: []string{
+			"ErrImagePull",
+			"ImagePullBackOff",
+			"InvalidImageName",
+			"CreateContainerConfigError",
+		},
+	}
+}
+
+// Fallback is what to do about content whose workload failed
+type Fallback struct {
+	Action  models.ActionType // ActionRetryWarm, ActionCoolDown or ActionMarkUnavailable
+	Attempt int               // Retry number for ActionRetryWarm
+	Backoff time.Duration     // Wait before the retry for ActionRetryWarm
+}
+
+// FailurePolicy chooses fallbacks for failed workloads: content is re-warmed
+// with exponential backoff until its retries are used up, then cooled down.
+// Failures no retry can fix, such as a missing image, mark it unavailable.
+// Retries are cleared once a pod has stayed ready for the stable period
+// without restarting, so a crash-looping pod that is briefly ready between
+// crashes keeps using them up.
+type FailurePolicy struct {
+	mu       sync.Mutex
+	attempts map[string]int          // Retries per content item since its workload was last healthy
+	ready    map[string]*readyWindow // Content whose pod is ready but not yet stable
+
+	config      *FailureConfig
+	unavailable map[string]bool
+	clock       Clock
+}
+
+// readyWindow is a pod that turned ready and has not restarted since
+type readyWindow struct {
+	pod      string
+	restarts int32
+	timer    Timer
+}
+
+// NewFailurePolicy creates a new workload failure policy
+func NewFailurePolicy(config *FailureConfig) *FailurePolicy {
+	if config == nil {
+		config = DefaultFailureConfig()
+	}
+	unavailable := make(map[string]bool, len(config.UnavailableReasons))
+	for _, reason := range config.UnavailableReasons {
+		unavailable[reason] = true
+	}
+	return &FailurePolicy{
+		attempts:    make(map[string]int),
+		ready:       make(map[string]*readyWindow),
+		config:      config,
+		unavailable: unavailable,
+		clock:       RealClock{},
+	}
+}
+
+// SetClock sets the clock that times stable periods. Call it before use.
+func (p *FailurePolicy) SetClock(clock Clock) {
+	p.clock = clock
+}
+
+// Decide returns the fallback for a content item whose workload failed for reason
+func (p *FailurePolicy) Decide(contentID, reason string) Fallback {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stopReadyLocked(contentID)
+
+	if p.unavailable[reason] {
+		delete(p.attempts, contentID)
+		return Fallback{Action: models.ActionMarkUnavailable}
+	}
+	attempt := p.attempts[contentID] + 1
+	if attempt > p.config.MaxRetries {
+		// Cooled content starts over with fresh retries when it is next warmed
+		delete(p.attempts, contentID)
+		return Fallback{Action: models.ActionCoolDown}
+	}
+	p.attempts[contentID] = attempt
+
+	backoff := p.config.RetryBackoff << (attempt - 1)
+	if p.config.MaxRetryBackoff > 0 && (backoff > p.config.MaxRetryBackoff || backoff <= 0) {
+		backoff = p.config.MaxRetryBackoff
+	}
+	return Fallback{Action: models.ActionRetryWarm, Attempt: attempt, Backoff: backoff}
+}
+
+// Ready records that a pod serving a content item is ready with a restart
+// count. Its retries are cleared if the pod is still the one ready, with the
+// same restarts, after the stable period; a restart or another pod starts the
+// period over.
+func (p *FailurePolicy) Ready(contentID, pod string, restarts int32) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.attempts[contentID]; !ok {
+		p.stopReadyLocked(contentID)
+		return // Nothing to clear
+	}
+	if window, ok := p.ready[contentID]; ok && window.pod == pod && window.restarts == restarts {
+		return // Still counting
+	}
+	p.stopReadyLocked(contentID)
+
+	window := &readyWindow{pod: pod, restarts: restarts}
+	window.timer = p.clock.AfterFunc(p.config.StablePeriod, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.ready[contentID] != window {
+			return // Failed, restarted or reset meanwhile
+		}
+		delete(p.ready, contentID)
+		delete(p.attempts, contentID)
+		log.Printf("Content %s recovered: pod %s ready for %s without restarting", contentID, pod, p.config.StablePeriod)
+	})
+	p.ready[contentID] = window
+}
+
+// stopReadyLocked stops counting a content item's stable period. The caller holds p.mu.
+func (p *FailurePolicy) stopReadyLocked(contentID string) {
+	if window, ok := p.ready[contentID]; ok {
+		window.timer.Stop()
+		delete(p.ready, contentID)
+	}
+}
+
+// MaxRetries returns how many re-warms a failing item gets
+func (p *FailurePolicy) MaxRetries() int {
+	return p.config.MaxRetries
+}
+
+// Reset clears all retries and stable periods
+func (p *FailurePolicy) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for contentID := range p.ready {
+		p.stopReadyLocked(contentID)
+	}
+	p.attempts = make(map[string]int)
+}
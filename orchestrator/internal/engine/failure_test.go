@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gavigo/orchestrator/internal/models"
+)
+
+func newTestFailurePolicy() (*FailurePolicy, *FakeClock) {
+	clock := NewFakeClock(testStart)
+	config := DefaultFailureConfig()
+	config.MaxRetries = 3
+	config.StablePeriod = time.Minute
+	policy := NewFailurePolicy(config)
+	policy.SetClock(clock)
+	return policy, clock
+}
+
+func TestFailurePolicyKeepsRetriesUntilPodIsStable(t *testing.T) {
+	policy, clock := newTestFailurePolicy()
+
+	if fallback := policy.Decide("game-a", "CrashLoopBackOff"); fallback.Action != models.ActionRetryWarm || fallback.Attempt != 1 {
+		t.Fatalf("first failure = %+v, want retry 1", fallback)
+	}
+
+	// Ready between crashes: the restart starts the stable period over
+	policy.Ready("game-a", "pod-1", 1)
+	clock.Advance(30 * time.Second)
+	policy.Ready("game-a", "pod-1", 1) // Repeated status updates keep the period running
+	clock.Advance(20 * time.Second)
+	policy.Ready("game-a", "pod-1", 2)
+	clock.Advance(30 * time.Second)
+	if fallback := policy.Decide("game-a", "CrashLoopBackOff"); fallback.Attempt != 2 {
+		t.Fatalf("failure after a restart = %+v, want retry 2", fallback)
+	}
+
+	// A failure cancels the pending stable period
+	policy.Ready("game-a", "pod-1", 3)
+	clock.Advance(59 * time.Second)
+	if fallback := policy.Decide("game-a", "CrashLoopBackOff"); fallback.Attempt != 3 {
+		t.Fatalf("failure inside the stable period = %+v, want retry 3", fallback)
+	}
+	clock.Advance(time.Minute)
+	if fallback := policy.Decide("game-a", "CrashLoopBackOff"); fallback.Action != models.ActionCoolDown {
+		t.Fatalf("failure after retries ran out = %+v, want cool-down", fallback)
+	}
+	if clock.Pending() != 0 {
+		t.Errorf("%d timers left", clock.Pending())
+	}
+}
+
+func TestFailurePolicyClearsRetriesAfterStablePeriod(t *testing.T) {
+	policy, clock := newTestFailurePolicy()
+	policy.Decide("game-a", "CrashLoopBackOff")
+	policy.Decide("game-a", "CrashLoopBackOff")
+
+	// Another pod turning ready starts the period over
+	policy.Ready("game-a", "pod-1", 0)
+	clock.Advance(40 * time.Second)
+	policy.Ready("game-a", "pod-2", 0)
+	clock.Advance(40 * time.Second)
+	if fallback := policy.Decide("ai-c", "CrashLoopBackOff"); fallback.Attempt != 1 {
+		t.Fatalf("other content = %+v, want its own retry 1", fallback)
+	}
+	clock.Advance(20 * time.Second)
+
+	if fallback := policy.Decide("game-a", "CrashLoopBackOff"); fallback.Action != models.ActionRetryWarm || fallback.Attempt != 1 {
+		t.Errorf("failure after a stable period = %+v, want retry 1", fallback)
+	}
+
+	// Ready without earlier failures has nothing to clear
+	policy.Ready("video-b", "pod-3", 0)
+	policy.Ready("ai-c", "pod-4", 0)
+	policy.Reset()
+	if clock.Pending() != 0 {
+		t.Errorf("%d timers left after Reset", clock.Pending())
+	}
+}
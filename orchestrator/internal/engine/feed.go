@@ -0,0 +1,295 @@
+package engine
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gavigo/orchestrator/internal/models"
+)
+
+// FeedConfig holds configuration for feed ranking
+type FeedConfig struct {
+	ScoreWeight     float64 // Weight for the session's combined score
+	AffinityWeight  float64 // Weight for theme affinity from session focus times
+	FreshnessWeight float64 // Weight for items the session has not engaged with yet
+
+	// Focus time on an item after which it is no longer considered fresh
+	FreshnessSaturationMS int
+
+	// Maximum number of consecutive items with the same theme
+	MaxSameThemeRun int
+
+	DefaultPageSize int
+	MaxPageSize     int
+}
+
+// DefaultFeedConfig returns default feed ranking configuration
+func DefaultFeedConfig() *FeedConfig {
+	return &FeedConfig{
+		ScoreWeight:           0.5,
+		AffinityWeight:        0.3,
+		FreshnessWeight:       0.2,
+		FreshnessSaturationMS: 30000,
+		MaxSameThemeRun:       2,
+		DefaultPageSize:       10,
+		MaxPageSize:           50,
+	}
+}
+
+// FeedRanker orders content into a personalized stream for a session
+type FeedRanker struct {
+	config *FeedConfig
+}
+
+// NewFeedRanker creates a new feed ranker
+func NewFeedRanker(config *FeedConfig) *FeedRanker {
+	if config == nil {
+		config = DefaultFeedConfig()
+	}
+	return &FeedRanker{config: config}
+}
+
+// Rank returns all content for the session in rank order (highest rank score
+// first, ties by ID), with explanations. Page cuts it into diversified pages.
+func (r *FeedRanker) Rank(
+	content []models.ContentItem,
+	scores map[string]*models.InputScores,
+	session *models.UserSession,
+) []models.FeedItem {
+	totalFocus := 0
+	for _, ms := range session.FocusTimes {
+		totalFocus += ms
+	}
+
+	candidates := make([]models.FeedItem, 0, len(content))
+	for _, c := range content {
+		exp := models.FeedExplanation{}
+		if s := scores[c.ID]; s != nil {
+			exp.CombinedScore = s.CombinedScore
+		}
+		if totalFocus > 0 {
+			exp.ThemeAffinity = float64(session.GetFocusTime(c.Theme)) / float64(totalFocus)
+		}
+		exp.Freshness = 1.0
+		if r.config.FreshnessSaturationMS > 0 {
+			seen := float64(session.GetContentFocus(c.ID)) / float64(r.config.FreshnessSaturationMS)
+			exp.Freshness = 1.0 - math.Min(1.0, seen)
+		}
+		exp.RankScore = exp.CombinedScore*r.config.ScoreWeight +
+			exp.ThemeAffinity*r.config.AffinityWeight +
+			exp.Freshness*r.config.FreshnessWeight
+		exp.Reason = r.reason(exp, c.Theme)
+
+		candidates = append(candidates, models.FeedItem{Content: c, Explanation: exp})
+	}
+
+	// Ties are broken by ID so every item has a unique rank key for cursors
+	sort.Slice(candidates, func(i, j int) bool {
+		return rankKey(candidates[i]).before(rankKey(candidates[j]))
+	})
+	for i := range candidates {
+		candidates[i].Position = i
+	}
+	return candidates
+}
+
+// reason builds a short human-readable summary of the ranking factors
+func (r *FeedRanker) reason(exp models.FeedExplanation, theme string) string {
+	parts := []string{}
+	if exp.CombinedScore > 0 {
+		parts = append(parts, fmt.Sprintf("score %.2f", exp.CombinedScore))
+	}
+	if exp.ThemeAffinity > 0 {
+		parts = append(parts, fmt.Sprintf("%.0f%% of focus time on %s", exp.ThemeAffinity*100, theme))
+	}
+	if exp.Freshness >= 1.0 {
+		parts = append(parts, "not yet seen")
+	}
+	if len(parts) == 0 {
+		return "catalog order"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Page returns one page of items from Rank that follow the cursor. Items are
+// served in rank order, except that no more than MaxSameThemeRun items of the
+// same theme appear in a row when a later item of another theme exists, across
+// page boundaries too. The cursor holds the rank key up to which every item
+// was served rather than an offset, and the items served ahead of it to break
+// a run, so items are neither skipped nor repeated when rank scores shift
+// between requests.
+func (r *FeedRanker) Page(ranked []models.FeedItem, cursor string, limit int) ([]models.FeedItem, string, error) {
+	prev, err := decodeFeedCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+	if limit <= 0 {
+		limit = r.config.DefaultPageSize
+	}
+	if limit > r.config.MaxPageSize {
+		limit = r.config.MaxPageSize
+	}
+
+	start := 0
+	if prev.after != nil {
+		start = sort.Search(len(ranked), func(i int) bool {
+			return prev.after.before(rankKey(ranked[i]))
+		})
+	}
+	served := make(map[string]bool, len(prev.ahead))
+	for _, id := range prev.ahead {
+		served[id] = true
+	}
+	var remaining []models.FeedItem
+	for _, item := range ranked[start:] {
+		if !served[item.Content.ID] {
+			remaining = append(remaining, item)
+		}
+	}
+	position := len(ranked) - len(remaining)
+
+	page := make([]models.FeedItem, 0, limit)
+	theme, run := prev.theme, prev.run
+	for len(page) < limit && len(remaining) > 0 {
+		pick := 0
+		if r.config.MaxSameThemeRun > 0 && run >= r.config.MaxSameThemeRun {
+			for i, item := range remaining {
+				if item.Content.Theme != theme {
+					pick = i
+					break
+				}
+			}
+		}
+
+		item := remaining[pick]
+		if pick > 0 {
+			item.Explanation.DiversityAdjusted = true
+			item.Explanation.Reason += "; promoted to break a same-theme run"
+			served[item.Content.ID] = true
+		}
+		item.Position = position
+		position++
+		page = append(page, item)
+		remaining = append(remaining[:pick:pick], remaining[pick+1:]...)
+
+		if item.Content.Theme == theme {
+			run++
+		} else {
+			theme, run = item.Content.Theme, 1
+		}
+	}
+	if len(remaining) == 0 {
+		return page, "", nil
+	}
+
+	// Every item ranked ahead of the first one left is served; those served
+	// past it are carried in the cursor
+	next := feedCursor{after: prev.after, theme: theme, run: run}
+	first := rankKey(remaining[0])
+	for _, item := range ranked[start:] {
+		if !rankKey(item).before(first) {
+			break
+		}
+		key := rankKey(item)
+		next.after = &key
+	}
+	for _, item := range ranked[start:] {
+		if served[item.Content.ID] && first.before(rankKey(item)) {
+			next.ahead = append(next.ahead, item.Content.ID)
+		}
+	}
+	return page, encodeFeedCursor(next), nil
+}
+
+// feedKey orders ranked items: higher rank score first, then by ID
+type feedKey struct {
+	score float64
+	id    string
+}
+
+func rankKey(item models.FeedItem) feedKey {
+	return feedKey{score: item.Explanation.RankScore, id: item.Content.ID}
+}
+
+// before reports whether k ranks ahead of other
+func (k feedKey) before(other feedKey) bool {
+	if k.score != other.score {
+		return k.score > other.score
+	}
+	return k.id < other.id
+}
+
+// feedCursor is where a page continues the feed
+type feedCursor struct {
+	after *feedKey // Every item up to this rank key was served; nil at the start of the feed
+	ahead []string // Items ranked after it that were served to break a same-theme run
+	theme string   // Theme of the same-theme run the previous page ended with
+	run   int      // Length of that run
+}
+
+// encodeFeedCursor encodes a cursor as an opaque string
+func encodeFeedCursor(cursor feedCursor) string {
+	key := feedKey{}
+	if cursor.after != nil {
+		key = *cursor.after
+	}
+	ahead := make([]string, len(cursor.ahead))
+	for i, id := range cursor.ahead {
+		ahead[i] = url.QueryEscape(id)
+	}
+	value := strings.Join([]string{
+		strconv.FormatFloat(key.score, 'g', -1, 64),
+		strconv.Itoa(cursor.run),
+		url.QueryEscape(cursor.theme),
+		strings.Join(ahead, ","),
+		key.id,
+	}, ":")
+	return base64.RawURLEncoding.EncodeToString([]byte("feed:" + value))
+}
+
+// decodeFeedCursor decodes an opaque cursor (empty cursor = the start of the feed)
+func decodeFeedCursor(cursor string) (feedCursor, error) {
+	if cursor == "" {
+		return feedCursor{}, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return feedCursor{}, fmt.Errorf("invalid cursor: %w", err)
+	}
+	value, ok := strings.CutPrefix(string(raw), "feed:")
+	if !ok {
+		return feedCursor{}, fmt.Errorf("invalid cursor")
+	}
+	parts := strings.SplitN(value, ":", 5)
+	if len(parts) != 5 || parts[4] == "" {
+		return feedCursor{}, fmt.Errorf("invalid cursor rank key")
+	}
+	score, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return feedCursor{}, fmt.Errorf("invalid cursor rank key")
+	}
+	run, err := strconv.Atoi(parts[1])
+	if err != nil || run < 0 {
+		return feedCursor{}, fmt.Errorf("invalid cursor theme run")
+	}
+	theme, err := url.QueryUnescape(parts[2])
+	if err != nil {
+		return feedCursor{}, fmt.Errorf("invalid cursor theme run")
+	}
+	var ahead []string
+	if parts[3] != "" {
+		for _, escaped := range strings.Split(parts[3], ",") {
+			id, err := url.QueryUnescape(escaped)
+			if err != nil || id == "" {
+				return feedCursor{}, fmt.Errorf("invalid cursor served items")
+			}
+			ahead = append(ahead, id)
+		}
+	}
+	return feedCursor{after: &feedKey{score: score, id: parts[4]}, ahead: ahead, theme: theme, run: run}, nil
+}
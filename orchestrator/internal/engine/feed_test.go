@@ -0,0 +1,151 @@
+package engine
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gavigo/orchestrator/internal/models"
+)
+
+func feedContent(ids ...string) []models.ContentItem {
+	content := make([]models.ContentItem, 0, len(ids))
+	for _, id := range ids {
+		content = append(content, models.ContentItem{ID: id, Theme: "theme-" + id, Type: models.ContentTypeVideo})
+	}
+	return content
+}
+
+func feedIDs(items []models.FeedItem) []string {
+	ids := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.Content.ID)
+	}
+	return ids
+}
+
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestFeedPageCursorSurvivesRankChanges(t *testing.T) {
+	ranker := NewFeedRanker(nil)
+	session := models.NewSession("s1")
+	scores := map[string]*models.InputScores{
+		"a": {CombinedScore: 0.9},
+		"b": {CombinedScore: 0.8},
+		"c": {CombinedScore: 0.7},
+		"d": {CombinedScore: 0.6},
+		"e": {CombinedScore: 0.5},
+		"f": {CombinedScore: 0.4},
+	}
+	content := feedContent("a", "b", "c", "d", "e", "f")
+
+	first, cursor, err := ranker.Page(ranker.Rank(content, scores, session), "", 3)
+	if err != nil {
+		t.Fatalf("first page: %v", err)
+	}
+	if got := feedIDs(first); !equalIDs(got, []string{"a", "b", "c"}) {
+		t.Fatalf("first page = %v, want [a b c]", got)
+	}
+
+	// A new item ranks ahead of the served page and a served item drops
+	// within it; an offset cursor would repeat c
+	scores["x"] = &models.InputScores{CombinedScore: 0.95}
+	scores["a"].CombinedScore = 0.75
+	content = append(content, feedContent("x")...)
+
+	second, cursor, err := ranker.Page(ranker.Rank(content, scores, session), cursor, 3)
+	if err != nil {
+		t.Fatalf("second page: %v", err)
+	}
+	if got := feedIDs(second); !equalIDs(got, []string{"d", "e", "f"}) {
+		t.Fatalf("second page = %v, want [d e f]", got)
+	}
+	if cursor != "" {
+		t.Errorf("last page cursor = %q, want none", cursor)
+	}
+}
+
+func TestFeedPageTiesOrderedByID(t *testing.T) {
+	ranker := NewFeedRanker(nil)
+	ranked := ranker.Rank(feedContent("c", "a", "b"), nil, models.NewSession("s1"))
+
+	var seen []string
+	cursor := ""
+	for {
+		page, next, err := ranker.Page(ranked, cursor, 1)
+		if err != nil {
+			t.Fatalf("page: %v", err)
+		}
+		seen = append(seen, feedIDs(page)...)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if !equalIDs(seen, []string{"a", "b", "c"}) {
+		t.Errorf("paged = %v, want [a b c]", seen)
+	}
+}
+
+func TestFeedPageRejectsInvalidCursor(t *testing.T) {
+	ranker := NewFeedRanker(nil)
+	for _, cursor := range []string{"!!", "bm9wZQ", encodeFeedCursor(feedCursor{})} {
+		if _, _, err := ranker.Page(nil, cursor, 1); err == nil {
+			t.Errorf("Page(%q) succeeded, want error", cursor)
+		}
+	}
+}
+
+func TestFeedPageDiversityHoldsAcrossPages(t *testing.T) {
+	ranker := NewFeedRanker(nil)
+	scores := map[string]*models.InputScores{}
+	content := make([]models.ContentItem, 0, 6)
+	for i, theme := range []string{"puzzle", "puzzle", "puzzle", "puzzle", "racing", "racing"} {
+		id := fmt.Sprintf("c%d", i+1)
+		content = append(content, models.ContentItem{ID: id, Theme: theme, Type: models.ContentTypeVideo})
+		scores[id] = &models.InputScores{CombinedScore: 1 - float64(i)/10}
+	}
+	ranked := ranker.Rank(content, scores, models.NewSession("s1"))
+
+	// The first page ends with two puzzles, so the second starts with a
+	// racing item from further down rather than c3
+	var seen []models.FeedItem
+	cursor := ""
+	for {
+		page, next, err := ranker.Page(ranked, cursor, 2)
+		if err != nil {
+			t.Fatalf("page: %v", err)
+		}
+		seen = append(seen, page...)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if got := feedIDs(seen); !equalIDs(got, []string{"c1", "c2", "c5", "c3", "c4", "c6"}) {
+		t.Errorf("paged = %v, want [c1 c2 c5 c3 c4 c6]", got)
+	}
+	run := 0
+	for i, item := range seen {
+		if i > 0 && item.Content.Theme == seen[i-1].Content.Theme {
+			run++
+		} else {
+			run = 1
+		}
+		if run > DefaultFeedConfig().MaxSameThemeRun {
+			t.Fatalf("paged = %v: more than %d %s items in a row", feedIDs(seen), DefaultFeedConfig().MaxSameThemeRun, item.Content.Theme)
+		}
+	}
+	if len(seen) != len(content) {
+		t.Errorf("paged %d items, want %d", len(seen), len(content))
+	}
+}
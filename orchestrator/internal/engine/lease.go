@@ -0,0 +1,260 @@
+package engine
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LeaseConfig holds configuration for session leases on HOT content
+type LeaseConfig struct {
+	HeartbeatTimeout time.Duration // A lease expires when its session has not sent a heartbeat for this long
+	SweepInterval    time.Duration // How often to scan for expired leases
+}
+
+// DefaultLeaseConfig returns default lease configuration
+func DefaultLeaseConfig() *LeaseConfig {
+	return &LeaseConfig{
+		HeartbeatTimeout: 45 * time.Second,
+		SweepInterval:    10 * time.Second,
+	}
+}
+
+// LeaseManager reference-counts the sessions using each HOT content item, so
+// content stays HOT until the last session using it lets go
+type LeaseManager struct {
+	mu       sync.Mutex
+	leases   map[string]map[string]bool // content ID -> sessions holding a lease
+	lastSeen map[string]time.Time       // session ID -> last heartbeat
+
+	config *LeaseConfig
+	clock  Clock
+	timer  Timer  // Next scheduled sweep; nil when stopped
+	run    uint64 // Incremented by every Start so a stale sweep loop ends
+
+	// Callback when a session's lease expired; remaining is how many sessions still hold the content
+	OnExpire func(contentID, sessionID string, remaining int)
+}
+
+// NewLeaseManager creates a new lease manager
+func NewLeaseManager(config *LeaseConfig) *LeaseManager {
+	if config == nil {
+		config = DefaultLeaseConfig()
+	}
+	return &LeaseManager{
+		leases:   make(map[string]map[string]bool),
+		lastSeen: make(map[string]time.Time),
+		config:   config,
+		clock:    RealClock{},
+	}
+}
+
+// SetClock sets the clock used for heartbeats and sweep scheduling. Call it
+// before Start.
+func (m *LeaseManager) SetClock(clock Clock) {
+	m.clock = clock
+}
+
+// Acquire gives a session a lease on content and returns how many sessions
+// hold it. Acquiring counts as a heartbeat.
+func (m *LeaseManager) Acquire(contentID, sessionID string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	holders, ok := m.leases[contentID]
+	if !ok {
+		holders = make(map[string]bool)
+		m.leases[contentID] = holders
+	}
+	holders[sessionID] = true
+	m.lastSeen[sessionID] = m.clock.Now()
+	return len(holders)
+}
+
+// Release drops a session's lease on content and returns how many sessions
+// still hold it
+func (m *LeaseManager) Release(contentID, sessionID string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.releaseLocked(contentID, sessionID)
+}
+
+// ReleaseSession drops every lease a session holds and returns, for each
+// content item it held, how many sessions still hold it
+func (m *LeaseManager) ReleaseSession(sessionID string) map[string]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.lastSeen, sessionID)
+
+	released := make(map[string]int)
+	for contentID, holders := range m.leases {
+		if holders[sessionID] {
+			released[contentID] = m.releaseLocked(contentID, sessionID)
+		}
+	}
+	return released
+}
+
+// Renew records a heartbeat from a session and returns the content it holds
+func (m *LeaseManager) Renew(sessionID string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var held []string
+	for contentID, holders := range m.leases {
+		if holders[sessionID] {
+			held = append(held, contentID)
+		}
+	}
+	if len(held) > 0 {
+		m.lastSeen[sessionID] = m.clock.Now()
+	}
+	sort.Strings(held)
+	return held
+}
+
+// Forget drops every lease on content (e.g. after it left HOT some other way)
+// and returns the sessions that held one
+func (m *LeaseManager) Forget(contentID string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sessions := sortedSessions(m.leases[contentID])
+	delete(m.leases, contentID)
+	return sessions
+}
+
+// Count returns how many sessions hold a lease on content
+func (m *LeaseManager) Count(contentID string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.leases[contentID])
+}
+
+// Holds reports whether a session holds a lease on content
+func (m *LeaseManager) Holds(contentID, sessionID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.leases[contentID][sessionID]
+}
+
+// Holders returns the sessions holding a lease on each leased content item
+func (m *LeaseManager) Holders() map[string][]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	holders := make(map[string][]string, len(m.leases))
+	for contentID, sessions := range m.leases {
+		holders[contentID] = sortedSessions(sessions)
+	}
+	return holders
+}
+
+// Counts returns the number of lease holders per leased content item
+func (m *LeaseManager) Counts() map[string]int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	counts := make(map[string]int, len(m.leases))
+	for contentID, holders := range m.leases {
+		counts[contentID] = len(holders)
+	}
+	return counts
+}
+
+// Start begins periodic sweeps for expired leases
+func (m *LeaseManager) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.timer != nil {
+		return
+	}
+
+	m.run++
+	run := m.run
+	var sweep func()
+	sweep = func() {
+		m.Sweep()
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.timer != nil && m.run == run { // Not stopped during the sweep
+			m.timer = m.clock.AfterFunc(m.config.SweepInterval, sweep)
+		}
+	}
+	m.timer = m.clock.AfterFunc(m.config.SweepInterval, sweep)
+}
+
+// Stop halts periodic sweeps
+func (m *LeaseManager) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.timer != nil {
+		m.timer.Stop()
+		m.timer = nil
+	}
+}
+
+// Sweep expires the leases of sessions whose heartbeat timed out
+func (m *LeaseManager) Sweep() {
+	type expiry struct {
+		contentID string
+		sessionID string
+		remaining int
+	}
+	now := m.clock.Now()
+	var expired []expiry
+
+	m.mu.Lock()
+	for sessionID, seen := range m.lastSeen {
+		if now.Sub(seen) < m.config.HeartbeatTimeout {
+			continue
+		}
+		delete(m.lastSeen, sessionID)
+		for contentID, holders := range m.leases {
+			if holders[sessionID] {
+				expired = append(expired, expiry{contentID, sessionID, m.releaseLocked(contentID, sessionID)})
+			}
+		}
+	}
+	m.mu.Unlock()
+
+	sort.Slice(expired, func(i, j int) bool {
+		if expired[i].contentID != expired[j].contentID {
+			return expired[i].contentID < expired[j].contentID
+		}
+		return expired[i].sessionID < expired[j].sessionID
+	})
+	for _, e := range expired {
+		log.Printf("Lease expired: content=%s session=%s (%d remaining)", e.contentID, e.sessionID, e.remaining)
+		if m.OnExpire != nil {
+			m.OnExpire(e.contentID, e.sessionID, e.remaining)
+		}
+	}
+}
+
+// Reset drops every lease
+func (m *LeaseManager) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.leases = make(map[string]map[string]bool)
+	m.lastSeen = make(map[string]time.Time)
+}
+
+func sortedSessions(holders map[string]bool) []string {
+	sessions := make([]string, 0, len(holders))
+	for sessionID := range holders {
+		sessions = append(sessions, sessionID)
+	}
+	sort.Strings(sessions)
+	return sessions
+}
+
+func (m *LeaseManager) releaseLocked(contentID, sessionID string) int {
+	holders, ok := m.leases[contentID]
+	if !ok {
+		return 0
+	}
+	delete(holders, sessionID)
+	if len(holders) == 0 {
+		delete(m.leases, contentID)
+	}
+	return len(holders)
+}
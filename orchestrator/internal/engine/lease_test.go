@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestLeases() (*LeaseManager, *FakeClock) {
+	clock := NewFakeClock(testStart)
+	leases := NewLeaseManager(&LeaseConfig{HeartbeatTimeout: 45 * time.Second, SweepInterval: 10 * time.Second})
+	leases.SetClock(clock)
+	return leases, clock
+}
+
+func TestLeaseHeartbeatExpiry(t *testing.T) {
+	tests := []struct {
+		name        string
+		heartbeats  []time.Duration // Offsets at which s1 renews
+		after       time.Duration
+		wantExpired []string
+		wantHolders map[string][]string
+	}{
+		{
+			name:        "no heartbeat expires the lease",
+			after:       50 * time.Second,
+			wantExpired: []string{"game-a:s1:1", "video-b:s1:0"},
+			wantHolders: map[string][]string{"game-a": {"s2"}},
+		},
+		{
+			name:        "heartbeat renews every lease of the session",
+			heartbeats:  []time.Duration{30 * time.Second},
+			after:       70 * time.Second,
+			wantExpired: []string{},
+			wantHolders: map[string][]string{"game-a": {"s1", "s2"}, "video-b": {"s1"}},
+		},
+		{
+			name:        "lease expires a timeout after the last heartbeat",
+			heartbeats:  []time.Duration{30 * time.Second},
+			after:       80 * time.Second,
+			wantExpired: []string{"game-a:s1:1", "video-b:s1:0"},
+			wantHolders: map[string][]string{"game-a": {"s2"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leases, clock := newTestLeases()
+			expired := []string{}
+			leases.OnExpire = func(contentID, sessionID string, remaining int) {
+				expired = append(expired, fmt.Sprintf("%s:%s:%d", contentID, sessionID, remaining))
+			}
+			leases.Acquire("game-a", "s1")
+			leases.Acquire("video-b", "s1")
+			leases.Start()
+			defer leases.Stop()
+
+			// s2 keeps renewing throughout
+			elapsed := time.Duration(0)
+			step := func(to time.Duration) {
+				for elapsed+20*time.Second < to {
+					elapsed += 20 * time.Second
+					clock.Advance(20 * time.Second)
+					leases.Acquire("game-a", "s2")
+				}
+				clock.Advance(to - elapsed)
+				elapsed = to
+			}
+			leases.Acquire("game-a", "s2")
+			for _, at := range tt.heartbeats {
+				step(at)
+				if held := leases.Renew("s1"); !reflect.DeepEqual(held, []string{"game-a", "video-b"}) {
+					t.Fatalf("Renew = %v, want both leases", held)
+				}
+			}
+			step(tt.after)
+
+			if !reflect.DeepEqual(expired, tt.wantExpired) {
+				t.Errorf("expired %v, want %v", expired, tt.wantExpired)
+			}
+			if holders := leases.Holders(); !reflect.DeepEqual(holders, tt.wantHolders) {
+				t.Errorf("holders %v, want %v", holders, tt.wantHolders)
+			}
+		})
+	}
+}
+
+func TestLeaseSweepsStopWithManager(t *testing.T) {
+	leases, clock := newTestLeases()
+	expired := 0
+	leases.OnExpire = func(contentID, sessionID string, remaining int) { expired++ }
+	leases.Acquire("game-a", "s1")
+
+	leases.Start()
+	leases.Start() // Already running
+	if clock.Pending() != 1 {
+		t.Fatalf("%d sweeps scheduled, want 1", clock.Pending())
+	}
+	leases.Stop()
+	clock.Advance(time.Minute)
+	if expired != 0 || leases.Count("game-a") != 1 {
+		t.Fatalf("stopped manager expired %d leases", expired)
+	}
+
+	// A renewal without leases is not a heartbeat for later ones
+	if held := leases.Renew("s2"); len(held) != 0 {
+		t.Errorf("Renew = %v for a session without leases", held)
+	}
+	leases.Start()
+	clock.Advance(10 * time.Second)
+	if expired != 1 || leases.Count("game-a") != 0 {
+		t.Errorf("restarted manager expired %d leases, want 1", expired)
+	}
+	leases.Stop()
+	if clock.Pending() != 0 {
+		t.Errorf("%d timers left", clock.Pending())
+	}
+}
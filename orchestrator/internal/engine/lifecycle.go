@@ -0,0 +1,190 @@
+package engine
+
+import (
+	"log"
+
+	"github.com/gavigo/orchestrator/internal/models"
+)
+
+// ContainerLifecycle applies container state changes through the state machine and
+// records the matching activation spine phases and proof signals in one place
+type ContainerLifecycle struct {
+	machine *models.ContainerStateMachine
+	spine   *ActivationSpine
+	proof   *ProofSignalManager
+	clock   Clock
+	rng     *Rand
+
+	// Dependencies
+	GetContent   func(contentID string) *models.ContentItem
+	OnTransition func(t models.StateTransition) // Called after every applied transition
+}
+
+// NewContainerLifecycle creates a lifecycle driving the given spine and proof manager
+func NewContainerLifecycle(machine *models.ContainerStateMachine, spine *ActivationSpine, proof *ProofSignalManager) *ContainerLifecycle {
+	return &ContainerLifecycle{
+		machine: machine,
+		spine:   spine,
+		proof:   proof,
+		clock:   RealClock{},
+		rng:     NewTimeSeededRand(),
+	}
+}
+
+// SetClock sets the clock that times simulated container startups
+func (l *ContainerLifecycle) SetClock(clock Clock) {
+	l.clock = clock
+}
+
+// SetRand sets the random source for simulated startup delays
+func (l *ContainerLifecycle) SetRand(rng *Rand) {
+	l.rng = rng
+}
+
+// Current returns a container's current state
+func (l *ContainerLifecycle) Current(contentID string) models.ContainerStatus {
+	return l.machine.Current(contentID)
+}
+
+// Transition moves a container to a new state from whatever state it is in
+func (l *ContainerLifecycle) Transition(contentID string, to models.ContainerStatus, cause models.TransitionCause, sessionID string) (models.StateTransition, error) {
+	t, err := l.machine.Transition(contentID, to, cause, sessionID)
+	return t, l.applied(t, err)
+}
+
+// TransitionFrom moves a container to a new state only if it is still in from
+func (l *ContainerLifecycle) TransitionFrom(contentID string, from, to models.ContainerStatus, cause models.TransitionCause, sessionID string) (models.StateTransition, error) {
+	t, err := l.machine.TransitionFrom(contentID, from, to, cause, sessionID)
+	return t, l.applied(t, err)
+}
+
+// applied records side effects for a successful state change
+func (l *ContainerLifecycle) applied(t models.StateTransition, err error) error {
+	if err != nil || !t.Changed() {
+		return err
+	}
+	l.record(t)
+	if l.OnTransition != nil {
+		l.OnTransition(t)
+	}
+	return nil
+}
+
+// Warm brings a container to WARM. COLD and FAILED containers go through WARMING
+// and become WARM after the simulated startup delay; HOT ones are scaled back.
+func (l *ContainerLifecycle) Warm(contentID string, cause models.TransitionCause, sessionID string) error {
+	switch l.Current(contentID) {
+	case models.StatusWarm, models.StatusWarming:
+		return nil
+	case models.StatusHot, models.StatusCooling:
+		_, err := l.Transition(contentID, models.StatusWarm, cause, sessionID)
+		return err
+	}
+
+	if _, err := l.Transition(contentID, models.StatusWarming, cause, sessionID); err != nil {
+		return err
+	}
+
+	delay := SimulatedStartupDelay(l.rng, l.contentType(contentID))
+	l.clock.AfterFunc(delay, func() {
+		if _, err := l.TransitionFrom(contentID, models.StatusWarming, models.StatusWarm, models.CauseReady, sessionID); err != nil {
+			// Activated, cooled or failed while warming
+			log.Printf("Warming of %s did not complete: %v", contentID, err)
+		}
+	})
+	return nil
+}
+
+// Cool scales a container down to COLD, through COOLING if it was running
+func (l *ContainerLifecycle) Cool(contentID string, cause models.TransitionCause, sessionID string) error {
+	switch l.Current(contentID) {
+	case models.StatusCold:
+		return nil
+	case models.StatusWarming, models.StatusFailed, models.StatusCooling:
+		_, err := l.Transition(contentID, models.StatusCold, cause, sessionID)
+		return err
+	}
+
+	t, err := l.Transition(contentID, models.StatusCooling, cause, sessionID)
+	if err != nil {
+		return err
+	}
+	_, err = l.TransitionFrom(contentID, t.To, models.StatusCold, cause, sessionID)
+	return err
+}
+
+// contentType returns the type of a content item (GAME if unknown)
+func (l *ContainerLifecycle) contentType(contentID string) models.ContentType {
+	if l.GetContent != nil {
+		if content := l.GetContent(contentID); content != nil {
+			return content.Type
+		}
+	}
+	return models.ContentTypeGame
+}
+
+// record emits the spine phases and proof signals for a state change
+func (l *ContainerLifecycle) record(t models.StateTransition) {
+	id, session, source := t.ContentID, t.SessionID, string(t.Cause)
+
+	switch {
+	case t.To == models.StatusWarming:
+		// Videos warm by prefetching media rather than scaling a pod
+		if !l.contentType(id).UsesWorkload() {
+			source = "media_prefetch"
+		}
+		l.spine.RecordPhase(id, session, models.PhasePreWarm, source, models.WeightPreview, false)
+		l.proof.OnContainerStateChange(id, t.From, t.To)
+
+	case t.From == models.StatusWarming && t.To == models.StatusWarm:
+		source = "container_ready_simulated"
+		if !l.contentType(id).UsesWorkload() {
+			source = "media_prefetched_simulated"
+		}
+		l.spine.RecordPhase(id, session, models.PhasePreviewReady, source, models.WeightPreview, true)
+		l.proof.OnContainerStateChange(id, t.From, t.To)
+		l.proof.OnPreviewReady(id)
+
+	case t.To == models.StatusHot:
+		switch t.Cause {
+		case models.CauseRestore:
+			l.spine.RecordPhase(id, session, models.PhaseRestoreStart, source, models.WeightFull, false)
+			l.proof.OnRestoreStart(id)
+		case models.CauseActivation:
+			l.spine.RecordPhase(id, session, models.PhaseActivating, source, models.WeightFull, false)
+			l.spine.RecordPhase(id, session, models.PhaseHot, "activation_complete", models.WeightFull, false)
+		default:
+			l.spine.RecordPhase(id, session, models.PhaseHot, source, models.WeightFull, false)
+		}
+		l.proof.OnContainerStateChange(id, t.From, t.To)
+
+	case t.From == models.StatusHot && (t.To == models.StatusWarm || t.To == models.StatusCooling):
+		coolingSource := source
+		if t.Cause == models.CauseDeactivation {
+			coolingSource = "scaling_back"
+		}
+		l.spine.RecordPhase(id, session, models.PhaseDeactivating, source, models.WeightPreview, false)
+		l.spine.RecordPhase(id, session, models.PhaseCooling, coolingSource, models.WeightIdle, false)
+		l.spine.MarkPreviousHot(id)
+		if t.Cause == models.CauseDeactivation {
+			l.proof.OnDeactivation(id)
+		} else {
+			l.proof.OnCoolDown(id, t.From, t.To, source)
+		}
+
+	case t.To == models.StatusCooling:
+		l.spine.RecordPhase(id, session, models.PhaseCooling, source, models.WeightIdle, false)
+		l.proof.OnCoolDown(id, t.From, t.To, source)
+
+	case t.To == models.StatusCold:
+		// A container that is gone can no longer be restored
+		l.spine.ClearPreviousHot(id)
+		l.proof.OnCoolDown(id, t.From, t.To, source)
+		if t.Cause == models.CauseManual || t.Cause == models.CauseContentRemoved {
+			l.proof.InvalidateAttempt(id)
+		}
+
+	default:
+		l.proof.OnContainerStateChange(id, t.From, t.To)
+	}
+}
@@ -3,7 +3,6 @@ package engine
 import (
 	"fmt"
 	"sync"
-	"time"
 
 	"github.com/gavigo/orchestrator/internal/config"
 	"github.com/gavigo/orchestrator/internal/models"
@@ -42,6 +41,8 @@ type ProofSignalManager struct {
 	recentSignals  []*models.ProofSignalEvent // circular buffer
 	maxSignals     int
 
+	clock          Clock
+
 	onEmit           func(event *models.ProofSignalEvent)
 	onSnapshotUpdate func(snapshot *models.TelemetrySnapshot)
 }
@@ -56,13 +57,19 @@ func NewProofSignalManager(cfg *config.Config, onEmit func(*models.ProofSignalEv
 		focusLossTime:    make(map[string]int64),
 		recentSignals:    make([]*models.ProofSignalEvent, 0, 200),
 		maxSignals:       200,
+		clock:            RealClock{},
 		onEmit:           onEmit,
 		onSnapshotUpdate: onSnapshotUpdate,
 	}
 }
 
+// SetClock sets the clock used for signal timestamps, latencies and the restore window
+func (m *ProofSignalManager) SetClock(clock Clock) {
+	m.clock = clock
+}
+
 func (m *ProofSignalManager) nowMs() int64 {
-	return time.Now().UnixMilli()
+	return m.clock.Now().UnixMilli()
 }
 
 func (m *ProofSignalManager) getOrCreateAttempt(contentID string) *AttemptState {
@@ -89,7 +96,7 @@ func (m *ProofSignalManager) emit(contentID string, eventType models.ProofEventT
 	}
 
 	event := &models.ProofSignalEvent{
-		EventID:         fmt.Sprintf("proof-%d-%s", time.Now().UnixNano(), eventType),
+		EventID:         fmt.Sprintf("proof-%d-%s", m.clock.Now().UnixNano(), eventType),
 		ContentID:       contentID,
 		AttemptID:       att.AttemptID,
 		EventType:       eventType,
@@ -208,10 +215,10 @@ func (m *ProofSignalManager) OnContainerStateChange(contentID string, oldState,
 	att.CurrentState = newState
 
 	switch {
-	case newState == models.StatusWarm && oldState == models.StatusCold:
+	case newState == models.StatusWarming:
 		att.PrewarmStartTs = m.nowMs()
 		event := &models.ProofSignalEvent{
-			EventID:         fmt.Sprintf("proof-%d-%s", time.Now().UnixNano(), models.ProofPrewarmStart),
+			EventID:         fmt.Sprintf("proof-%d-%s", m.clock.Now().UnixNano(), models.ProofPrewarmStart),
 			ContentID:       contentID,
 			AttemptID:       att.AttemptID,
 			EventType:       models.ProofPrewarmStart,
@@ -233,7 +240,7 @@ func (m *ProofSignalManager) OnContainerStateChange(contentID string, oldState,
 	case newState == models.StatusHot:
 		att.HotEnteredTs = m.nowMs()
 		event := &models.ProofSignalEvent{
-			EventID:         fmt.Sprintf("proof-%d-%s", time.Now().UnixNano(), models.ProofHotStateEntered),
+			EventID:         fmt.Sprintf("proof-%d-%s", m.clock.Now().UnixNano(), models.ProofHotStateEntered),
 			ContentID:       contentID,
 			AttemptID:       att.AttemptID,
 			EventType:       models.ProofHotStateEntered,
@@ -270,8 +277,9 @@ func (m *ProofSignalManager) OnPreviewReady(contentID string) {
 	m.emit(contentID, models.ProofWarmReady, "preview_ready", nil)
 }
 
-// OnActivationRequest classifies the path and records the activation request
-func (m *ProofSignalManager) OnActivationRequest(contentID string, currentState models.ContainerStatus) {
+// OnActivationRequest classifies the path and records the activation request.
+// pooled is set when a warm pool pod was claimed for the activation.
+func (m *ProofSignalManager) OnActivationRequest(contentID string, currentState models.ContainerStatus, pooled bool) {
 	if !m.enabled {
 		return
 	}
@@ -292,6 +300,9 @@ func (m *ProofSignalManager) OnActivationRequest(contentID string, currentState
 	} else if currentState == models.StatusWarm || currentState == models.StatusHot {
 		att.PathType = models.PathPrewarm
 		att.CacheHit = true
+	} else if pooled {
+		att.PathType = models.PathPool
+		att.CacheHit = true
 	} else {
 		att.PathType = models.PathCold
 		att.CacheHit = false
@@ -366,6 +377,55 @@ func (m *ProofSignalManager) OnDeactivation(contentID string) {
 	m.focusLossTime[contentID] = now
 }
 
+// OnCoolDown records a cool-down transition (HOT->WARM, into COOLING, or into COLD)
+func (m *ProofSignalManager) OnCoolDown(contentID string, oldState, newState models.ContainerStatus, source string) {
+	if !m.enabled {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	att := m.getOrCreateAttempt(contentID)
+	att.CurrentState = newState
+
+	now := m.nowMs()
+	eventType := models.ProofCoolingStart
+	if newState == models.StatusCold {
+		// Restore is no longer possible once the container is gone
+		eventType = models.ProofColdStateEntered
+		delete(m.hotHistory, contentID)
+		delete(m.focusLossTime, contentID)
+	} else if oldState == models.StatusHot {
+		// Leaving HOT without a user deactivation is restore-eligible like one
+		m.hotHistory[contentID] = now
+		m.focusLossTime[contentID] = now
+	}
+
+	event := &models.ProofSignalEvent{
+		EventID:         fmt.Sprintf("proof-%d-%s", m.clock.Now().UnixNano(), eventType),
+		ContentID:       contentID,
+		AttemptID:       att.AttemptID,
+		EventType:       eventType,
+		TsServerMs:      now,
+		SourceEventType: source,
+		TriggerType:     att.TriggerType,
+		StateFrom:       string(oldState),
+		StateTo:         string(newState),
+	}
+	if len(m.recentSignals) >= m.maxSignals {
+		m.recentSignals = m.recentSignals[1:]
+	}
+	m.recentSignals = append(m.recentSignals, event)
+	if m.onEmit != nil {
+		m.onEmit(event)
+	}
+	m.emitSnapshot(att)
+
+	if newState == models.StatusCold {
+		att.Completed = true
+	}
+}
+
 // InvalidateAttempt clears the current attempt for a content item (used for force_warm/force_cold)
 func (m *ProofSignalManager) InvalidateAttempt(contentID string) {
 	m.mu.Lock()
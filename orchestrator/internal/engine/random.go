@@ -0,0 +1,39 @@
+package engine
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Rand is a seeded source of randomness that is safe for concurrent use. Engine
+// components take one instead of using the math/rand globals so a fixed seed
+// reproduces the same simulated delays.
+type Rand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+// NewRand creates a random source from a seed
+func NewRand(seed int64) *Rand {
+	return &Rand{r: rand.New(rand.NewSource(seed))}
+}
+
+// NewTimeSeededRand creates a random source seeded from the wall clock
+func NewTimeSeededRand() *Rand {
+	return NewRand(time.Now().UnixNano())
+}
+
+// Int63n returns a non-negative random number in [0, n)
+func (r *Rand) Int63n(n int64) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.r.Int63n(n)
+}
+
+// Intn returns a non-negative random number in [0, n)
+func (r *Rand) Intn(n int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.r.Intn(n)
+}
@@ -0,0 +1,195 @@
+package engine
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gavigo/orchestrator/internal/models"
+)
+
+// ReaperConfig holds configuration for the idle reaper
+type ReaperConfig struct {
+	HotIdleTimeout  time.Duration // Idle time before a HOT item is demoted to WARM
+	WarmIdleTimeout time.Duration // Idle time before a WARM item is demoted to COLD
+	RestoreWindow   time.Duration // WARM items deactivated within this window stay WARM for restore
+	SweepInterval   time.Duration // How often to scan for idle items
+}
+
+// DefaultReaperConfig returns default idle reaper configuration
+func DefaultReaperConfig() *ReaperConfig {
+	return &ReaperConfig{
+		HotIdleTimeout:  10 * time.Minute,
+		WarmIdleTimeout: 3 * time.Minute,
+		RestoreWindow:   2 * time.Minute,
+		SweepInterval:   15 * time.Second,
+	}
+}
+
+// IdleReaper demotes containers that have not been engaged with for a while
+type IdleReaper struct {
+	mu sync.Mutex
+
+	// Last engagement time per content item
+	lastEngaged map[string]time.Time
+
+	// Last deactivation time per content item (for restore window)
+	lastDeactivated map[string]time.Time
+
+	config *ReaperConfig
+	clock  Clock
+	timer  Timer  // Next scheduled sweep; nil when stopped
+	run    uint64 // Incremented by every Start so a stale sweep loop ends
+
+	// Callback when an idle item should be demoted
+	OnCoolDown func(contentID string, oldState, newState models.ContainerStatus, idleFor time.Duration)
+}
+
+// NewIdleReaper creates a new idle reaper
+func NewIdleReaper(config *ReaperConfig) *IdleReaper {
+	if config == nil {
+		config = DefaultReaperConfig()
+	}
+	return &IdleReaper{
+		lastEngaged:     make(map[string]time.Time),
+		lastDeactivated: make(map[string]time.Time),
+		config:          config,
+		clock:           RealClock{},
+	}
+}
+
+// SetClock sets the clock used for idle times and sweep scheduling. Call it
+// before Start.
+func (r *IdleReaper) SetClock(clock Clock) {
+	r.clock = clock
+}
+
+// Touch records engagement with a content item
+func (r *IdleReaper) Touch(contentID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastEngaged[contentID] = r.clock.Now()
+}
+
+// MarkDeactivated records that a content item left HOT, opening its restore window
+func (r *IdleReaper) MarkDeactivated(contentID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.clock.Now()
+	r.lastEngaged[contentID] = now
+	r.lastDeactivated[contentID] = now
+}
+
+// Forget drops tracking for a content item (e.g. after it is forced COLD)
+func (r *IdleReaper) Forget(contentID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.lastEngaged, contentID)
+	delete(r.lastDeactivated, contentID)
+}
+
+// LastEngaged returns the last engagement time for a content item
+func (r *IdleReaper) LastEngaged(contentID string) (time.Time, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.lastEngaged[contentID]
+	return t, ok
+}
+
+// Start begins periodic sweeps using getStates to read current container states
+func (r *IdleReaper) Start(getStates func() map[string]models.ContainerStatus) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.timer != nil {
+		return
+	}
+
+	r.run++
+	run := r.run
+	var sweep func()
+	sweep = func() {
+		r.Sweep(getStates())
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.timer != nil && r.run == run { // Not stopped during the sweep
+			r.timer = r.clock.AfterFunc(r.config.SweepInterval, sweep)
+		}
+	}
+	r.timer = r.clock.AfterFunc(r.config.SweepInterval, sweep)
+}
+
+// Stop halts periodic sweeps
+func (r *IdleReaper) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+}
+
+// Sweep checks all WARM and HOT items and demotes the ones that are idle
+func (r *IdleReaper) Sweep(states map[string]models.ContainerStatus) {
+	type demotion struct {
+		contentID string
+		oldState  models.ContainerStatus
+		newState  models.ContainerStatus
+		idleFor   time.Duration
+	}
+
+	now := r.clock.Now()
+	var demotions []demotion
+
+	r.mu.Lock()
+	for contentID, state := range states {
+		if state != models.StatusWarm && state != models.StatusHot {
+			continue
+		}
+
+		last, ok := r.lastEngaged[contentID]
+		if !ok {
+			// First time we see this item warm: start its idle clock now
+			r.lastEngaged[contentID] = now
+			continue
+		}
+		idleFor := now.Sub(last)
+
+		switch state {
+		case models.StatusHot:
+			if idleFor >= r.config.HotIdleTimeout {
+				// Leaving HOT opens a restore window just like a user deactivation
+				r.lastDeactivated[contentID] = now
+				r.lastEngaged[contentID] = now
+				demotions = append(demotions, demotion{contentID, state, models.StatusWarm, idleFor})
+			}
+		case models.StatusWarm:
+			if idleFor < r.config.WarmIdleTimeout {
+				continue
+			}
+			if deactivated, ok := r.lastDeactivated[contentID]; ok && now.Sub(deactivated) < r.config.RestoreWindow {
+				continue // Keep warm so the restore path stays available
+			}
+			delete(r.lastEngaged, contentID)
+			delete(r.lastDeactivated, contentID)
+			demotions = append(demotions, demotion{contentID, state, models.StatusCold, idleFor})
+		}
+	}
+	r.mu.Unlock()
+
+	for _, d := range demotions {
+		log.Printf("Idle reaper: content=%s idle for %s, cooling %s -> %s",
+			d.contentID, d.idleFor.Round(time.Second), d.oldState, d.newState)
+		if r.OnCoolDown != nil {
+			r.OnCoolDown(d.contentID, d.oldState, d.newState, d.idleFor)
+		}
+	}
+}
+
+// Reset clears all engagement tracking
+func (r *IdleReaper) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastEngaged = make(map[string]time.Time)
+	r.lastDeactivated = make(map[string]time.Time)
+}
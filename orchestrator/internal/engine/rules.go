@@ -3,6 +3,10 @@ package engine
 import (
 	"fmt"
 	"log"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gavigo/orchestrator/internal/models"
@@ -11,13 +15,24 @@ import (
 // RulesEngine implements the rule-based AI decision system
 type RulesEngine struct {
 	config *EngineConfig
+	clock  Clock
+
+	decisionSeq atomic.Uint64 // keeps decision IDs unique when the clock stands still
+
+	// Per-content transition tracking (hysteresis, dwell time, in-flight actions)
+	mu          sync.Mutex
+	transitions map[string]*contentTransition
+
+	// Weighted content relation graph for cross-domain recommendations
+	relations *models.RelationGraph
 
 	// Callbacks
-	OnDecision        func(decision *models.AIDecision)
+	OnDecision        func(decision *models.AIDecision) // Called when a decision is made, usually still pending
+	OnDecisionUpdated func(decision *models.AIDecision) // Called once the decision's action has succeeded or failed
 	OnModeChange      func(oldMode, newMode models.OperationalMode, reason string)
-	OnScaleAction     func(contentID string, targetState models.ContainerStatus)
+	OnScaleAction     func(contentID string, targetState models.ContainerStatus) error // Returns an error if the action could not be started
 	OnInject          func(content *models.ContentItem, position int, reason string)
-	OnThrottleAction  func(activeContentID string, mode models.OperationalMode)
+	OnThrottleAction  func(activeContentID string, mode models.OperationalMode) error // Returns once resources have been applied
 }
 
 // EngineConfig holds configuration for the rules engine
@@ -26,9 +41,24 @@ type EngineConfig struct {
 	WarmThreshold float64 // Score threshold to trigger WARM state
 	HotThreshold  float64 // Score threshold to trigger HOT state
 
+	// Hysteresis: a score must fall below these before the band is released
+	WarmDownThreshold float64 // Score below which the WARM band is released
+	HotDownThreshold  float64 // Score below which a score-promoted HOT item drops to WARM
+
+	// Debounce settings
+	MinDwellTime    time.Duration // Minimum time between transitions for the same content
+	InFlightTimeout time.Duration // How long an unconfirmed scale action blocks new ones
+
 	// Cross-domain recommendation settings
 	CrossDomainFocusThresholdMS int     // Focus duration to trigger cross-domain
 	CrossDomainScoreBoost       float64 // Score boost for related content
+	CrossDomainMaxInjections    int     // Max related items injected per recommendation
+	CrossDomainMaxHops          int     // Max relation graph hops to search
+	CrossDomainHopDecay         float64 // Weight multiplier per hop beyond the first
+
+	// Co-engagement learning settings
+	CoEngagementFocusThresholdMS int     // Focus per item before it counts as engaged in a session
+	CoEngagementWeightStep       float64 // Edge weight added per session engaging with both items
 
 	// Swarm intelligence settings
 	SwarmTrendThreshold float64 // Viral score threshold for swarm boost
@@ -40,12 +70,21 @@ type EngineConfig struct {
 // DefaultConfig returns default engine configuration
 func DefaultConfig() *EngineConfig {
 	return &EngineConfig{
-		WarmThreshold:               0.6,
-		HotThreshold:                0.8,
-		CrossDomainFocusThresholdMS: 5000,
-		CrossDomainScoreBoost:       0.2,
-		SwarmTrendThreshold:         0.7,
-		ModeFocusThresholdMS:        10000,
+		WarmThreshold:                0.6,
+		HotThreshold:                 0.8,
+		WarmDownThreshold:            0.45,
+		HotDownThreshold:             0.65,
+		MinDwellTime:                 5 * time.Second,
+		InFlightTimeout:              30 * time.Second,
+		CrossDomainFocusThresholdMS:  5000,
+		CrossDomainScoreBoost:        0.2,
+		CrossDomainMaxInjections:     2,
+		CrossDomainMaxHops:           2,
+		CrossDomainHopDecay:          0.5,
+		CoEngagementFocusThresholdMS: 5000,
+		CoEngagementWeightStep:       0.1,
+		SwarmTrendThreshold:          0.7,
+		ModeFocusThresholdMS:         10000,
 	}
 }
 
@@ -54,7 +93,31 @@ func NewRulesEngine(config *EngineConfig) *RulesEngine {
 	if config == nil {
 		config = DefaultConfig()
 	}
-	return &RulesEngine{config: config}
+	return &RulesEngine{
+		config:      config,
+		clock:       RealClock{},
+		transitions: make(map[string]*contentTransition),
+		relations:   models.DefaultRelationGraph(),
+	}
+}
+
+// SetClock sets the clock used for dwell times, timeouts and decision timestamps
+func (e *RulesEngine) SetClock(clock Clock) {
+	e.clock = clock
+}
+
+// SetRelationGraph replaces the content relation graph used for recommendations
+func (e *RulesEngine) SetRelationGraph(graph *models.RelationGraph) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.relations = graph
+}
+
+// Relations returns the content relation graph used for recommendations
+func (e *RulesEngine) Relations() *models.RelationGraph {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.relations
 }
 
 // ProcessFocusEvent handles user focus events and may trigger decisions
@@ -67,6 +130,9 @@ func (e *RulesEngine) ProcessFocusEvent(
 ) {
 	log.Printf("Processing focus event: content=%s, duration=%dms", focusedContent.ID, durationMS)
 
+	// Learn co-engagement relations from this session
+	e.learnCoEngagement(session, focusedContent.ID, durationMS)
+
 	// Rule 1: Cross-Domain Recommendation
 	if durationMS >= e.config.CrossDomainFocusThresholdMS {
 		e.checkCrossDomainRecommendation(session, focusedContent, allContent, scores)
@@ -77,7 +143,7 @@ func (e *RulesEngine) ProcessFocusEvent(
 
 	// Rule 3: Mode Change Detection
 	if durationMS >= e.config.ModeFocusThresholdMS {
-		e.checkModeChange(session, focusedContent)
+		e.checkModeChange(session, focusedContent, durationMS)
 	}
 }
 
@@ -90,17 +156,39 @@ func (e *RulesEngine) ProcessScoreUpdate(
 	log.Printf("Processing score update: content=%s, combined=%.2f, state=%s",
 		contentID, scores.CombinedScore, currentState)
 
-	// Rule: Scale based on combined score
-	if scores.CombinedScore >= e.config.HotThreshold && currentState != models.StatusHot {
-		e.makeDecision(models.TriggerCrossDomain, contentID, *scores,
+	// Rule: Scale based on the combined score's hysteresis band
+	band := e.updateBand(contentID, scores.CombinedScore)
+	state := e.effectiveState(contentID, currentState)
+
+	switch {
+	case band == bandHot && state != models.StatusHot:
+		if e.makeDecision(models.TriggerCrossDomain, contentID, *scores,
 			models.ActionScaleHot,
 			fmt.Sprintf("Combined score %.2f exceeds hot threshold %.2f",
-				scores.CombinedScore, e.config.HotThreshold))
-	} else if scores.CombinedScore >= e.config.WarmThreshold && currentState == models.StatusCold {
+				scores.CombinedScore, e.config.HotThreshold),
+			explain(RuleScoreHot, map[string]float64{
+				"hot_threshold":      e.config.HotThreshold,
+				"hot_down_threshold": e.config.HotDownThreshold,
+			}, scoreFeatures(*scores))) != nil {
+			e.setScorePromoted(contentID, true)
+		}
+	case band == bandWarm && state == models.StatusCold:
 		e.makeDecision(models.TriggerProactiveWarm, contentID, *scores,
 			models.ActionScaleWarm,
 			fmt.Sprintf("Combined score %.2f exceeds warm threshold %.2f",
-				scores.CombinedScore, e.config.WarmThreshold))
+				scores.CombinedScore, e.config.WarmThreshold),
+			explain(RuleScoreWarm, map[string]float64{
+				"warm_threshold":      e.config.WarmThreshold,
+				"warm_down_threshold": e.config.WarmDownThreshold,
+			}, scoreFeatures(*scores)))
+	case band != bandHot && state == models.StatusHot && e.isScorePromoted(contentID):
+		e.makeDecision(models.TriggerProactiveWarm, contentID, *scores,
+			models.ActionScaleWarm,
+			fmt.Sprintf("Combined score %.2f fell below hot release threshold %.2f",
+				scores.CombinedScore, e.config.HotDownThreshold),
+			explain(RuleScoreHotRelease, map[string]float64{
+				"hot_down_threshold": e.config.HotDownThreshold,
+			}, scoreFeatures(*scores)))
 	}
 }
 
@@ -115,70 +203,168 @@ func (e *RulesEngine) ProcessTrendSpike(
 
 	if viralScore >= e.config.SwarmTrendThreshold {
 		// Swarm intelligence: boost score and potentially scale
-		if currentState == models.StatusCold {
+		if e.effectiveState(contentID, currentState) == models.StatusCold {
 			e.makeDecision(models.TriggerSwarmBoost, contentID, *scores,
 				models.ActionScaleWarm,
-				fmt.Sprintf("Swarm intelligence detected viral trend (score: %.2f)", viralScore))
+				fmt.Sprintf("Swarm intelligence detected viral trend (score: %.2f)", viralScore),
+				explain(RuleSwarmTrend, map[string]float64{
+					"swarm_trend_threshold": e.config.SwarmTrendThreshold,
+				}, withFeature(scoreFeatures(*scores), "viral_score", viralScore)))
 		}
 	}
 }
 
-// checkCrossDomainRecommendation looks for related content to inject
+// checkCrossDomainRecommendation ranks related content from the relation graph and
+// injects the top candidates the session has not seen yet
 func (e *RulesEngine) checkCrossDomainRecommendation(
 	session *models.UserSession,
 	focusedContent *models.ContentItem,
 	allContent []*models.ContentItem,
 	scores map[string]*models.InputScores,
 ) {
-	// Find related content using CrossDomainRelations map
-	relatedID, hasRelation := models.CrossDomainRelations[focusedContent.ID]
-	if !hasRelation {
-		return
+	contentByID := make(map[string]*models.ContentItem, len(allContent))
+	for _, c := range allContent {
+		contentByID[c.ID] = c
 	}
 
-	// Skip if already injected
-	if session.HasInjected(relatedID) {
-		return
+	focusedScore := 0.0
+	if s := scores[focusedContent.ID]; s != nil {
+		focusedScore = s.CombinedScore
 	}
 
-	for _, content := range allContent {
-		if content.ID != relatedID {
+	type rankedCandidate struct {
+		content   *models.ContentItem
+		candidate models.RelationCandidate
+		relevance float64
+	}
+
+	// Rank candidates by path weight times the session's scores
+	var ranked []rankedCandidate
+	var considered []models.DecisionCandidate // candidates skipped before ranking
+	for _, cand := range e.Relations().Candidates(focusedContent.ID, e.config.CrossDomainMaxHops, e.config.CrossDomainHopDecay) {
+		if session.HasInjected(cand.ContentID) {
+			considered = append(considered, models.DecisionCandidate{
+				ContentID: cand.ContentID,
+				Score:     cand.Weight,
+				Reason:    "already injected in this session",
+			})
+			continue
+		}
+		content, ok := contentByID[cand.ContentID]
+		if !ok {
 			continue
 		}
+		candScore := 0.0
+		if s := scores[cand.ContentID]; s != nil {
+			candScore = s.CombinedScore
+		}
+		ranked = append(ranked, rankedCandidate{
+			content:   content,
+			candidate: cand,
+			relevance: cand.Weight * (focusedScore + candScore),
+		})
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].relevance != ranked[j].relevance {
+			return ranked[i].relevance > ranked[j].relevance
+		}
+		return ranked[i].candidate.Weight > ranked[j].candidate.Weight
+	})
+
+	// Record every ranked candidate so each decision shows what it competed with
+	for i, r := range ranked {
+		c := models.DecisionCandidate{
+			ContentID: r.content.ID,
+			Score:     r.relevance,
+			Selected:  i < e.config.CrossDomainMaxInjections,
+			Reason:    fmt.Sprintf("ranked %d by relevance", i+1),
+		}
+		if !c.Selected {
+			c.Reason += fmt.Sprintf(", beyond max injections (%d)", e.config.CrossDomainMaxInjections)
+		}
+		considered = append(considered, c)
+	}
+	if len(ranked) > e.config.CrossDomainMaxInjections {
+		ranked = ranked[:e.config.CrossDomainMaxInjections]
+	}
 
-		{
-			contentScores := scores[content.ID]
-			if contentScores == nil {
-				contentScores = &models.InputScores{}
-			}
+	thresholds := map[string]float64{
+		"focus_threshold_ms": float64(e.config.CrossDomainFocusThresholdMS),
+		"max_injections":     float64(e.config.CrossDomainMaxInjections),
+		"max_hops":           float64(e.config.CrossDomainMaxHops),
+		"hop_decay":          e.config.CrossDomainHopDecay,
+		"score_boost":        e.config.CrossDomainScoreBoost,
+	}
 
-			// Boost the score for cross-domain content
-			boostedScores := models.InputScores{
-				PersonalScore: contentScores.PersonalScore + e.config.CrossDomainScoreBoost,
-				GlobalScore:   contentScores.GlobalScore,
-				CombinedScore: contentScores.CombinedScore + e.config.CrossDomainScoreBoost*0.5,
-			}
+	for i, r := range ranked {
+		content := r.content
+		contentScores := scores[content.ID]
+		if contentScores == nil {
+			contentScores = &models.InputScores{}
+		}
+
+		// Boost the score for cross-domain content
+		boostedScores := models.InputScores{
+			PersonalScore: contentScores.PersonalScore + e.config.CrossDomainScoreBoost,
+			GlobalScore:   contentScores.GlobalScore,
+			CombinedScore: contentScores.CombinedScore + e.config.CrossDomainScoreBoost*0.5,
+		}
+
+		e.makeDecision(models.TriggerCrossDomain, content.ID, boostedScores,
+			models.ActionInjectContent,
+			fmt.Sprintf("Cross-domain recommendation: user engaged with %s %s, suggesting related %s via %s (%d hop, path %s, weight %.2f, relevance %.2f)",
+				focusedContent.Type, focusedContent.Theme, content.Type,
+				r.candidate.Via, r.candidate.Hops, strings.Join(r.candidate.Path, " > "),
+				r.candidate.Weight, r.relevance),
+			&models.DecisionExplanation{
+				RuleID:     RuleCrossDomainInject,
+				Thresholds: thresholds,
+				Features: map[string]float64{
+					"focused_score":   focusedScore,
+					"candidate_score": contentScores.CombinedScore,
+					"path_weight":     r.candidate.Weight,
+					"hops":            float64(r.candidate.Hops),
+					"relevance":       r.relevance,
+				},
+				Candidates: considered,
+			})
+
+		if e.OnInject != nil {
+			e.OnInject(content, i+1, fmt.Sprintf("Cross-domain recommendation based on %s relation",
+				strings.ToLower(strings.ReplaceAll(string(r.candidate.Via), "_", "-"))))
+		}
 
+		// IMPORTANT: Also warm the injected content so it's ready when user scrolls to it
+		if e.effectiveState(content.ID, content.ContainerStatus) == models.StatusCold {
 			e.makeDecision(models.TriggerCrossDomain, content.ID, boostedScores,
-				models.ActionInjectContent,
-				fmt.Sprintf("Cross-domain recommendation: user engaged with %s %s, suggesting related %s",
-					focusedContent.Type, focusedContent.Theme, content.Type))
+				models.ActionScaleWarm,
+				fmt.Sprintf("Cross-domain pre-warming: preparing %s for seamless activation", content.Title),
+				explain(RuleCrossDomainPrewarm, nil, scoreFeatures(boostedScores)))
+		}
 
-			if e.OnInject != nil {
-				e.OnInject(content, 1, "Cross-domain recommendation based on theme affinity")
-			}
+		session.MarkInjected(content.ID)
+	}
+}
 
-			// IMPORTANT: Also warm the injected content so it's ready when user scrolls to it
-			if content.ContainerStatus == models.StatusCold && e.OnScaleAction != nil {
-				e.makeDecision(models.TriggerCrossDomain, content.ID, boostedScores,
-					models.ActionScaleWarm,
-					fmt.Sprintf("Cross-domain pre-warming: preparing %s for seamless activation", content.Title))
-				e.OnScaleAction(content.ID, models.StatusWarm)
-			}
+// learnCoEngagement adds co-engagement edges once a session has engaged with an item
+// long enough, linking it to every other item the session already engaged with
+func (e *RulesEngine) learnCoEngagement(session *models.UserSession, contentID string, durationMS int) {
+	threshold := e.config.CoEngagementFocusThresholdMS
+	before := session.GetContentFocus(contentID)
+	session.AddContentFocus(contentID, durationMS)
+
+	// Only learn on the focus event that crosses the threshold
+	if before >= threshold || before+durationMS < threshold {
+		return
+	}
 
-			session.MarkInjected(content.ID)
-			break // Only inject one recommendation at a time
+	relations := e.Relations()
+	for otherID, ms := range session.ContentFocus {
+		if otherID == contentID || ms < threshold {
+			continue
 		}
+		relations.Reinforce(contentID, otherID, models.RelationCoEngagement, e.config.CoEngagementWeightStep)
+		log.Printf("Co-engagement learned: %s <-> %s (session=%s)", contentID, otherID, session.SessionID)
 	}
 }
 
@@ -192,16 +378,20 @@ func (e *RulesEngine) checkProactiveWarming(
 		return
 	}
 
-	if contentScores.CombinedScore >= e.config.WarmThreshold && content.ContainerStatus == models.StatusCold {
+	if contentScores.CombinedScore >= e.config.WarmThreshold &&
+		e.effectiveState(content.ID, content.ContainerStatus) == models.StatusCold {
 		e.makeDecision(models.TriggerProactiveWarm, content.ID, *contentScores,
 			models.ActionScaleWarm,
 			fmt.Sprintf("Proactive warming: engagement score %.2f indicates likely activation",
-				contentScores.CombinedScore))
+				contentScores.CombinedScore),
+			explain(RuleEngagementWarm, map[string]float64{
+				"warm_threshold": e.config.WarmThreshold,
+			}, scoreFeatures(*contentScores)))
 	}
 }
 
 // checkModeChange determines if the operational mode should change
-func (e *RulesEngine) checkModeChange(session *models.UserSession, content *models.ContentItem) {
+func (e *RulesEngine) checkModeChange(session *models.UserSession, content *models.ContentItem, durationMS int) {
 	var newMode models.OperationalMode
 	var reason string
 
@@ -212,34 +402,46 @@ func (e *RulesEngine) checkModeChange(session *models.UserSession, content *mode
 	case models.ContentTypeAIService:
 		newMode = models.ModeAIServiceFocus
 		reason = "Extended engagement with AI service"
+	case models.ContentTypeVideo:
+		newMode = models.ModeVideoWatching
+		reason = "Extended video watching"
 	default:
 		return
 	}
 
 	if session.CurrentMode != newMode {
 		oldMode := session.CurrentMode
+		thresholds := map[string]float64{"mode_focus_threshold_ms": float64(e.config.ModeFocusThresholdMS)}
+		features := map[string]float64{"focus_duration_ms": float64(durationMS)}
 
 		e.makeDecision(models.TriggerModeChange, content.ID, models.InputScores{},
 			models.ActionChangeMode,
-			fmt.Sprintf("Mode change from %s to %s: %s", oldMode, newMode, reason))
+			fmt.Sprintf("Mode change from %s to %s: %s", oldMode, newMode, reason),
+			explain(RuleModeFocus, thresholds, features))
 
 		if e.OnModeChange != nil {
 			e.OnModeChange(oldMode, newMode, reason)
 		}
 
-		// Trigger resource throttling when mode changes to focused mode
+		// Trigger resource throttling when mode changes to game or AI focus.
+		// Video plays from the CDN, so watching it throttles nothing.
 		if e.OnThrottleAction != nil {
 			if newMode == models.ModeGameFocus || newMode == models.ModeAIServiceFocus {
-				e.makeDecision(models.TriggerResourceThrottle, content.ID, models.InputScores{},
+				decision := e.makeDecision(models.TriggerResourceThrottle, content.ID, models.InputScores{},
 					models.ActionThrottleBackground,
-					fmt.Sprintf("Throttling background workloads for %s focus mode", content.Type))
-				e.OnThrottleAction(content.ID, newMode)
-			} else if newMode == models.ModeMixedStreamBrowsing {
-				// Restore resources when returning to mixed browsing
-				e.makeDecision(models.TriggerResourceThrottle, "", models.InputScores{},
-					models.ActionRestoreResources,
-					"Restoring resources for mixed stream browsing")
-				e.OnThrottleAction("", newMode)
+					fmt.Sprintf("Throttling background workloads for %s focus mode", content.Type),
+					explain(RuleFocusThrottle, thresholds, features))
+				e.finishDecision(decision, e.OnThrottleAction(content.ID, newMode))
+			} else if oldMode == models.ModeGameFocus || oldMode == models.ModeAIServiceFocus {
+				// Restore resources when leaving game or AI focus
+				reasoning := "Restoring resources for mixed stream browsing"
+				if newMode == models.ModeVideoWatching {
+					reasoning = "Restoring resources for video watching"
+				}
+				decision := e.makeDecision(models.TriggerResourceThrottle, "", models.InputScores{},
+					models.ActionRestoreResources, reasoning,
+					explain(RuleBrowseRestore, thresholds, features))
+				e.finishDecision(decision, e.OnThrottleAction("", newMode))
 			}
 		}
 
@@ -252,10 +454,12 @@ func (e *RulesEngine) ProcessInitialLoad(content []*models.ContentItem, warmCoun
 	log.Printf("Processing initial load: warming first %d items", warmCount)
 
 	for i := 0; i < warmCount && i < len(content); i++ {
-		if content[i].ContainerStatus == models.StatusCold {
+		if e.effectiveState(content[i].ID, content[i].ContainerStatus) == models.StatusCold {
 			e.makeDecision(models.TriggerInitialWarm, content[i].ID, models.InputScores{},
 				models.ActionScaleWarm,
-				fmt.Sprintf("Initial page load - pre-warming content item %d (%s)", i+1, content[i].Title))
+				fmt.Sprintf("Initial page load - pre-warming content item %d (%s)", i+1, content[i].Title),
+				explain(RuleInitialLoad, map[string]float64{"warm_count": float64(warmCount)},
+					map[string]float64{"position": float64(i)}))
 		}
 	}
 }
@@ -283,48 +487,109 @@ func (e *RulesEngine) ProcessScrollUpdate(visibleContent []string, allContent []
 	// Pre-warm the next 2 content items (lookahead)
 	lookaheadCount := 2
 	for j := lastVisibleIdx + 1; j <= lastVisibleIdx+lookaheadCount && j < len(allContent); j++ {
-		if allContent[j].ContainerStatus == models.StatusCold {
+		if e.effectiveState(allContent[j].ID, allContent[j].ContainerStatus) == models.StatusCold {
 			log.Printf("Lookahead warming: content=%s at position %d", allContent[j].ID, j)
 			e.makeDecision(models.TriggerLookahead, allContent[j].ID, models.InputScores{},
 				models.ActionScaleWarm,
-				fmt.Sprintf("Lookahead warming - user approaching content (%s)", allContent[j].Title))
+				fmt.Sprintf("Lookahead warming - user approaching content (%s)", allContent[j].Title),
+				explain(RuleScrollLookahead, map[string]float64{"lookahead_count": float64(lookaheadCount)},
+					map[string]float64{"position": float64(j), "last_visible_position": float64(lastVisibleIdx)}))
 		}
 	}
 }
 
-// makeDecision creates and records an AI decision
+// Reset clears transition tracking and learned relations, keeping the
+// catalog's relations
+func (e *RulesEngine) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, t := range e.transitions {
+		t.clearInFlight()
+	}
+	e.transitions = make(map[string]*contentTransition)
+	e.relations.ForgetLearned()
+}
+
+// makeDecision creates and records an AI decision. Scale decisions are dropped
+// (returning nil) when another action for the same content is in flight or the
+// content has not reached its minimum dwell time.
+//
+// Decisions are emitted through OnDecision before their action runs. Scale
+// decisions complete when the state machine confirms the target state, fail if the
+// action is rejected, superseded or not confirmed within InFlightTimeout, and are
+// then re-emitted through OnDecisionUpdated. Throttle decisions are completed by
+// the caller once OnThrottleAction returns.
 func (e *RulesEngine) makeDecision(
 	trigger models.TriggerType,
 	contentID string,
 	scores models.InputScores,
 	action models.ActionType,
 	reasoning string,
-) {
+	explanation *models.DecisionExplanation,
+) *models.AIDecision {
+	now := e.clock.Now()
 	decision := &models.AIDecision{
-		DecisionID:        fmt.Sprintf("dec-%d", time.Now().UnixNano()),
-		Timestamp:         time.Now(),
+		DecisionID:        fmt.Sprintf("dec-%d-%d", now.UnixNano(), e.decisionSeq.Add(1)),
+		Timestamp:         now,
 		TriggerType:       trigger,
 		AffectedContentID: contentID,
 		ReasoningText:     reasoning,
+		Explanation:       explanation,
 		InputScores:       scores,
 		ResultingAction:   action,
-		Success:           true,
+	}
+
+	var target models.ContainerStatus
+	switch action {
+	case models.ActionScaleWarm:
+		target = models.StatusWarm
+	case models.ActionScaleHot:
+		target = models.StatusHot
+	case models.ActionInjectContent, models.ActionChangeMode:
+		// Applied in-process by the caller; these cannot fail
+		decision.CompleteAt(now, nil)
+	}
+	if target != "" {
+		if ok, why := e.beginTransition(contentID, target, decision); !ok {
+			log.Printf("AI Decision skipped: [%s] %s -> %s: %s", trigger, contentID, action, why)
+			return nil
+		}
 	}
 
 	log.Printf("AI Decision: [%s] %s -> %s: %s",
 		trigger, contentID, action, reasoning)
 
 	if e.OnDecision != nil {
-		e.OnDecision(decision)
+		snapshot := *decision
+		e.OnDecision(&snapshot)
 	}
 
 	// Trigger scaling action if needed
-	if e.OnScaleAction != nil {
-		switch action {
-		case models.ActionScaleWarm:
-			e.OnScaleAction(contentID, models.StatusWarm)
-		case models.ActionScaleHot:
-			e.OnScaleAction(contentID, models.StatusHot)
+	if target != "" {
+		timeout := e.config.InFlightTimeout
+		e.setTimeout(contentID, decision, e.clock.AfterFunc(timeout, func() {
+			e.abortScaleAction(contentID, decision, fmt.Errorf("%w within %s", ErrScaleTimeout, timeout))
+		}))
+		if e.OnScaleAction != nil {
+			if err := e.OnScaleAction(contentID, target); err != nil {
+				e.abortScaleAction(contentID, decision, err)
+			}
 		}
 	}
+
+	return decision
+}
+
+// finishDecision records the outcome of a decision's action and emits the update
+func (e *RulesEngine) finishDecision(decision *models.AIDecision, err error) {
+	decision.CompleteAt(e.clock.Now(), err)
+	if err != nil {
+		log.Printf("AI Decision failed: [%s] %s -> %s: %v",
+			decision.TriggerType, decision.AffectedContentID, decision.ResultingAction, err)
+	}
+
+	if e.OnDecisionUpdated != nil {
+		updated := *decision
+		e.OnDecisionUpdated(&updated)
+	}
 }
@@ -0,0 +1,83 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gavigo/orchestrator/internal/models"
+)
+
+var testStart = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestEngine returns an engine on a fake clock that records finished decisions
+func newTestEngine() (*RulesEngine, *FakeClock, *[]models.AIDecision) {
+	clock := NewFakeClock(testStart)
+	engine := NewRulesEngine(nil)
+	engine.SetClock(clock)
+	finished := &[]models.AIDecision{}
+	engine.OnDecisionUpdated = func(decision *models.AIDecision) {
+		*finished = append(*finished, *decision)
+	}
+	return engine, clock, finished
+}
+
+func warmOne(engine *RulesEngine) string {
+	content := []*models.ContentItem{{ID: "game-a", Type: models.ContentTypeGame, ContainerStatus: models.StatusCold}}
+	engine.ProcessInitialLoad(content, 1)
+	return content[0].ID
+}
+
+func TestScaleTimeoutStoppedWhenConfirmed(t *testing.T) {
+	engine, clock, finished := newTestEngine()
+	id := warmOne(engine)
+	if clock.Pending() != 1 {
+		t.Fatalf("pending timers = %d, want the in-flight timeout", clock.Pending())
+	}
+
+	engine.CompleteScaleAction(id, models.StatusWarm)
+	if clock.Pending() != 0 {
+		t.Errorf("pending timers = %d after confirmation, want 0", clock.Pending())
+	}
+	if len(*finished) != 1 || !(*finished)[0].Success {
+		t.Fatalf("finished = %+v, want one successful decision", *finished)
+	}
+
+	clock.Advance(DefaultConfig().InFlightTimeout)
+	if len(*finished) != 1 {
+		t.Errorf("decision finished again after its timeout: %+v", *finished)
+	}
+}
+
+func TestScaleTimeoutStoppedWhenSupersededOrReset(t *testing.T) {
+	engine, clock, _ := newTestEngine()
+	id := warmOne(engine)
+	engine.ObserveState(id, models.StatusHot)
+	if clock.Pending() != 0 {
+		t.Errorf("pending timers = %d after supersede, want 0", clock.Pending())
+	}
+
+	engine, clock, _ = newTestEngine()
+	warmOne(engine)
+	engine.Reset()
+	if clock.Pending() != 0 {
+		t.Errorf("pending timers = %d after reset, want 0", clock.Pending())
+	}
+}
+
+func TestScaleTimeoutFailsUnconfirmedAction(t *testing.T) {
+	engine, clock, finished := newTestEngine()
+	warmOne(engine)
+
+	clock.Advance(DefaultConfig().InFlightTimeout - time.Millisecond)
+	if len(*finished) != 0 {
+		t.Fatalf("decision finished before its timeout: %+v", *finished)
+	}
+	clock.Advance(time.Millisecond)
+	if len(*finished) != 1 || (*finished)[0].Success || !strings.Contains((*finished)[0].Error, ErrScaleTimeout.Error()) {
+		t.Fatalf("finished = %+v, want one timed-out decision", *finished)
+	}
+	if got := (*finished)[0].CompletedAt; got == nil || !got.Equal(testStart.Add(DefaultConfig().InFlightTimeout)) {
+		t.Errorf("completed at %v, want the virtual timeout", got)
+	}
+}
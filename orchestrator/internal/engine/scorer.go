@@ -24,6 +24,11 @@ type Scorer struct {
 
 	// Configuration
 	config *ScorerConfig
+	clock  Clock
+
+	// Decay timer; run counts StartDecay calls so a stale tick does not rearm
+	timer Timer
+	run   int
 
 	// Callback when scores update
 	OnScoreUpdate func(contentID string, scores *models.InputScores)
@@ -61,17 +66,47 @@ func NewScorer(config *ScorerConfig) *Scorer {
 		globalScores:   make(map[string]float64),
 		trendScores:    make(map[string]*models.TrendScore),
 		config:         config,
+		clock:          RealClock{},
 	}
 }
 
-// StartDecay starts the score decay process
+// SetClock sets the clock driving score decay and trend timestamps. Call it
+// before StartDecay.
+func (s *Scorer) SetClock(clock Clock) {
+	s.clock = clock
+}
+
+// StartDecay starts the score decay process; it runs until StopDecay
 func (s *Scorer) StartDecay() {
-	ticker := time.NewTicker(s.config.DecayInterval)
-	go func() {
-		for range ticker.C {
-			s.applyDecay()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.timer != nil {
+		return
+	}
+
+	s.run++
+	run := s.run
+	var tick func()
+	tick = func() {
+		s.applyDecay()
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.timer != nil && s.run == run { // Not stopped during the decay
+			s.timer = s.clock.AfterFunc(s.config.DecayInterval, tick)
 		}
-	}()
+	}
+	s.timer = s.clock.AfterFunc(s.config.DecayInterval, tick)
+}
+
+// StopDecay halts score decay
+func (s *Scorer) StopDecay() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
 }
 
 // RecordFocusEvent records a user focus event and updates scores
@@ -133,7 +168,7 @@ func (s *Scorer) SetTrendScore(contentID string, viralScore float64, direction s
 		ContentID:      contentID,
 		ViralScore:     viralScore,
 		TrendDirection: direction,
-		LastUpdated:    time.Now(),
+		LastUpdated:    s.clock.Now(),
 		ManualOverride: true,
 	}
 
@@ -0,0 +1,41 @@
+package engine
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScorerDecayStops(t *testing.T) {
+	clock := NewFakeClock(testStart)
+	scorer := NewScorer(&ScorerConfig{PersonalWeight: 1, DecayRate: 0.01, DecayInterval: 5 * time.Second, FocusDurationScale: 0.1})
+	scorer.SetClock(clock)
+	scorer.RecordFocusEvent("s1", "game-a", 5000, "puzzle")
+
+	scorer.StartDecay()
+	scorer.StartDecay() // Already running
+	if clock.Pending() != 1 {
+		t.Fatalf("%d decay timers scheduled, want 1", clock.Pending())
+	}
+	clock.Advance(5 * time.Second)
+	decayed := scorer.GetScores("s1", "game-a").PersonalScore
+	if decayed >= 0.5 {
+		t.Fatalf("personal score %.3f did not decay from 0.5", decayed)
+	}
+
+	scorer.StopDecay()
+	if clock.Pending() != 0 {
+		t.Fatalf("%d decay timers left after StopDecay", clock.Pending())
+	}
+	clock.Advance(time.Minute)
+	if score := scorer.GetScores("s1", "game-a").PersonalScore; score != decayed {
+		t.Errorf("personal score decayed to %.3f after StopDecay, want %.3f", score, decayed)
+	}
+
+	// Decay resumes once restarted
+	scorer.StartDecay()
+	clock.Advance(5 * time.Second)
+	if score := scorer.GetScores("s1", "game-a").PersonalScore; score >= decayed {
+		t.Errorf("personal score %.3f did not decay after a restart", score)
+	}
+	scorer.StopDecay()
+}
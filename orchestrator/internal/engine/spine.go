@@ -2,7 +2,6 @@ package engine
 
 import (
 	"fmt"
-	"math/rand"
 	"sync"
 	"time"
 
@@ -23,6 +22,7 @@ type ActivationSpine struct {
 	mu        sync.RWMutex
 	timelines map[string]*ContentTimeline
 	onEmit    func(event *models.ActivationSpineEvent)
+	clock     Clock
 }
 
 // NewActivationSpine creates a new activation spine tracker
@@ -30,9 +30,15 @@ func NewActivationSpine(onEmit func(event *models.ActivationSpineEvent)) *Activa
 	return &ActivationSpine{
 		timelines: make(map[string]*ContentTimeline),
 		onEmit:    onEmit,
+		clock:     RealClock{},
 	}
 }
 
+// SetClock sets the clock used to timestamp phases
+func (s *ActivationSpine) SetClock(clock Clock) {
+	s.clock = clock
+}
+
 // RecordPhase records an activation phase event for a content item
 func (s *ActivationSpine) RecordPhase(contentID, sessionID string, phase models.ActivationPhase, triggerSource string, weight models.ResourceWeight, isSimulated bool) {
 	s.mu.Lock()
@@ -50,7 +56,7 @@ func (s *ActivationSpine) RecordPhase(contentID, sessionID string, phase models.
 		tl.SessionID = sessionID
 	}
 
-	now := time.Now()
+	now := s.clock.Now()
 
 	if phase == models.PhaseIntent {
 		tl.IntentTime = now
@@ -100,6 +106,16 @@ func (s *ActivationSpine) MarkPreviousHot(contentID string) {
 	tl.PreviousHot = true
 }
 
+// ClearPreviousHot removes the restore eligibility of a content item
+func (s *ActivationSpine) ClearPreviousHot(contentID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if tl, exists := s.timelines[contentID]; exists {
+		tl.PreviousHot = false
+	}
+}
+
 // HasIntent returns whether an INTENT has been recorded for this content
 func (s *ActivationSpine) HasIntent(contentID string) bool {
 	s.mu.RLock()
@@ -134,17 +150,27 @@ func (s *ActivationSpine) Reset() {
 	s.timelines = make(map[string]*ContentTimeline)
 }
 
-// SimulatedStartupDelay returns a jittered startup delay based on content type
-func SimulatedStartupDelay(contentType models.ContentType) time.Duration {
+// StartupDelayRange returns the simulated startup delay for a content type as a
+// base delay plus the maximum random jitter added to it
+func StartupDelayRange(contentType models.ContentType) (base, jitter time.Duration) {
 	switch contentType {
 	case models.ContentTypeAIService:
-		return time.Duration(800+rand.Intn(400)) * time.Millisecond
+		return 800 * time.Millisecond, 400 * time.Millisecond
+	case models.ContentTypeVideo:
+		// No pod to start: prefetch the first segments and confirm the rendition is transcoded
+		return 150 * time.Millisecond, 300 * time.Millisecond
 	default: // GAME
-		return time.Duration(1500+rand.Intn(1000)) * time.Millisecond
+		return 1500 * time.Millisecond, 1000 * time.Millisecond
 	}
 }
 
+// SimulatedStartupDelay returns a jittered startup delay based on content type
+func SimulatedStartupDelay(rng *Rand, contentType models.ContentType) time.Duration {
+	base, jitter := StartupDelayRange(contentType)
+	return base + time.Duration(rng.Int63n(int64(jitter)))
+}
+
 // SimulatedRestoreDelay returns a jittered restore delay (faster than cold start)
-func SimulatedRestoreDelay() time.Duration {
-	return time.Duration(200+rand.Intn(300)) * time.Millisecond
+func SimulatedRestoreDelay(rng *Rand) time.Duration {
+	return time.Duration(200+rng.Intn(300)) * time.Millisecond
 }
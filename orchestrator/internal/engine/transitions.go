@@ -0,0 +1,224 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gavigo/orchestrator/internal/models"
+)
+
+var (
+	// ErrScaleSuperseded fails a scale decision whose container was moved elsewhere first
+	ErrScaleSuperseded = errors.New("scale action superseded")
+	// ErrScaleTimeout fails a scale decision whose action was not confirmed in time
+	ErrScaleTimeout = errors.New("scale action not confirmed")
+)
+
+// scoreBand is the hysteresis band a content item's combined score currently sits in
+type scoreBand int
+
+const (
+	bandCold scoreBand = iota
+	bandWarm
+	bandHot
+)
+
+// contentTransition tracks the engine's view of scaling for a single content item
+type contentTransition struct {
+	state         models.ContainerStatus // last confirmed state
+	changedAt     time.Time              // when the last transition was confirmed
+	inFlight      models.ContainerStatus // target of a pending scale action ("" if none)
+	inFlightSince time.Time
+	decision      *models.AIDecision // decision waiting on the in-flight action
+	timeout       Timer              // fails the in-flight action if it is not confirmed in time
+	band          scoreBand          // current hysteresis band of the combined score
+	scorePromoted bool               // HOT was reached through the score rule, not a user activation
+}
+
+// transition returns the tracking entry for a content item, creating it if needed.
+// Caller must hold e.mu.
+func (e *RulesEngine) transition(contentID string) *contentTransition {
+	t, exists := e.transitions[contentID]
+	if !exists {
+		t = &contentTransition{state: models.StatusCold}
+		e.transitions[contentID] = t
+	}
+	return t
+}
+
+// clearInFlight drops the pending scale action, stopping its timeout, and returns
+// the decision that was waiting on it, if any. Caller must hold e.mu.
+func (t *contentTransition) clearInFlight() *models.AIDecision {
+	decision := t.decision
+	if t.timeout != nil {
+		t.timeout.Stop()
+		t.timeout = nil
+	}
+	t.inFlight = ""
+	t.decision = nil
+	return decision
+}
+
+// setTimeout attaches a timeout to a decision's scale action, or stops it if
+// the action is no longer in flight
+func (e *RulesEngine) setTimeout(contentID string, decision *models.AIDecision, timeout Timer) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if t, exists := e.transitions[contentID]; exists && t.decision == decision {
+		t.timeout = timeout
+		return
+	}
+	timeout.Stop()
+}
+
+// effectiveState returns the state a content item is in or is being moved to.
+// Rules should use this instead of the possibly stale state carried on ContentItem.
+func (e *RulesEngine) effectiveState(contentID string, reported models.ContainerStatus) models.ContainerStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	t, exists := e.transitions[contentID]
+	if !exists {
+		return reported
+	}
+	if t.inFlight != "" {
+		return t.inFlight
+	}
+	return reported
+}
+
+// beginTransition reserves a scale action for a content item on behalf of a decision.
+// It returns false if another action is already in flight or the item has not dwelt
+// long enough in its current state.
+func (e *RulesEngine) beginTransition(contentID string, target models.ContainerStatus, decision *models.AIDecision) (bool, string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.clock.Now()
+	t := e.transition(contentID)
+
+	if inFlight := t.inFlight; inFlight != "" {
+		if inFlight == target {
+			return false, "duplicate scale to " + string(target) + " already in flight"
+		}
+		return false, "conflicting scale to " + string(inFlight) + " already in flight"
+	}
+	if !t.changedAt.IsZero() && now.Sub(t.changedAt) < e.config.MinDwellTime {
+		return false, "minimum dwell time not reached in " + string(t.state)
+	}
+
+	t.inFlight = target
+	t.inFlightSince = now
+	t.decision = decision
+	return true, ""
+}
+
+// CompleteScaleAction confirms that a scale action issued by the engine has been
+// applied, completing the decision that requested it
+func (e *RulesEngine) CompleteScaleAction(contentID string, state models.ContainerStatus) {
+	e.mu.Lock()
+	t := e.transition(contentID)
+	var done *models.AIDecision
+	if t.inFlight == state {
+		done = t.clearInFlight()
+	}
+	if state != models.StatusHot {
+		t.scorePromoted = false
+	}
+	if t.state != state {
+		t.state = state
+		t.changedAt = e.clock.Now()
+	}
+	e.mu.Unlock()
+
+	if done != nil {
+		e.finishDecision(done, nil)
+	}
+}
+
+// ObserveState records a state change made outside the engine (activation,
+// deactivation, cool-down, manual controls). Any pending engine action is dropped;
+// its decision succeeds only if the container still reached the requested state.
+func (e *RulesEngine) ObserveState(contentID string, state models.ContainerStatus) {
+	e.mu.Lock()
+	t := e.transition(contentID)
+	target := t.inFlight
+	dropped := t.clearInFlight()
+	t.scorePromoted = false
+	if t.state != state {
+		t.state = state
+		t.changedAt = e.clock.Now()
+	}
+	e.mu.Unlock()
+
+	if dropped == nil {
+		return
+	}
+	if state == target {
+		e.finishDecision(dropped, nil)
+	} else {
+		e.finishDecision(dropped, fmt.Errorf("%w: container moved to %s", ErrScaleSuperseded, state))
+	}
+}
+
+// ObserveLease records that a session holds a content item, so its HOT state
+// is no longer the score rule's to release, even if the score promoted it
+func (e *RulesEngine) ObserveLease(contentID string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.transition(contentID).scorePromoted = false
+}
+
+// abortScaleAction fails a decision's scale action if it is still the one in
+// flight for the content item, releasing the item for new actions
+func (e *RulesEngine) abortScaleAction(contentID string, decision *models.AIDecision, err error) {
+	e.mu.Lock()
+	t, exists := e.transitions[contentID]
+	if !exists || t.decision != decision {
+		e.mu.Unlock()
+		return // Already completed, superseded or reset
+	}
+	t.clearInFlight()
+	e.mu.Unlock()
+
+	e.finishDecision(decision, err)
+}
+
+// updateBand moves a content item's score band with hysteresis and returns the new band
+func (e *RulesEngine) updateBand(contentID string, score float64) scoreBand {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	t := e.transition(contentID)
+
+	switch {
+	case score >= e.config.HotThreshold:
+		t.band = bandHot
+	case t.band == bandHot && score >= e.config.HotDownThreshold:
+		// Stay HOT until the score drops below the down threshold
+	case score >= e.config.WarmThreshold:
+		t.band = bandWarm
+	case t.band >= bandWarm && score >= e.config.WarmDownThreshold:
+		t.band = bandWarm
+	default:
+		t.band = bandCold
+	}
+
+	return t.band
+}
+
+// setScorePromoted flags whether a content item's HOT state came from the score rule
+func (e *RulesEngine) setScorePromoted(contentID string, promoted bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.transition(contentID).scorePromoted = promoted
+}
+
+// isScorePromoted reports whether a content item's HOT state came from the score rule
+func (e *RulesEngine) isScorePromoted(contentID string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	t, exists := e.transitions[contentID]
+	return exists && t.scorePromoted
+}
@@ -0,0 +1,199 @@
+package engine
+
+import (
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PoolTunerConfig holds configuration for warm pool sizing
+type PoolTunerConfig struct {
+	MinSize        int           // Generic pods kept per runtime class even without demand
+	MaxSize        int           // Upper bound per runtime class
+	Window         time.Duration // Cold activations are averaged over this window
+	ReplenishTime  time.Duration // Time to provision a replacement generic pod
+	Headroom       float64       // Multiplier on the cold activations expected while replenishing
+	ResizeInterval time.Duration // How often pool sizes are recomputed
+}
+
+// DefaultPoolTunerConfig returns default warm pool sizing configuration
+func DefaultPoolTunerConfig() *PoolTunerConfig {
+	return &PoolTunerConfig{
+		MinSize:        1,
+		MaxSize:        10,
+		Window:         10 * time.Minute,
+		ReplenishTime:  30 * time.Second,
+		Headroom:       2.0,
+		ResizeInterval: 30 * time.Second,
+	}
+}
+
+// PoolStatus is the sizing state of one runtime class's warm pool
+type PoolStatus struct {
+	RuntimeClass    string  `json:"runtime_class"`
+	TargetSize      int     `json:"target_size"`
+	ColdActivations int     `json:"cold_activations"` // Within the window
+	RatePerMinute   float64 `json:"rate_per_minute"`
+}
+
+// PoolTuner sizes a warm pool of generic pods per runtime class from the
+// recent rate of cold activations, so a claim is usually available without
+// keeping every long-tail item warm
+type PoolTuner struct {
+	mu     sync.Mutex
+	config *PoolTunerConfig
+	clock  Clock
+	timer  Timer  // Next scheduled resize; nil when stopped
+	run    uint64 // Incremented by every Start so a stale resize loop ends
+
+	demand map[string][]time.Time // runtime class -> cold activation times within the window
+	sizes  map[string]int         // runtime class -> last target size reported
+
+	// Callback with each runtime class's target pool size on every resize
+	OnResize func(runtimeClass string, size int)
+}
+
+// NewPoolTuner creates a new warm pool tuner
+func NewPoolTuner(config *PoolTunerConfig) *PoolTuner {
+	if config == nil {
+		config = DefaultPoolTunerConfig()
+	}
+	return &PoolTuner{
+		config: config,
+		clock:  RealClock{},
+		demand: make(map[string][]time.Time),
+		sizes:  make(map[string]int),
+	}
+}
+
+// SetClock sets the clock used for demand tracking and resize scheduling.
+// Call it before Start.
+func (t *PoolTuner) SetClock(clock Clock) {
+	t.clock = clock
+}
+
+// Register starts sizing a runtime class's pool
+func (t *PoolTuner) Register(runtimeClass string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.demand[runtimeClass]; !ok {
+		t.demand[runtimeClass] = nil
+	}
+}
+
+// RecordColdActivation records an activation of content that was not warm
+func (t *PoolTuner) RecordColdActivation(runtimeClass string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.demand[runtimeClass] = append(t.demand[runtimeClass], t.clock.Now())
+}
+
+// Start begins periodic resizing; the first resize runs right away
+func (t *PoolTuner) Start() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.timer != nil {
+		return
+	}
+
+	t.run++
+	run := t.run
+	var resize func()
+	resize = func() {
+		t.Tune()
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.timer != nil && t.run == run { // Not stopped during the resize
+			t.timer = t.clock.AfterFunc(t.config.ResizeInterval, resize)
+		}
+	}
+	t.timer = t.clock.AfterFunc(0, resize)
+}
+
+// Stop halts periodic resizing
+func (t *PoolTuner) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+}
+
+// Tune recomputes every pool's target size and reports it, so pools refill
+// the pods claimed since the last resize
+func (t *PoolTuner) Tune() {
+	t.mu.Lock()
+	statuses := t.statusLocked()
+	changed := make(map[string]bool, len(statuses))
+	for _, status := range statuses {
+		if last, ok := t.sizes[status.RuntimeClass]; !ok || last != status.TargetSize {
+			changed[status.RuntimeClass] = true
+		}
+		t.sizes[status.RuntimeClass] = status.TargetSize
+	}
+	t.mu.Unlock()
+
+	for _, status := range statuses {
+		if changed[status.RuntimeClass] {
+			log.Printf("Warm pool: runtime=%s target size %d", status.RuntimeClass, status.TargetSize)
+		}
+		if t.OnResize != nil {
+			t.OnResize(status.RuntimeClass, status.TargetSize)
+		}
+	}
+}
+
+// Status returns every pool's sizing state, ordered by runtime class
+func (t *PoolTuner) Status() []PoolStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.statusLocked()
+}
+
+// Reset clears demand history
+func (t *PoolTuner) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for runtimeClass := range t.demand {
+		t.demand[runtimeClass] = nil
+	}
+	t.sizes = make(map[string]int)
+}
+
+// statusLocked prunes demand outside the window and sizes each pool to cover
+// the cold activations expected while a claimed pod is replaced
+func (t *PoolTuner) statusLocked() []PoolStatus {
+	cutoff := t.clock.Now().Add(-t.config.Window)
+	statuses := make([]PoolStatus, 0, len(t.demand))
+	for runtimeClass, times := range t.demand {
+		recent := times[:0]
+		for _, at := range times {
+			if at.After(cutoff) {
+				recent = append(recent, at)
+			}
+		}
+		t.demand[runtimeClass] = recent
+
+		perSecond := float64(len(recent)) / t.config.Window.Seconds()
+		size := int(math.Ceil(perSecond * t.config.ReplenishTime.Seconds() * t.config.Headroom))
+		size = max(size, t.config.MinSize)
+		if t.config.MaxSize > 0 {
+			size = min(size, t.config.MaxSize)
+		}
+
+		statuses = append(statuses, PoolStatus{
+			RuntimeClass:    runtimeClass,
+			TargetSize:      size,
+			ColdActivations: len(recent),
+			RatePerMinute:   perSecond * 60,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].RuntimeClass < statuses[j].RuntimeClass
+	})
+	return statuses
+}
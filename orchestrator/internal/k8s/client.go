@@ -4,15 +4,30 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sync"
 
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
 type Client struct {
-	clientset *kubernetes.Clientset
+	clientset kubernetes.Interface
+	dynamic   dynamic.Interface // Custom resources; nil unless set
 	namespace string
+
+	watcherMu sync.Mutex
+	watcher   *Watcher // Backs GetAllPodStatuses once started
+}
+
+// NewClientForClientset wraps an existing clientset, such as the fake clientset
+// from k8s.io/client-go/kubernetes/fake
+func NewClientForClientset(clientset kubernetes.Interface, namespace string) *Client {
+	return &Client{
+		clientset: clientset,
+		namespace: namespace,
+	}
 }
 
 func NewClient(namespace string) (*Client, error) {
@@ -42,14 +57,23 @@ func NewClient(namespace string) (*Client, error) {
 	if err != nil {
 		return nil, err
 	}
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
 
-	return &Client{
-		clientset: clientset,
-		namespace: namespace,
-	}, nil
+	client := NewClientForClientset(clientset, namespace)
+	client.SetDynamicClient(dynamicClient)
+	return client, nil
+}
+
+// SetDynamicClient sets the client for custom resources, such as the fake
+// client from k8s.io/client-go/dynamic/fake
+func (c *Client) SetDynamicClient(dynamicClient dynamic.Interface) {
+	c.dynamic = dynamicClient
 }
 
-func (c *Client) Clientset() *kubernetes.Clientset {
+func (c *Client) Clientset() kubernetes.Interface {
 	return c.clientset
 }
 
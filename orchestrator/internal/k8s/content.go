@@ -0,0 +1,166 @@
+package k8s
+
+import (
+	"fmt"
+
+	"github.com/gavigo/orchestrator/internal/models"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// OrchestratedContentResource is the OrchestratedContent custom resource (see
+// k8s/orchestrator/crd.yaml)
+var OrchestratedContentResource = schema.GroupVersionResource{
+	Group:    "gavigo.io",
+	Version:  "v1alpha1",
+	Resource: "orchestratedcontents",
+}
+
+// Default replicas of a declared deployment in each state
+const (
+	defaultWarmReplicas int32 = 1
+	defaultHotReplicas  int32 = 2
+)
+
+// OrchestratedContent declares a content item, the deployment serving it,
+// its replicas while WARM and HOT, and its resource profiles
+type OrchestratedContent struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   OrchestratedContentSpec   `json:"spec"`
+	Status OrchestratedContentStatus `json:"status,omitempty"`
+}
+
+// OrchestratedContentSpec is the declared content item
+type OrchestratedContentSpec struct {
+	ContentID    string             `json:"contentId,omitempty"` // Defaults to the resource name
+	Type         models.ContentType `json:"type"`
+	Theme        string             `json:"theme"`
+	Title        string             `json:"title"`
+	Description  string             `json:"description,omitempty"`
+	ThumbnailURL string             `json:"thumbnailUrl,omitempty"`
+	MediaURL     string             `json:"mediaUrl,omitempty"`    // VIDEO only
+	DurationSec  int                `json:"durationSec,omitempty"` // VIDEO only
+	RuntimeClass string             `json:"runtimeClass,omitempty"`
+	Deployment   *ContentDeployment `json:"deployment,omitempty"` // Required for types that use a workload
+	Replicas     ContentReplicas    `json:"replicas,omitempty"`
+	Resources    *ContentResources  `json:"resources,omitempty"` // Throttle profiles; the throttle config's when unset
+}
+
+// ContentDeployment is the deployment serving declared content
+type ContentDeployment struct {
+	Name     string                 `json:"name,omitempty"` // Defaults to the content ID
+	Template corev1.PodTemplateSpec `json:"template"`
+}
+
+// ContentReplicas are a declared deployment's replicas while its content is
+// WARM and HOT; it has none while COLD
+type ContentReplicas struct {
+	Warm *int32 `json:"warm,omitempty"` // Default 1
+	Hot  *int32 `json:"hot,omitempty"`  // Default 2
+}
+
+// ContentResources are a declared deployment's resources at each throttle
+// level, for all containers and per container
+type ContentResources struct {
+	ContainerProfile `json:",inline"`
+	Containers       map[string]ContainerProfile `json:"containers,omitempty"`
+}
+
+// OrchestratedContentStatus reports the outcome of the last reconcile
+type OrchestratedContentStatus struct {
+	ObservedGeneration int64  `json:"observedGeneration,omitempty"`
+	Deployment         string `json:"deployment,omitempty"`
+	Ready              bool   `json:"ready"`
+	Error              string `json:"error,omitempty"`
+}
+
+// compile parses every level of the profiles
+func (r *ContentResources) compile() (map[string]map[string]corev1.ResourceRequirements, error) {
+	return compileProfiles(r.ContainerProfile, r.Containers)
+}
+
+// ContentID returns the declared content ID
+func (c *OrchestratedContent) ContentID() string {
+	if c.Spec.ContentID != "" {
+		return c.Spec.ContentID
+	}
+	return c.Name
+}
+
+// DeploymentName returns the name of the deployment serving the content, or
+// "" for content without a workload
+func (c *OrchestratedContent) DeploymentName() string {
+	if !c.Spec.Type.UsesWorkload() {
+		return ""
+	}
+	if c.Spec.Deployment != nil && c.Spec.Deployment.Name != "" {
+		return c.Spec.Deployment.Name
+	}
+	return c.ContentID()
+}
+
+// ContentItem returns the catalog item the resource declares
+func (c *OrchestratedContent) ContentItem() models.ContentItem {
+	return models.ContentItem{
+		ID:              c.ContentID(),
+		Type:            c.Spec.Type,
+		Theme:           c.Spec.Theme,
+		Title:           c.Spec.Title,
+		Description:     c.Spec.Description,
+		ThumbnailURL:    c.Spec.ThumbnailURL,
+		ContainerStatus: models.StatusCold,
+		DeploymentName:  c.DeploymentName(),
+		MediaURL:        c.Spec.MediaURL,
+		DurationSec:     c.Spec.DurationSec,
+		RuntimeClass:    c.Spec.RuntimeClass,
+	}
+}
+
+// Replicas returns the declared deployment's replicas for a content state
+func (c *OrchestratedContent) Replicas(status models.ContainerStatus) int32 {
+	switch status {
+	case models.StatusWarming, models.StatusWarm:
+		if c.Spec.Replicas.Warm != nil {
+			return *c.Spec.Replicas.Warm
+		}
+		return defaultWarmReplicas
+	case models.StatusHot:
+		if c.Spec.Replicas.Hot != nil {
+			return *c.Spec.Replicas.Hot
+		}
+		return defaultHotReplicas
+	default:
+		return 0
+	}
+}
+
+// Validate checks the declared content item, deployment, replicas and resources
+func (c *OrchestratedContent) Validate() error {
+	item := c.ContentItem()
+	if err := models.ValidateContentItem(&item); err != nil {
+		return err
+	}
+	if !c.Spec.Type.UsesWorkload() {
+		if c.Spec.Deployment != nil {
+			return fmt.Errorf("deployment must be empty for %s", c.Spec.Type)
+		}
+		return nil
+	}
+
+	if c.Spec.Deployment == nil || len(c.Spec.Deployment.Template.Spec.Containers) == 0 {
+		return fmt.Errorf("deployment template with at least one container is required for %s", c.Spec.Type)
+	}
+	warm, hot := c.Replicas(models.StatusWarm), c.Replicas(models.StatusHot)
+	if warm < 0 || hot < warm {
+		return fmt.Errorf("replicas must satisfy 0 <= warm (%d) <= hot (%d)", warm, hot)
+	}
+	if c.Spec.Resources != nil {
+		if _, err := c.Spec.Resources.compile(); err != nil {
+			return fmt.Errorf("resources: %w", err)
+		}
+	}
+	return nil
+}
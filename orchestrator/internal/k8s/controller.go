@@ -0,0 +1,479 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gavigo/orchestrator/internal/models"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// AnnotationTemplateHash is the hash of the declared pod template a deployment was last reconciled to
+const AnnotationTemplateHash = "gavigo.io/template-hash"
+
+// errInvalidContent is returned for resources that cannot be reconciled until they are changed
+var errInvalidContent = errors.New("invalid OrchestratedContent")
+
+// ContentControllerConfig holds configuration for the OrchestratedContent controller
+type ContentControllerConfig struct {
+	PodLabels       map[string]string // Labels every declared pod gets, so the pod watcher selects it
+	DeploymentLabel string            // Pod label naming the pod's deployment
+	ResyncPeriod    time.Duration     // How often every resource is reconciled again; 0 disables
+}
+
+// DefaultContentControllerConfig returns the default controller configuration
+func DefaultContentControllerConfig() ContentControllerConfig {
+	return ContentControllerConfig{
+		PodLabels:       map[string]string{"type": "workload"},
+		DeploymentLabel: "app",
+		ResyncPeriod:    5 * time.Minute,
+	}
+}
+
+// ContentController reconciles OrchestratedContent resources into the
+// deployments serving them and is the catalog source of the content they
+// declare. Deployments are owned by their resource, so deleting the resource
+// deletes its deployment.
+type ContentController struct {
+	client   *Client
+	config   ContentControllerConfig
+	informer cache.SharedIndexInformer
+	queue    workqueue.RateLimitingInterface
+
+	mu       sync.RWMutex
+	declared map[string]*OrchestratedContent // Resource name -> last valid reconcile
+	modTime  time.Time                       // Last change of the declared content
+
+	// Callback when a deployment's declared resource profiles change; nil
+	// resources when it no longer has any
+	OnResources func(deploymentName string, resources *ContentResources) error
+
+	// Dependencies
+	IsLeader func() bool // Whether this replica writes deployments and status; nil always does
+}
+
+// NewContentController creates a controller for a client's namespace. Set the
+// callbacks, then call Start.
+func NewContentController(client *Client, config ContentControllerConfig) (*ContentController, error) {
+	if client.dynamic == nil {
+		return nil, errors.New("content controller needs a dynamic client")
+	}
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(client.dynamic, config.ResyncPeriod, client.namespace, nil)
+	c := &ContentController{
+		client:   client,
+		config:   config,
+		informer: factory.ForResource(OrchestratedContentResource).Informer(),
+		queue:    workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		declared: make(map[string]*OrchestratedContent),
+		modTime:  time.Now(),
+	}
+
+	c.informer.SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+		log.Printf("Watch of OrchestratedContent failed, relisting with backoff: %v", err)
+	})
+	enqueue := func(obj interface{}) {
+		if key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj); err == nil {
+			c.queue.Add(key)
+		}
+	}
+	c.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    enqueue,
+		UpdateFunc: func(_, newObj interface{}) { enqueue(newObj) },
+		DeleteFunc: enqueue,
+	})
+	return c, nil
+}
+
+// Start runs the controller until ctx is done. It returns once every existing
+// resource was reconciled, so Load serves the declared content from the start.
+func (c *ContentController) Start(ctx context.Context) error {
+	go c.informer.Run(ctx.Done())
+
+	syncCtx, cancel := context.WithTimeout(ctx, watcherSyncTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(syncCtx.Done(), c.informer.HasSynced) {
+		c.queue.ShutDown()
+		return fmt.Errorf("OrchestratedContent cache did not sync: %w", syncCtx.Err())
+	}
+	for _, key := range c.informer.GetStore().ListKeys() {
+		if err := c.sync(ctx, key); err != nil {
+			log.Printf("Reconcile of OrchestratedContent %s failed, retrying: %v", key, err)
+		}
+	}
+
+	go func() {
+		for c.processNext(ctx) {
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		c.queue.ShutDown()
+	}()
+	log.Printf("Reconciling OrchestratedContent: %d declared", len(c.declaredContent()))
+	return nil
+}
+
+// Resync reconciles every resource again, such as once this replica becomes
+// the leader and may write the deployments its followers only read
+func (c *ContentController) Resync() {
+	for _, key := range c.informer.GetStore().ListKeys() {
+		c.queue.Add(key)
+	}
+}
+
+// Load returns the declared content, oldest resource first
+func (c *ContentController) Load() ([]models.ContentItem, error) {
+	declared := c.declaredContent()
+	items := make([]models.ContentItem, 0, len(declared))
+	for _, content := range declared {
+		items = append(items, content.ContentItem())
+	}
+	return items, nil
+}
+
+// ModTime returns when the declared content last changed
+func (c *ContentController) ModTime() (time.Time, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.modTime, nil
+}
+
+// Scale sets a declared deployment's replicas for its content's state: none
+// while COLD, the warm replicas while WARMING or WARM and the hot replicas
+// while HOT, but never fewer than minReplicas, which pooled instance slots
+// need. Other states are left alone, and deployments not declared by a
+// resource are only scaled up to minReplicas.
+func (c *ContentController) Scale(ctx context.Context, deploymentName string, status models.ContainerStatus, minReplicas int32) error {
+	switch status {
+	case models.StatusCold, models.StatusWarming, models.StatusWarm, models.StatusHot:
+	default:
+		return nil
+	}
+	for _, content := range c.declaredContent() {
+		if content.DeploymentName() == deploymentName {
+			replicas := content.Replicas(status)
+			if replicas < minReplicas {
+				replicas = minReplicas
+			}
+			return c.client.ScaleDeployment(ctx, deploymentName, replicas)
+		}
+	}
+	return c.client.EnsureReplicas(ctx, deploymentName, minReplicas)
+}
+
+func (c *ContentController) processNext(ctx context.Context) bool {
+	key, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	if err := c.sync(ctx, key.(string)); err != nil {
+		log.Printf("Reconcile of OrchestratedContent %s failed, retrying: %v", key, err)
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+// sync reconciles one resource and records the outcome in its status. Only
+// errors a retry may fix are returned.
+func (c *ContentController) sync(ctx context.Context, key string) error {
+	obj, exists, err := c.informer.GetStore().GetByKey(key)
+	if err != nil {
+		return err
+	}
+	_, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return nil
+	}
+	if !exists {
+		c.forget(name)
+		return nil
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil
+	}
+	var content OrchestratedContent
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &content); err != nil {
+		log.Printf("Ignoring OrchestratedContent %s: %v", name, err)
+		c.forget(name)
+		return nil
+	}
+
+	status := OrchestratedContentStatus{
+		ObservedGeneration: content.Generation,
+		Deployment:         content.DeploymentName(),
+	}
+	err = c.reconcile(ctx, &content)
+	if err != nil {
+		status.Error = err.Error()
+		if errors.Is(err, errInvalidContent) {
+			c.forget(name)
+		}
+	} else {
+		status.Ready = true
+		c.declare(name, &content)
+	}
+
+	if !c.leading() {
+		return nil // The leader reports status and retries
+	}
+	if statusErr := c.updateStatus(ctx, u, &content, status); statusErr != nil {
+		return errors.Join(err, statusErr)
+	}
+	if errors.Is(err, errInvalidContent) {
+		log.Printf("OrchestratedContent %s not reconciled: %v", name, err)
+		return nil
+	}
+	return err
+}
+
+// reconcile validates a resource and creates or updates its deployment
+func (c *ContentController) reconcile(ctx context.Context, content *OrchestratedContent) error {
+	if err := content.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidContent, err)
+	}
+	if err := c.checkConflicts(content); err != nil {
+		return err
+	}
+	if content.DeploymentName() == "" {
+		return nil
+	}
+	if c.leading() {
+		if err := c.applyDeployment(ctx, content); err != nil {
+			return err
+		}
+	}
+	if c.OnResources != nil {
+		if err := c.OnResources(content.DeploymentName(), content.Spec.Resources); err != nil {
+			return fmt.Errorf("%w: %v", errInvalidContent, err)
+		}
+	}
+	return nil
+}
+
+// leading reports whether this replica writes deployments and status
+func (c *ContentController) leading() bool {
+	return c.IsLeader == nil || c.IsLeader()
+}
+
+// checkConflicts rejects a content ID or deployment another resource declares
+func (c *ContentController) checkConflicts(content *OrchestratedContent) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for name, other := range c.declared {
+		if name == content.Name {
+			continue
+		}
+		if other.ContentID() == content.ContentID() {
+			return fmt.Errorf("%w: content %s is declared by %s", errInvalidContent, content.ContentID(), name)
+		}
+		if deployment := content.DeploymentName(); deployment != "" && other.DeploymentName() == deployment {
+			return fmt.Errorf("%w: deployment %s is declared by %s", errInvalidContent, deployment, name)
+		}
+	}
+	return nil
+}
+
+// applyDeployment creates a resource's deployment with no replicas, or
+// updates its pod template when the declared one changed. Replicas are left
+// to Scale and resources to the throttler.
+func (c *ContentController) applyDeployment(ctx context.Context, content *OrchestratedContent) error {
+	desired, err := c.deploymentFor(content)
+	if err != nil {
+		return err
+	}
+	deploymentsClient := c.client.clientset.AppsV1().Deployments(c.client.namespace)
+
+	var current *appsv1.Deployment
+	err = withRetry(ctx, func() (err error) {
+		current, err = deploymentsClient.Get(ctx, desired.Name, metav1.GetOptions{})
+		return err
+	})
+	if apierrors.IsNotFound(err) {
+		err = withRetry(ctx, func() error {
+			_, err := deploymentsClient.Create(ctx, desired, metav1.CreateOptions{})
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create deployment %s: %w", desired.Name, err)
+		}
+		log.Printf("Created deployment %s for content %s", desired.Name, content.ContentID())
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get deployment %s: %w", desired.Name, err)
+	}
+
+	if !metav1.IsControlledBy(current, content) {
+		return fmt.Errorf("%w: deployment %s exists and is not owned by it", errInvalidContent, desired.Name)
+	}
+	if current.Annotations[AnnotationTemplateHash] == desired.Annotations[AnnotationTemplateHash] {
+		return nil
+	}
+
+	// The new template's resources are the originals from now on
+	updated := current.DeepCopy()
+	updated.Labels = desired.Labels
+	if updated.Annotations == nil {
+		updated.Annotations = make(map[string]string)
+	}
+	updated.Annotations[AnnotationTemplateHash] = desired.Annotations[AnnotationTemplateHash]
+	delete(updated.Annotations, AnnotationThrottleLevel)
+	delete(updated.Annotations, AnnotationOriginalResources)
+	updated.Spec.Template = desired.Spec.Template
+	err = withRetry(ctx, func() error {
+		_, err := deploymentsClient.Update(ctx, updated, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update deployment %s: %w", desired.Name, err)
+	}
+	log.Printf("Updated pod template of deployment %s for content %s", desired.Name, content.ContentID())
+	return nil
+}
+
+// deploymentFor builds the deployment a resource declares. Its pods carry the
+// watcher's labels and the deployment is labelled for throttling.
+func (c *ContentController) deploymentFor(content *OrchestratedContent) (*appsv1.Deployment, error) {
+	name := content.DeploymentName()
+	template := content.Spec.Deployment.Template.DeepCopy()
+	podLabels := make(map[string]string, len(template.Labels)+len(c.config.PodLabels)+2)
+	for key, value := range template.Labels {
+		podLabels[key] = value
+	}
+	for key, value := range c.config.PodLabels {
+		podLabels[key] = value
+	}
+	podLabels[c.config.DeploymentLabel] = name
+	podLabels[LabelContent] = content.ContentID()
+	template.Labels = podLabels
+
+	data, err := json.Marshal(template)
+	if err != nil {
+		return nil, err
+	}
+	hash := fnv.New64a()
+	hash.Write(data)
+
+	replicas := int32(0)
+	gvk := OrchestratedContentResource.GroupVersion().WithKind("OrchestratedContent")
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: c.client.namespace,
+			Labels: map[string]string{
+				c.config.DeploymentLabel: name,
+				LabelManaged:             "true",
+				LabelContent:             content.ContentID(),
+			},
+			Annotations:     map[string]string{AnnotationTemplateHash: fmt.Sprintf("%x", hash.Sum64())},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(content, gvk)},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{c.config.DeploymentLabel: name}},
+			Template: *template,
+		},
+	}, nil
+}
+
+// updateStatus writes a resource's status if it changed
+func (c *ContentController) updateStatus(ctx context.Context, u *unstructured.Unstructured, content *OrchestratedContent, status OrchestratedContentStatus) error {
+	if reflect.DeepEqual(content.Status, status) {
+		return nil
+	}
+	fields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&status)
+	if err != nil {
+		return err
+	}
+	updated := u.DeepCopy()
+	if err := unstructured.SetNestedField(updated.Object, fields, "status"); err != nil {
+		return err
+	}
+
+	resourceClient := c.client.dynamic.Resource(OrchestratedContentResource).Namespace(c.client.namespace)
+	err = withRetry(ctx, func() error {
+		_, err := resourceClient.UpdateStatus(ctx, updated, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to update status of OrchestratedContent %s: %w", content.Name, err)
+	}
+	return nil
+}
+
+// declare records a reconciled resource, noting a change of the content it declares
+func (c *ContentController) declare(name string, content *OrchestratedContent) {
+	c.mu.Lock()
+	previous, existed := c.declared[name]
+	c.declared[name] = content
+	if !existed || previous.ContentItem() != content.ContentItem() {
+		c.modTime = time.Now()
+	}
+	c.mu.Unlock()
+
+	if existed && previous.DeploymentName() != "" && previous.DeploymentName() != content.DeploymentName() {
+		c.clearResources(previous.DeploymentName())
+	}
+}
+
+// forget drops a resource that was deleted or became invalid
+func (c *ContentController) forget(name string) {
+	c.mu.Lock()
+	previous, existed := c.declared[name]
+	delete(c.declared, name)
+	if existed {
+		c.modTime = time.Now()
+	}
+	c.mu.Unlock()
+
+	if existed && previous.DeploymentName() != "" {
+		c.clearResources(previous.DeploymentName())
+	}
+}
+
+func (c *ContentController) clearResources(deploymentName string) {
+	if c.OnResources == nil {
+		return
+	}
+	if err := c.OnResources(deploymentName, nil); err != nil {
+		log.Printf("Failed to clear resources of %s: %v", deploymentName, err)
+	}
+}
+
+// declaredContent returns the declared resources, oldest first
+func (c *ContentController) declaredContent() []*OrchestratedContent {
+	c.mu.RLock()
+	declared := make([]*OrchestratedContent, 0, len(c.declared))
+	for _, content := range c.declared {
+		declared = append(declared, content)
+	}
+	c.mu.RUnlock()
+
+	sort.Slice(declared, func(i, j int) bool {
+		if !declared[i].CreationTimestamp.Equal(&declared[j].CreationTimestamp) {
+			return declared[i].CreationTimestamp.Before(&declared[j].CreationTimestamp)
+		}
+		return declared[i].Name < declared[j].Name
+	})
+	return declared
+}
@@ -0,0 +1,601 @@
+package k8s
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gavigo/orchestrator/internal/models"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Labels on dedicated instance pods
+const (
+	LabelInstance   = "gavigo.io/instance"
+	LabelSession    = "gavigo.io/session"
+	LabelDeployment = "gavigo.io/deployment"
+)
+
+const (
+	// instanceLeaseSuffix names the lease sharing a deployment's instances after it
+	instanceLeaseSuffix = "-instances"
+	// AnnotationInstancePrefix prefixes the instance ID of the annotation carrying its record
+	AnnotationInstancePrefix = "instances.gavigo.io/"
+)
+
+// InstanceConfig holds configuration for per-session workload instances
+type InstanceConfig struct {
+	Mode         string // models.InstanceDedicated or models.InstancePooled
+	SlotsPerPod  int    // Sessions served by one pod of a pooled deployment
+	EndpointBase string // URL prefix of instance endpoints
+}
+
+// DefaultInstanceConfig returns the default instance configuration
+func DefaultInstanceConfig() InstanceConfig {
+	return InstanceConfig{
+		Mode:         models.InstancePooled,
+		SlotsPerPod:  4,
+		EndpointBase: "/workloads",
+	}
+}
+
+// Environment variables set on dedicated instance pods
+const (
+	EnvInstanceID   = "GAVIGO_INSTANCE_ID"
+	EnvSessionToken = "GAVIGO_SESSION_TOKEN"
+)
+
+// Instance routing errors
+var (
+	ErrInstanceUnauthorized = errors.New("unknown instance or invalid session token")
+	ErrInstanceNotReady     = errors.New("instance is not ready")
+	ErrInstancePending      = errors.New("instance is still being allocated")
+)
+
+// InstanceAllocator gives each session its own instance of a workload: either a
+// dedicated pod cloned from the deployment's pod template, or a slot in one of
+// the deployment's pods. Every replica records the instances it allocates in a
+// lease per deployment, so any replica routes them. Pooled slots are bound to
+// a pod with room when routed and rebound when their pod goes away; the leader
+// scales pooled deployments for the slots every replica holds (Slots).
+//
+// Instances are reserved under the lock and provisioned outside it, so one
+// slow API call does not hold up every other session. A reservation reclaimed
+// before its provisioning finishes is rolled back.
+type InstanceAllocator struct {
+	client *Client
+	config InstanceConfig
+
+	mu        sync.Mutex
+	instances map[string]*models.WorkloadInstance // session/content -> instance allocated by this replica
+	pending   map[string]bool                     // session/content -> still being provisioned
+}
+
+// instanceRecord is an instance as shared with the other replicas. Only a
+// hash of the session token is shared.
+type instanceRecord struct {
+	Session   string    `json:"session"`
+	Content   string    `json:"content"`
+	Mode      string    `json:"mode"`
+	Pod       string    `json:"pod,omitempty"` // Dedicated pod, or the pod a pooled slot is bound to
+	Slot      int       `json:"slot"`
+	TokenHash string    `json:"token_sha256"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewInstanceAllocator creates a new instance allocator
+func NewInstanceAllocator(client *Client, config InstanceConfig) *InstanceAllocator {
+	if config.SlotsPerPod <= 0 {
+		config.SlotsPerPod = 1
+	}
+	return &InstanceAllocator{
+		client:    client,
+		config:    config,
+		instances: make(map[string]*models.WorkloadInstance),
+		pending:   make(map[string]bool),
+	}
+}
+
+// Allocate provisions a session's instance of a content item's deployment. A
+// session asking again for the same content gets its existing instance.
+func (a *InstanceAllocator) Allocate(ctx context.Context, sessionID, contentID, deploymentName string) (*models.WorkloadInstance, error) {
+	key := instanceKey(sessionID, contentID)
+
+	id, err := randomHex(6)
+	if err != nil {
+		return nil, err
+	}
+	token, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	instance := &models.WorkloadInstance{
+		ID:           id,
+		SessionID:    sessionID,
+		ContentID:    contentID,
+		Deployment:   deploymentName,
+		Mode:         a.config.Mode,
+		EndpointURL:  fmt.Sprintf("%s/%s/%s", a.config.EndpointBase, deploymentName, id),
+		SessionToken: token,
+		CreatedAt:    time.Now(),
+	}
+
+	// Reserve the instance
+	a.mu.Lock()
+	if existing, ok := a.instances[key]; ok {
+		pending := a.pending[key]
+		snapshot := *existing
+		a.mu.Unlock()
+		if pending {
+			return nil, fmt.Errorf("allocate instance of %s: %w", deploymentName, ErrInstancePending)
+		}
+		return &snapshot, nil
+	}
+	a.instances[key] = instance
+	a.pending[key] = true
+	a.mu.Unlock()
+
+	// Create a dedicated pod and share the instance; a pooled slot is bound
+	// now if a pod has room, else when it is routed once the leader scaled up
+	record := instanceRecord{
+		Session:   sessionID,
+		Content:   contentID,
+		Mode:      instance.Mode,
+		Slot:      -1,
+		TokenHash: tokenHash(token),
+		CreatedAt: instance.CreatedAt,
+	}
+	var podName string
+	if a.config.Mode == models.InstanceDedicated {
+		podName, err = a.createPod(ctx, instance)
+		record.Pod, record.Slot = podName, 0
+	}
+	if err == nil {
+		err = a.writeRecord(ctx, deploymentName, id, &record)
+	}
+	if err == nil && a.config.Mode != models.InstanceDedicated {
+		if bound, err := a.bindSlot(ctx, deploymentName, id, nil); err == nil {
+			record = bound
+		} else if !errors.Is(err, ErrInstanceNotReady) {
+			log.Printf("Failed to bind instance %s of %s to a pod: %v", id, deploymentName, err)
+		}
+	}
+
+	// Commit, or roll back a failed or reclaimed reservation
+	a.mu.Lock()
+	reclaimed := a.instances[key] != instance
+	if err == nil && !reclaimed {
+		instance.PodName, instance.Slot = record.Pod, record.Slot
+		delete(a.pending, key)
+		snapshot := *instance
+		a.mu.Unlock()
+		log.Printf("Allocated %s instance %s of %s for session %s", instance.Mode, id, deploymentName, sessionID)
+		return &snapshot, nil
+	}
+	if !reclaimed {
+		delete(a.instances, key)
+		delete(a.pending, key)
+	}
+	a.mu.Unlock()
+
+	if err := a.removeRecord(ctx, deploymentName, id); err != nil {
+		log.Printf("Failed to remove record of instance %s rolled back during allocation: %v", id, err)
+	}
+	if podName != "" {
+		if err := a.deletePod(ctx, podName); err != nil {
+			log.Printf("Failed to delete instance pod %s rolled back during allocation: %v", podName, err)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to allocate instance of %s: %w", deploymentName, err)
+	}
+	return nil, fmt.Errorf("allocate instance of %s: reclaimed during allocation", deploymentName)
+}
+
+// Reclaim deletes a session's dedicated pod or frees its pooled slot. Sessions
+// without an instance of the content are ignored. An instance still being
+// allocated is rolled back by its allocation.
+func (a *InstanceAllocator) Reclaim(ctx context.Context, sessionID, contentID string) error {
+	key := instanceKey(sessionID, contentID)
+
+	a.mu.Lock()
+	instance, ok := a.instances[key]
+	if !ok {
+		a.mu.Unlock()
+		return nil
+	}
+	pending := a.pending[key]
+	delete(a.instances, key)
+	delete(a.pending, key)
+	a.mu.Unlock()
+	if pending {
+		return nil
+	}
+
+	err := a.removeRecord(ctx, instance.Deployment, instance.ID)
+	if err != nil {
+		err = fmt.Errorf("failed to remove record of instance %s: %w", instance.ID, err)
+	} else if instance.Mode == models.InstanceDedicated && instance.PodName != "" {
+		if err = a.deletePod(ctx, instance.PodName); err != nil {
+			err = fmt.Errorf("failed to delete instance pod %s: %w", instance.PodName, err)
+		}
+	}
+	if err != nil {
+		// Keep the instance so a later reclaim retries, unless the session
+		// already has a new one
+		a.mu.Lock()
+		if _, ok := a.instances[key]; !ok {
+			a.instances[key] = instance
+		}
+		a.mu.Unlock()
+		return err
+	}
+
+	log.Printf("Reclaimed instance %s of %s from session %s", instance.ID, instance.Deployment, sessionID)
+	return nil
+}
+
+// Route verifies a session token for an instance of a deployment, whichever
+// replica allocated it, and returns the instance and the URL of the pod
+// serving it. A pooled slot whose pod is gone is bound to another pod with
+// room; ErrInstanceNotReady is returned until one has.
+func (a *InstanceAllocator) Route(ctx context.Context, deploymentName, instanceID, token string) (*models.WorkloadInstance, *url.URL, error) {
+	records, err := a.records(ctx, deploymentName)
+	if err != nil {
+		return nil, nil, err
+	}
+	record, ok := records[instanceID]
+	if !ok || subtle.ConstantTimeCompare([]byte(record.TokenHash), []byte(tokenHash(token))) != 1 {
+		return nil, nil, ErrInstanceUnauthorized
+	}
+
+	var pod *corev1.Pod
+	if record.Mode == models.InstanceDedicated {
+		pod, err = a.client.clientset.CoreV1().Pods(a.client.namespace).Get(ctx, record.Pod, metav1.GetOptions{})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get instance pod %s: %w", record.Pod, err)
+		}
+	} else {
+		pods, err := a.servingPods(ctx, deploymentName)
+		if err != nil {
+			return nil, nil, err
+		}
+		if pod = pods[record.Pod]; pod == nil {
+			if record, err = a.bindSlot(ctx, deploymentName, instanceID, pods); err != nil {
+				return nil, nil, err
+			}
+			pod = pods[record.Pod]
+		}
+	}
+	target, err := podURL(pod)
+	if err != nil {
+		return nil, nil, fmt.Errorf("instance pod %s: %w", pod.Name, err)
+	}
+
+	instance := &models.WorkloadInstance{
+		ID:          instanceID,
+		SessionID:   record.Session,
+		ContentID:   record.Content,
+		Deployment:  deploymentName,
+		Mode:        record.Mode,
+		PodName:     record.Pod,
+		Slot:        record.Slot,
+		EndpointURL: fmt.Sprintf("%s/%s/%s", a.config.EndpointBase, deploymentName, instanceID),
+		CreatedAt:   record.CreatedAt,
+	}
+	return instance, target, nil
+}
+
+// podURL returns the URL a pod serves at: its IP and first container port.
+// It returns ErrInstanceNotReady until the pod has an IP.
+func podURL(pod *corev1.Pod) (*url.URL, error) {
+	if pod.Status.PodIP == "" {
+		return nil, ErrInstanceNotReady
+	}
+	host := pod.Status.PodIP
+	if port := containerPort(pod); port > 0 {
+		host = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	return &url.URL{Scheme: "http", Host: host}, nil
+}
+
+// Instances returns the instances this replica allocated, oldest first
+func (a *InstanceAllocator) Instances() []models.WorkloadInstance {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	instances := make([]models.WorkloadInstance, 0, len(a.instances))
+	for key, instance := range a.instances {
+		if !a.pending[key] {
+			instances = append(instances, *instance)
+		}
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].CreatedAt.Before(instances[j].CreatedAt)
+	})
+	return instances
+}
+
+// createPod starts a pod from the deployment's pod template, giving its
+// containers the instance ID and session token
+func (a *InstanceAllocator) createPod(ctx context.Context, instance *models.WorkloadInstance) (string, error) {
+	deployment, err := a.client.getDeployment(ctx, instance.Deployment)
+	if err != nil {
+		return "", err
+	}
+
+	pod := podFromTemplate(deployment, instance.Deployment+"-"+instance.ID, map[string]string{
+		LabelInstance:   instance.ID,
+		LabelSession:    instance.SessionID,
+		LabelDeployment: instance.Deployment,
+	})
+	for i := range pod.Spec.Containers {
+		pod.Spec.Containers[i].Env = append(pod.Spec.Containers[i].Env,
+			corev1.EnvVar{Name: EnvInstanceID, Value: instance.ID},
+			corev1.EnvVar{Name: EnvSessionToken, Value: instance.SessionToken},
+		)
+	}
+
+	podsClient := a.client.clientset.CoreV1().Pods(a.client.namespace)
+	err = withRetry(ctx, func() error {
+		_, err := podsClient.Create(ctx, pod, metav1.CreateOptions{})
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return pod.Name, nil
+}
+
+// deletePod deletes a dedicated instance pod; pods already gone are ignored
+func (a *InstanceAllocator) deletePod(ctx context.Context, podName string) error {
+	podsClient := a.client.clientset.CoreV1().Pods(a.client.namespace)
+	err := withRetry(ctx, func() error {
+		return podsClient.Delete(ctx, podName, metav1.DeleteOptions{})
+	})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// Slots returns how many pooled slots the sessions of this replica hold per
+// deployment, including slots still being allocated
+func (a *InstanceAllocator) Slots() map[string]int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	slots := make(map[string]int)
+	for _, instance := range a.instances {
+		if instance.Mode != models.InstanceDedicated {
+			slots[instance.Deployment]++
+		}
+	}
+	return slots
+}
+
+// ReplicasForSlots returns the replicas a pooled deployment needs to serve slots
+func (a *InstanceAllocator) ReplicasForSlots(slots int) int32 {
+	return int32((slots + a.config.SlotsPerPod - 1) / a.config.SlotsPerPod)
+}
+
+// bindSlot binds a pooled instance to the serving pod with the fewest bound
+// slots, taking its lowest free slot, unless the instance is bound to a
+// serving pod already. It returns the instance's record, ErrInstanceNotReady
+// if no pod has room, or ErrInstanceUnauthorized once the instance is gone.
+// pods are the deployment's serving pods; nil lists them.
+func (a *InstanceAllocator) bindSlot(ctx context.Context, deploymentName, instanceID string, pods map[string]*corev1.Pod) (instanceRecord, error) {
+	if pods == nil {
+		var err error
+		if pods, err = a.servingPods(ctx, deploymentName); err != nil {
+			return instanceRecord{}, err
+		}
+	}
+
+	var bound instanceRecord
+	err := a.client.updateLeaseAnnotations(ctx, deploymentName+instanceLeaseSuffix, func(annotations map[string]string) (bool, error) {
+		records := parseInstanceRecords(deploymentName, annotations)
+		record, ok := records[instanceID]
+		if !ok {
+			return false, ErrInstanceUnauthorized
+		}
+		if pods[record.Pod] != nil {
+			bound = record // Bound meanwhile by another replica
+			return false, nil
+		}
+
+		// Slots taken on each serving pod
+		taken := make(map[string]map[int]bool, len(pods))
+		for name := range pods {
+			taken[name] = make(map[int]bool)
+		}
+		for _, other := range records {
+			if other.Mode != models.InstanceDedicated && taken[other.Pod] != nil {
+				taken[other.Pod][other.Slot] = true
+			}
+		}
+		names := make([]string, 0, len(pods))
+		for name := range pods {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		podName := ""
+		for _, name := range names {
+			if len(taken[name]) < a.config.SlotsPerPod && (podName == "" || len(taken[name]) < len(taken[podName])) {
+				podName = name
+			}
+		}
+		if podName == "" {
+			return false, ErrInstanceNotReady
+		}
+		record.Pod, record.Slot = podName, 0
+		for taken[podName][record.Slot] {
+			record.Slot++
+		}
+
+		value, err := json.Marshal(record)
+		if err != nil {
+			return false, err
+		}
+		annotations[AnnotationInstancePrefix+instanceID] = string(value)
+		bound = record
+		return true, nil
+	})
+	if err != nil {
+		return instanceRecord{}, err
+	}
+	return bound, nil
+}
+
+// servingPods returns the pods of a deployment that have an IP and are not
+// being deleted, by name
+func (a *InstanceAllocator) servingPods(ctx context.Context, deploymentName string) (map[string]*corev1.Pod, error) {
+	deployment, err := a.client.getDeployment(ctx, deploymentName)
+	if err != nil {
+		return nil, err
+	}
+	if deployment.Spec.Selector == nil {
+		return map[string]*corev1.Pod{}, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("deployment %s selector: %w", deploymentName, err)
+	}
+	var pods *corev1.PodList
+	err = withRetry(ctx, func() (err error) {
+		pods, err = a.client.clientset.CoreV1().Pods(a.client.namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods of %s: %w", deploymentName, err)
+	}
+	serving := make(map[string]*corev1.Pod, len(pods.Items))
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Status.PodIP != "" && pod.DeletionTimestamp == nil {
+			serving[pod.Name] = pod
+		}
+	}
+	return serving, nil
+}
+
+// records returns the instances of a deployment every replica allocated, by ID
+func (a *InstanceAllocator) records(ctx context.Context, deploymentName string) (map[string]instanceRecord, error) {
+	annotations, err := a.client.leaseAnnotations(ctx, deploymentName+instanceLeaseSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read instances of %s: %w", deploymentName, err)
+	}
+	return parseInstanceRecords(deploymentName, annotations), nil
+}
+
+// writeRecord shares an instance with the other replicas
+func (a *InstanceAllocator) writeRecord(ctx context.Context, deploymentName, instanceID string, record *instanceRecord) error {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return a.client.updateLeaseAnnotations(ctx, deploymentName+instanceLeaseSuffix, func(annotations map[string]string) (bool, error) {
+		annotations[AnnotationInstancePrefix+instanceID] = string(value)
+		return true, nil
+	})
+}
+
+// removeRecord stops sharing an instance; instances not shared are ignored
+func (a *InstanceAllocator) removeRecord(ctx context.Context, deploymentName, instanceID string) error {
+	return a.client.updateLeaseAnnotations(ctx, deploymentName+instanceLeaseSuffix, func(annotations map[string]string) (bool, error) {
+		_, ok := annotations[AnnotationInstancePrefix+instanceID]
+		delete(annotations, AnnotationInstancePrefix+instanceID)
+		return ok, nil
+	})
+}
+
+// parseInstanceRecords returns the instance records among lease annotations, by ID
+func parseInstanceRecords(deploymentName string, annotations map[string]string) map[string]instanceRecord {
+	records := make(map[string]instanceRecord)
+	for key, value := range annotations {
+		id, ok := strings.CutPrefix(key, AnnotationInstancePrefix)
+		if !ok {
+			continue
+		}
+		var record instanceRecord
+		if err := json.Unmarshal([]byte(value), &record); err != nil {
+			log.Printf("Ignoring malformed record of instance %s of %s: %v", id, deploymentName, err)
+			continue
+		}
+		records[id] = record
+	}
+	return records
+}
+
+// tokenHash returns the hex SHA-256 of a session token
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// containerPort returns the first container port a pod declares, or 0
+func containerPort(pod *corev1.Pod) int32 {
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			return port.ContainerPort
+		}
+	}
+	return 0
+}
+
+// podFromTemplate builds a standalone pod from a deployment's pod template.
+// The deployment's selector labels are left off so its ReplicaSet does not
+// adopt the pod.
+func podFromTemplate(deployment *appsv1.Deployment, name string, labels map[string]string) *corev1.Pod {
+	template := deployment.Spec.Template.DeepCopy()
+	podLabels := make(map[string]string, len(template.Labels)+len(labels))
+	for k, v := range template.Labels {
+		podLabels[k] = v
+	}
+	if deployment.Spec.Selector != nil {
+		for k := range deployment.Spec.Selector.MatchLabels {
+			delete(podLabels, k)
+		}
+	}
+	for k, v := range labels {
+		podLabels[k] = v
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   deployment.Namespace,
+			Labels:      podLabels,
+			Annotations: template.Annotations,
+		},
+		Spec: template.Spec,
+	}
+	pod.Spec.RestartPolicy = corev1.RestartPolicyAlways
+	return pod
+}
+
+func instanceKey(sessionID, contentID string) string {
+	return sessionID + "/" + contentID
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,293 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gavigo/orchestrator/internal/models"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+const testNamespace = "gavigo"
+
+// testDeployment returns a workload deployment with one container on port 8080
+func testDeployment(name string, replicas int32) *appsv1.Deployment {
+	labels := map[string]string{"app": name, "type": "workload"}
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": name}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name:  name,
+					Image: "gavigo/" + name,
+					Ports: []corev1.ContainerPort{{ContainerPort: 8080}},
+				}}},
+			},
+		},
+	}
+}
+
+func newTestAllocator(mode string, objects ...runtime.Object) (*InstanceAllocator, *fake.Clientset) {
+	clientset := fake.NewSimpleClientset(objects...)
+	return newTestReplicaAllocator(mode, clientset), clientset
+}
+
+// newTestReplicaAllocator returns the allocator of another replica on a clientset
+func newTestReplicaAllocator(mode string, clientset *fake.Clientset) *InstanceAllocator {
+	config := DefaultInstanceConfig()
+	config.Mode = mode
+	config.SlotsPerPod = 2
+	return NewInstanceAllocator(NewClientForClientset(clientset, testNamespace), config)
+}
+
+// addServingPod adds a pod of a test deployment that has an IP
+func addServingPod(t *testing.T, clientset *fake.Clientset, deployment, name, ip string) {
+	t.Helper()
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testNamespace, Labels: map[string]string{"app": deployment}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: deployment, Ports: []corev1.ContainerPort{{ContainerPort: 8080}}}}},
+		Status:     corev1.PodStatus{PodIP: ip},
+	}
+	if _, err := clientset.CoreV1().Pods(testNamespace).Create(context.Background(), pod, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func podEnv(pod *corev1.Pod, name string) string {
+	for _, env := range pod.Spec.Containers[0].Env {
+		if env.Name == name {
+			return env.Value
+		}
+	}
+	return ""
+}
+
+func TestDedicatedInstanceGetsItsOwnPod(t *testing.T) {
+	ctx := context.Background()
+	allocator, clientset := newTestAllocator(models.InstanceDedicated, testDeployment("game-a", 1))
+
+	instance, err := allocator.Allocate(ctx, "s1", "content-a", "game-a")
+	if err != nil {
+		t.Fatalf("Allocate: %v", err)
+	}
+	if instance.EndpointURL != "/workloads/game-a/"+instance.ID || instance.SessionToken == "" {
+		t.Errorf("instance = %+v, want an endpoint under /workloads/game-a and a token", instance)
+	}
+	again, err := allocator.Allocate(ctx, "s1", "content-a", "game-a")
+	if err != nil || again.ID != instance.ID {
+		t.Errorf("second Allocate = %+v, %v; want the same instance", again, err)
+	}
+
+	pod, err := clientset.CoreV1().Pods(testNamespace).Get(ctx, instance.PodName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("instance pod: %v", err)
+	}
+	if pod.Labels[LabelSession] != "s1" || pod.Labels[LabelInstance] != instance.ID || pod.Labels["app"] != "" {
+		t.Errorf("pod labels = %v, want instance labels without the deployment selector", pod.Labels)
+	}
+	if podEnv(pod, EnvSessionToken) != instance.SessionToken || podEnv(pod, EnvInstanceID) != instance.ID {
+		t.Errorf("pod env = %v, want the instance ID and session token", pod.Spec.Containers[0].Env)
+	}
+
+	// Routed only with the session token, and once the pod has an IP
+	if _, _, err := allocator.Route(ctx, "game-a", instance.ID, "wrong"); !errors.Is(err, ErrInstanceUnauthorized) {
+		t.Errorf("Route with a wrong token = %v, want ErrInstanceUnauthorized", err)
+	}
+	if _, _, err := allocator.Route(ctx, "other", instance.ID, instance.SessionToken); !errors.Is(err, ErrInstanceUnauthorized) {
+		t.Errorf("Route under another deployment = %v, want ErrInstanceUnauthorized", err)
+	}
+	if _, _, err := allocator.Route(ctx, "game-a", instance.ID, instance.SessionToken); !errors.Is(err, ErrInstanceNotReady) {
+		t.Errorf("Route before the pod has an IP = %v, want ErrInstanceNotReady", err)
+	}
+	pod.Status.PodIP = "10.0.0.7"
+	if _, err := clientset.CoreV1().Pods(testNamespace).UpdateStatus(ctx, pod, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	// Any replica routes the instance
+	other := newTestReplicaAllocator(models.InstanceDedicated, clientset)
+	for _, router := range []*InstanceAllocator{allocator, other} {
+		routed, target, err := router.Route(ctx, "game-a", instance.ID, instance.SessionToken)
+		if err != nil || routed.SessionID != "s1" || routed.PodName != instance.PodName || target.String() != "http://10.0.0.7:8080" {
+			t.Errorf("Route = %+v, %v, %v; want the pod at 10.0.0.7:8080", routed, target, err)
+		}
+	}
+
+	if err := allocator.Reclaim(ctx, "s1", "content-a"); err != nil {
+		t.Fatalf("Reclaim: %v", err)
+	}
+	if pods, _ := clientset.CoreV1().Pods(testNamespace).List(ctx, metav1.ListOptions{}); len(pods.Items) != 0 {
+		t.Errorf("%d pods left after Reclaim", len(pods.Items))
+	}
+	if _, _, err := other.Route(ctx, "game-a", instance.ID, instance.SessionToken); !errors.Is(err, ErrInstanceUnauthorized) {
+		t.Errorf("Route after Reclaim = %v, want ErrInstanceUnauthorized", err)
+	}
+}
+
+func TestPooledInstancesShareReplicasBySlot(t *testing.T) {
+	ctx := context.Background()
+	allocator, clientset := newTestAllocator(models.InstancePooled, testDeployment("ai-service", 1))
+	other := newTestReplicaAllocator(models.InstancePooled, clientset)
+	addServingPod(t, clientset, "ai-service", "ai-service-a", "10.0.0.1")
+
+	// Two slots per pod: the third session finds no room
+	instances := make(map[string]*models.WorkloadInstance)
+	for _, sessionID := range []string{"s1", "s2", "s3"} {
+		instance, err := allocator.Allocate(ctx, sessionID, "content-ai", "ai-service")
+		if err != nil {
+			t.Fatalf("Allocate %s: %v", sessionID, err)
+		}
+		instances[sessionID] = instance
+	}
+	for sessionID, want := range map[string]int{"s1": 0, "s2": 1, "s3": -1} {
+		if got := instances[sessionID]; got.Slot != want || (want >= 0) != (got.PodName == "ai-service-a") {
+			t.Errorf("%s bound to %q slot %d, want slot %d", sessionID, got.PodName, got.Slot, want)
+		}
+	}
+	if slots := allocator.Slots(); slots["ai-service"] != 3 || allocator.ReplicasForSlots(3) != 2 {
+		t.Errorf("Slots = %v, ReplicasForSlots(3) = %d; want 3 slots on 2 replicas", slots, allocator.ReplicasForSlots(3))
+	}
+	if _, _, err := other.Route(ctx, "ai-service", instances["s3"].ID, instances["s3"].SessionToken); !errors.Is(err, ErrInstanceNotReady) {
+		t.Errorf("Route without room = %v, want ErrInstanceNotReady", err)
+	}
+
+	// Any replica routes each slot to its pod, binding s3 once a pod has room
+	routed, target, err := other.Route(ctx, "ai-service", instances["s2"].ID, instances["s2"].SessionToken)
+	if err != nil || routed.Slot != 1 || target.String() != "http://10.0.0.1:8080" {
+		t.Errorf("Route = %+v, %v, %v; want slot 1 on 10.0.0.1:8080", routed, target, err)
+	}
+	addServingPod(t, clientset, "ai-service", "ai-service-b", "10.0.0.2")
+	routed, target, err = other.Route(ctx, "ai-service", instances["s3"].ID, instances["s3"].SessionToken)
+	if err != nil || routed.PodName != "ai-service-b" || routed.Slot != 0 || target.String() != "http://10.0.0.2:8080" {
+		t.Errorf("Route = %+v, %v, %v; want slot 0 on 10.0.0.2:8080", routed, target, err)
+	}
+	if _, _, err := other.Route(ctx, "ai-service", instances["s3"].ID, "wrong"); !errors.Is(err, ErrInstanceUnauthorized) {
+		t.Errorf("Route with a wrong token = %v, want ErrInstanceUnauthorized", err)
+	}
+
+	// A slot whose pod is gone is rebound
+	if err := clientset.CoreV1().Pods(testNamespace).Delete(ctx, "ai-service-a", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	routed, _, err = other.Route(ctx, "ai-service", instances["s1"].ID, instances["s1"].SessionToken)
+	if err != nil || routed.PodName != "ai-service-b" || routed.Slot != 1 {
+		t.Errorf("Route after the pod left = %+v, %v; want slot 1 on ai-service-b", routed, err)
+	}
+
+	// A reclaimed slot is reused
+	if err := allocator.Reclaim(ctx, "s3", "content-ai"); err != nil {
+		t.Fatalf("Reclaim: %v", err)
+	}
+	if _, _, err := other.Route(ctx, "ai-service", instances["s3"].ID, instances["s3"].SessionToken); !errors.Is(err, ErrInstanceUnauthorized) {
+		t.Errorf("Route after Reclaim = %v, want ErrInstanceUnauthorized", err)
+	}
+	instance, err := other.Allocate(ctx, "s4", "content-ai", "ai-service")
+	if err != nil || instance.PodName != "ai-service-b" || instance.Slot != 0 {
+		t.Errorf("Allocate after Reclaim = %+v, %v; want slot 0 on ai-service-b", instance, err)
+	}
+	if pods, _ := clientset.CoreV1().Pods(testNamespace).List(ctx, metav1.ListOptions{}); len(pods.Items) != 1 {
+		t.Errorf("pooled instances created %d pods", len(pods.Items)-1)
+	}
+	if got := len(allocator.Instances()); got != 2 {
+		t.Errorf("%d instances, want 2", got)
+	}
+}
+
+// holdingClientset runs hold before every pod creation. Reactors cannot block
+// a call on their own: the fake clientset runs them under its lock.
+type holdingClientset struct {
+	*fake.Clientset
+	hold func()
+}
+
+func (c holdingClientset) CoreV1() typedcorev1.CoreV1Interface {
+	return holdingCoreV1{c.Clientset.CoreV1(), c.hold}
+}
+
+type holdingCoreV1 struct {
+	typedcorev1.CoreV1Interface
+	hold func()
+}
+
+func (c holdingCoreV1) Pods(namespace string) typedcorev1.PodInterface {
+	return holdingPods{c.CoreV1Interface.Pods(namespace), c.hold}
+}
+
+type holdingPods struct {
+	typedcorev1.PodInterface
+	hold func()
+}
+
+func (p holdingPods) Create(ctx context.Context, pod *corev1.Pod, opts metav1.CreateOptions) (*corev1.Pod, error) {
+	p.hold()
+	return p.PodInterface.Create(ctx, pod, opts)
+}
+
+func TestInstanceAllocationDoesNotBlockOtherSessions(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset(testDeployment("game-a", 1))
+
+	// Hold the first pod creation until released
+	release := make(chan struct{})
+	creating := make(chan struct{})
+	var held atomic.Bool
+	holding := holdingClientset{clientset, func() {
+		if held.CompareAndSwap(false, true) {
+			close(creating)
+			<-release
+		}
+	}}
+	config := DefaultInstanceConfig()
+	config.Mode = models.InstanceDedicated
+	allocator := NewInstanceAllocator(NewClientForClientset(holding, testNamespace), config)
+
+	done := make(chan error)
+	go func() {
+		_, err := allocator.Allocate(ctx, "s1", "content-a", "game-a")
+		done <- err
+	}()
+	<-creating
+
+	// Other sessions allocate, list and reclaim while the first is in flight
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		if _, err := allocator.Allocate(ctx, "s2", "content-a", "game-a"); err != nil {
+			t.Errorf("Allocate s2: %v", err)
+		}
+		if _, err := allocator.Allocate(ctx, "s1", "content-a", "game-a"); !errors.Is(err, ErrInstancePending) {
+			t.Errorf("Allocate of a pending instance = %v, want ErrInstancePending", err)
+		}
+		if got := len(allocator.Instances()); got != 1 {
+			t.Errorf("%d instances listed, want only the allocated one", got)
+		}
+		// Reclaiming the pending instance rolls it back once its pod exists
+		if err := allocator.Reclaim(ctx, "s1", "content-a"); err != nil {
+			t.Errorf("Reclaim s1: %v", err)
+		}
+	}()
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("other sessions blocked behind an in-flight allocation")
+	}
+
+	close(release)
+	if err := <-done; err == nil {
+		t.Error("Allocate of a reclaimed instance succeeded")
+	}
+	pods, _ := clientset.CoreV1().Pods(testNamespace).List(ctx, metav1.ListOptions{})
+	if len(pods.Items) != 1 || pods.Items[0].Labels[LabelSession] != "s2" {
+		t.Errorf("pods = %d, want only s2's", len(pods.Items))
+	}
+}
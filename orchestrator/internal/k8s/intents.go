@@ -0,0 +1,174 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gavigo/orchestrator/internal/models"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// intentLeaseSuffix names the intent lease after the election lease
+	intentLeaseSuffix = "-intents"
+	// AnnotationIntentPrefix prefixes the replica name of the annotation carrying its intent
+	AnnotationIntentPrefix = "intents.gavigo.io/"
+	// intentWithdrawTimeout bounds removing this replica's intent on Stop
+	intentWithdrawTimeout = 5 * time.Second
+)
+
+// watchIntents sets up the informer of the intent lease
+func (e *LeaderElector) watchIntents() {
+	e.intentsFactory = informers.NewSharedInformerFactoryWithOptions(e.client.clientset, 0,
+		informers.WithNamespace(e.client.namespace),
+		informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.FieldSelector = fields.OneTermEqualSelector("metadata.name", e.intentLease).String()
+		}))
+	e.intents = e.intentsFactory.Coordination().V1().Leases().Informer()
+	e.intents.SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+		log.Printf("Watch of intent lease %s failed, relisting with backoff: %v", e.intentLease, err)
+	})
+	e.intents.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { e.intentsChanged(obj) },
+		UpdateFunc: func(_, newObj interface{}) { e.intentsChanged(newObj) },
+	})
+}
+
+// Publish shares the workload state this replica wants with the leader. The
+// intent is refreshed until Stop, which withdraws it.
+func (e *LeaderElector) Publish(ctx context.Context, intent models.ReplicaIntent) error {
+	intent.Replica = e.identity
+	e.mu.Lock()
+	e.published = &intent
+	e.mu.Unlock()
+	return e.writeIntent(ctx, &intent)
+}
+
+// Intents returns the intents of the other replicas that were refreshed
+// within the intent TTL, ordered by replica
+func (e *LeaderElector) Intents() []models.ReplicaIntent {
+	intents := []models.ReplicaIntent{}
+	obj, exists, err := e.intents.GetStore().GetByKey(e.client.namespace + "/" + e.intentLease)
+	if err != nil || !exists {
+		return intents
+	}
+	lease, ok := obj.(*coordinationv1.Lease)
+	if !ok {
+		return intents
+	}
+
+	expired := time.Now().Add(-e.intentTTL)
+	for key, value := range lease.Annotations {
+		replica, ok := strings.CutPrefix(key, AnnotationIntentPrefix)
+		if !ok || replica == e.identity {
+			continue
+		}
+		var intent models.ReplicaIntent
+		if err := json.Unmarshal([]byte(value), &intent); err != nil {
+			log.Printf("Ignoring malformed intent of replica %s: %v", replica, err)
+			continue
+		}
+		if intent.UpdatedAt.Before(expired) {
+			continue
+		}
+		intent.Replica = replica
+		intents = append(intents, intent)
+	}
+	sort.Slice(intents, func(i, j int) bool { return intents[i].Replica < intents[j].Replica })
+	return intents
+}
+
+// writeIntent stores an intent in the intent lease, stamped with the current time
+func (e *LeaderElector) writeIntent(ctx context.Context, intent *models.ReplicaIntent) error {
+	intent.UpdatedAt = time.Now()
+	value, err := json.Marshal(intent)
+	if err != nil {
+		return err
+	}
+	if err := e.updateIntentLease(ctx, AnnotationIntentPrefix+e.identity, string(value)); err != nil {
+		return fmt.Errorf("failed to publish intent of replica %s: %w", e.identity, err)
+	}
+	return nil
+}
+
+// updateIntentLease sets an annotation of the intent lease, or removes it
+// when value is empty
+func (e *LeaderElector) updateIntentLease(ctx context.Context, key, value string) error {
+	return e.client.updateLeaseAnnotations(ctx, e.intentLease, func(annotations map[string]string) (bool, error) {
+		if value == "" {
+			_, ok := annotations[key]
+			delete(annotations, key)
+			return ok, nil
+		}
+		annotations[key] = value
+		return true, nil
+	})
+}
+
+// refreshIntents republishes this replica's intent so it does not expire and,
+// on the leader, reconciles so expired intents stop counting, until ctx is done
+func (e *LeaderElector) refreshIntents(ctx context.Context) {
+	ticker := time.NewTicker(e.intentTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		e.mu.RLock()
+		var intent *models.ReplicaIntent
+		if e.published != nil {
+			copied := *e.published
+			intent = &copied
+		}
+		e.mu.RUnlock()
+		if intent != nil {
+			if err := e.writeIntent(ctx, intent); err != nil && ctx.Err() == nil {
+				log.Printf("Warning: %v", err)
+			}
+		}
+		e.notifyIntents()
+	}
+}
+
+// withdrawIntent removes this replica's intent so the leader stops counting it
+func (e *LeaderElector) withdrawIntent() {
+	e.mu.Lock()
+	published := e.published != nil
+	e.published = nil
+	e.mu.Unlock()
+	if !published {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), intentWithdrawTimeout)
+	defer cancel()
+	if err := e.updateIntentLease(ctx, AnnotationIntentPrefix+e.identity, ""); err != nil {
+		log.Printf("Failed to withdraw intent of replica %s: %v", e.identity, err)
+	}
+}
+
+// intentsChanged hands the other replicas' intents to the leader when the intent lease changes
+func (e *LeaderElector) intentsChanged(obj interface{}) {
+	if lease, ok := obj.(*coordinationv1.Lease); ok && lease.Name == e.intentLease {
+		e.notifyIntents()
+	}
+}
+
+// notifyIntents calls OnIntents while this replica leads
+func (e *LeaderElector) notifyIntents() {
+	if e.OnIntents != nil && e.IsLeader() {
+		e.OnIntents(e.Intents())
+	}
+}
@@ -0,0 +1,222 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gavigo/orchestrator/internal/models"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// LeaderElectionConfig holds configuration for electing the replica that
+// scales and throttles workloads
+type LeaderElectionConfig struct {
+	LeaseName     string        // Lease object shared by all replicas
+	Identity      string        // This replica's name in the lease; defaults to the hostname (the pod name)
+	LeaseDuration time.Duration // How long followers wait before taking over an unrenewed lease
+	RenewDeadline time.Duration // How long the leader retries renewing before it steps down
+	RetryPeriod   time.Duration // Wait between attempts to acquire or renew
+	IntentTTL     time.Duration // How long a replica's published intent counts; replicas refresh theirs three times per TTL
+}
+
+// DefaultLeaderElectionConfig returns the default leader election configuration
+func DefaultLeaderElectionConfig() LeaderElectionConfig {
+	return LeaderElectionConfig{
+		LeaseName:     "gavigo-orchestrator",
+		LeaseDuration: 15 * time.Second,
+		RenewDeadline: 10 * time.Second,
+		RetryPeriod:   2 * time.Second,
+		IntentTTL:     30 * time.Second,
+	}
+}
+
+// LeaderStatus is a replica's view of the election
+type LeaderStatus struct {
+	Enabled  bool   `json:"enabled"`
+	Identity string `json:"identity,omitempty"` // This replica
+	Leader   string `json:"leader,omitempty"`   // Replica holding the lease; empty while there is none
+	IsLeader bool   `json:"is_leader"`
+}
+
+// LeaderElector campaigns for a Lease in the client's namespace so that only
+// one orchestrator replica scales and throttles workloads. A replica that
+// loses the lease campaigns again; the lease is released on Stop so another
+// replica takes over without waiting for it to expire. Every replica shares
+// the workload state it wants with Publish, so the leader scales and
+// throttles for all of them.
+type LeaderElector struct {
+	client   *Client
+	elector  *leaderelection.LeaderElector
+	identity string
+
+	intentLease    string        // Lease whose annotations carry each replica's intent
+	intentTTL      time.Duration // Age after which an unrefreshed intent no longer counts
+	intentsFactory informers.SharedInformerFactory
+	intents        cache.SharedIndexInformer
+
+	mu        sync.RWMutex
+	leading   bool
+	cancel    context.CancelFunc
+	done      chan struct{}
+	published *models.ReplicaIntent // Refreshed until Stop
+
+	// Callbacks when this replica gains and loses the lease. OnElected runs in
+	// its own goroutine; its context is done once leadership is lost.
+	OnElected func(ctx context.Context)
+	OnDeposed func()
+	// Callback on the leader with the live intents of the other replicas, when
+	// one changes and on every refresh so expired intents stop counting
+	OnIntents func(intents []models.ReplicaIntent)
+}
+
+// NewLeaderElector creates an elector for a client's namespace. Set the
+// callbacks, then call Start.
+func NewLeaderElector(client *Client, config LeaderElectionConfig) (*LeaderElector, error) {
+	if config.Identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+		config.Identity = hostname
+	}
+	if config.LeaseName == "" {
+		return nil, errors.New("leader election needs a lease name")
+	}
+
+	e := &LeaderElector{
+		client:      client,
+		identity:    config.Identity,
+		intentLease: config.LeaseName + intentLeaseSuffix,
+		intentTTL:   config.IntentTTL,
+	}
+	if e.intentTTL <= 0 {
+		e.intentTTL = DefaultLeaderElectionConfig().IntentTTL
+	}
+	e.watchIntents()
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta:  metav1.ObjectMeta{Name: config.LeaseName, Namespace: client.namespace},
+			Client:     client.clientset.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{Identity: config.Identity},
+		},
+		LeaseDuration:   config.LeaseDuration,
+		RenewDeadline:   config.RenewDeadline,
+		RetryPeriod:     config.RetryPeriod,
+		ReleaseOnCancel: true,
+		Name:            config.LeaseName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: e.elected,
+			OnStoppedLeading: e.deposed,
+			OnNewLeader: func(identity string) {
+				if identity != config.Identity {
+					log.Printf("Following leader %s", identity)
+				}
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	e.elector = elector
+	return e, nil
+}
+
+// Start campaigns for the lease in the background until ctx is done or Stop
+// is called
+func (e *LeaderElector) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	e.mu.Lock()
+	e.cancel = cancel
+	e.done = make(chan struct{})
+	e.mu.Unlock()
+
+	log.Printf("Campaigning for leadership as %s", e.identity)
+	e.intentsFactory.Start(ctx.Done())
+	var running sync.WaitGroup
+	running.Add(2)
+	go func() {
+		defer running.Done()
+		for ctx.Err() == nil {
+			e.elector.Run(ctx) // Returns when leadership is lost or ctx is done
+		}
+	}()
+	go func() {
+		defer running.Done()
+		e.refreshIntents(ctx)
+	}()
+	go func() {
+		running.Wait()
+		close(e.done)
+	}()
+}
+
+// Stop ends the campaign and returns once a held lease is released and this
+// replica's intent withdrawn
+func (e *LeaderElector) Stop() {
+	e.mu.RLock()
+	cancel, done := e.cancel, e.done
+	e.mu.RUnlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+	e.withdrawIntent()
+}
+
+// IsLeader reports whether this replica holds the lease
+func (e *LeaderElector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.leading
+}
+
+// Status returns this replica's view of the election
+func (e *LeaderElector) Status() LeaderStatus {
+	return LeaderStatus{
+		Enabled:  true,
+		Identity: e.identity,
+		Leader:   e.elector.GetLeader(),
+		IsLeader: e.IsLeader(),
+	}
+}
+
+// elected runs in its own goroutine, so a campaign may already have ended
+func (e *LeaderElector) elected(ctx context.Context) {
+	e.mu.Lock()
+	if ctx.Err() != nil {
+		e.mu.Unlock()
+		return
+	}
+	e.leading = true
+	e.mu.Unlock()
+	log.Printf("Elected leader as %s: scaling and throttling workloads", e.identity)
+
+	if e.OnElected != nil {
+		e.OnElected(ctx)
+	}
+}
+
+// deposed runs whenever a campaign ends, including ones that never led
+func (e *LeaderElector) deposed() {
+	e.mu.Lock()
+	wasLeading := e.leading
+	e.leading = false
+	e.mu.Unlock()
+	if !wasLeading {
+		return
+	}
+	log.Printf("Stopped leading as %s", e.identity)
+
+	if e.OnDeposed != nil {
+		e.OnDeposed()
+	}
+}
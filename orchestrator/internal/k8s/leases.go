@@ -0,0 +1,65 @@
+package k8s
+
+import (
+	"context"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// updateLeaseAnnotations changes the annotations of a lease that replicas
+// share state through, creating the lease if needed. update is called with
+// the current annotations, retried against fresh ones on conflicts, and
+// reports whether it changed them.
+func (c *Client) updateLeaseAnnotations(ctx context.Context, name string, update func(annotations map[string]string) (bool, error)) error {
+	leases := c.clientset.CoordinationV1().Leases(c.namespace)
+	return withRetry(ctx, func() error {
+		lease, err := leases.Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			annotations := make(map[string]string)
+			if changed, err := update(annotations); err != nil || !changed {
+				return err
+			}
+			lease = &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   c.namespace,
+				Annotations: annotations,
+			}}
+			_, err = leases.Create(ctx, lease, metav1.CreateOptions{})
+			if apierrors.IsAlreadyExists(err) {
+				// Created meanwhile by another replica: retry as an update
+				return apierrors.NewConflict(coordinationv1.Resource("leases"), name, err)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		if lease.Annotations == nil {
+			lease.Annotations = make(map[string]string)
+		}
+		if changed, err := update(lease.Annotations); err != nil || !changed {
+			return err
+		}
+		_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// leaseAnnotations returns the annotations of a shared lease; none if it does not exist
+func (c *Client) leaseAnnotations(ctx context.Context, name string) (map[string]string, error) {
+	leases := c.clientset.CoordinationV1().Leases(c.namespace)
+	var lease *coordinationv1.Lease
+	err := withRetry(ctx, func() (err error) {
+		lease, err = leases.Get(ctx, name, metav1.GetOptions{})
+		return err
+	})
+	if apierrors.IsNotFound(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return lease.Annotations, nil
+}
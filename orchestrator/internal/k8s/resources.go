@@ -0,0 +1,120 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/gavigo/orchestrator/internal/models"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// podMetricsList is the part of a metrics.k8s.io/v1beta1 PodMetricsList the
+// orchestrator reads, decoded here rather than pulling in the metrics client
+type podMetricsList struct {
+	Items []struct {
+		Metadata   metav1.ObjectMeta `json:"metadata"`
+		Containers []struct {
+			Name  string              `json:"name"`
+			Usage corev1.ResourceList `json:"usage"`
+		} `json:"containers"`
+	} `json:"items"`
+}
+
+// DeploymentResources sums the requests and limits of each deployment's
+// desired pods and, if withUsage, their live usage from the metrics API.
+// Deployments that do not exist are skipped. The returned bool reports whether
+// usage was read; without a metrics API only requests and limits are returned.
+func (c *Client) DeploymentResources(ctx context.Context, names []string, withUsage bool) ([]models.DeploymentResources, bool, error) {
+	deploymentsClient := c.clientset.AppsV1().Deployments(c.namespace)
+
+	var deployments []*appsv1.Deployment
+	resources := make([]models.DeploymentResources, 0, len(names))
+	for _, name := range names {
+		var deployment *appsv1.Deployment
+		err := withRetry(ctx, func() (err error) {
+			deployment, err = deploymentsClient.Get(ctx, name, metav1.GetOptions{})
+			return err
+		})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to get deployment %s: %w", name, err)
+		}
+
+		replicas := int32(0)
+		if deployment.Spec.Replicas != nil {
+			replicas = *deployment.Spec.Replicas
+		}
+		r := models.DeploymentResources{Deployment: name, Replicas: replicas}
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			r.CPURequestMillis += container.Resources.Requests.Cpu().MilliValue()
+			r.CPULimitMillis += container.Resources.Limits.Cpu().MilliValue()
+			r.MemoryRequestBytes += container.Resources.Requests.Memory().Value()
+			r.MemoryLimitBytes += container.Resources.Limits.Memory().Value()
+		}
+		r.CPURequestMillis *= int64(replicas)
+		r.CPULimitMillis *= int64(replicas)
+		r.MemoryRequestBytes *= int64(replicas)
+		r.MemoryLimitBytes *= int64(replicas)
+
+		deployments = append(deployments, deployment)
+		resources = append(resources, r)
+	}
+
+	if !withUsage {
+		return resources, false, nil
+	}
+	metrics, err := c.podMetrics(ctx)
+	if err != nil {
+		log.Printf("Resource usage not available: %v", err)
+		return resources, false, nil
+	}
+	for i, deployment := range deployments {
+		selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+		if err != nil || selector.Empty() {
+			continue
+		}
+		for _, pod := range metrics.Items {
+			if !selector.Matches(labels.Set(pod.Metadata.Labels)) {
+				continue
+			}
+			for _, container := range pod.Containers {
+				resources[i].CPUUsageMillis += container.Usage.Cpu().MilliValue()
+				resources[i].MemoryUsageBytes += container.Usage.Memory().Value()
+			}
+		}
+	}
+	return resources, true, nil
+}
+
+// podMetrics lists the usage of the namespace's pods from the metrics API
+func (c *Client) podMetrics(ctx context.Context) (*podMetricsList, error) {
+	restClient := c.clientset.Discovery().RESTClient()
+	if restClient == nil {
+		return nil, fmt.Errorf("no REST client for the metrics API")
+	}
+
+	var data []byte
+	err := withRetry(ctx, func() (err error) {
+		data, err = restClient.Get().
+			AbsPath("/apis/metrics.k8s.io/v1beta1/namespaces", c.namespace, "pods").
+			DoRaw(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pod metrics: %w", err)
+	}
+
+	var metrics podMetricsList
+	if err := json.Unmarshal(data, &metrics); err != nil {
+		return nil, fmt.Errorf("failed to parse pod metrics: %w", err)
+	}
+	return &metrics, nil
+}
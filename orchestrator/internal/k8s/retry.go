@@ -0,0 +1,41 @@
+package k8s
+
+import (
+	"context"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+)
+
+// RetryBackoff is the backoff used when retrying transient Kubernetes API failures
+var RetryBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 200 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.1,
+}
+
+// IsTransient reports whether a Kubernetes API error is likely to succeed on retry:
+// write conflicts, throttling, timeouts and temporarily unavailable API servers
+func IsTransient(err error) bool {
+	return apierrors.IsConflict(err) ||
+		apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsInternalError(err) ||
+		utilnet.IsConnectionReset(err) ||
+		utilnet.IsConnectionRefused(err)
+}
+
+// withRetry runs fn, retrying transient failures with RetryBackoff. fn should
+// re-read any object it updates so conflicts are retried against fresh state.
+// Retries stop early once ctx is done.
+func withRetry(ctx context.Context, fn func() error) error {
+	return retry.OnError(RetryBackoff, func(err error) bool {
+		return ctx.Err() == nil && IsTransient(err)
+	}, fn)
+}
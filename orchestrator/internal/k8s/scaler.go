@@ -2,27 +2,33 @@ package k8s
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 
+	appsv1 "k8s.io/api/apps/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-// ScaleDeployment scales a deployment to the specified number of replicas
+// ScaleDeployment scales a deployment to the specified number of replicas,
+// retrying transient API failures
 func (c *Client) ScaleDeployment(ctx context.Context, deploymentName string, replicas int32) error {
 	deploymentsClient := c.clientset.AppsV1().Deployments(c.namespace)
 
-	// Get current deployment
-	deployment, err := deploymentsClient.Get(ctx, deploymentName, metav1.GetOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to get deployment %s: %w", deploymentName, err)
-	}
+	err := withRetry(ctx, func() error {
+		// Get current deployment
+		deployment, err := deploymentsClient.Get(ctx, deploymentName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
 
-	// Update replicas
-	deployment.Spec.Replicas = &replicas
+		// Update replicas
+		deployment.Spec.Replicas = &replicas
 
-	// Apply update
-	_, err = deploymentsClient.Update(ctx, deployment, metav1.UpdateOptions{})
+		// Apply update
+		_, err = deploymentsClient.Update(ctx, deployment, metav1.UpdateOptions{})
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to scale deployment %s: %w", deploymentName, err)
 	}
@@ -35,7 +41,11 @@ func (c *Client) ScaleDeployment(ctx context.Context, deploymentName string, rep
 func (c *Client) GetDeploymentReplicas(ctx context.Context, deploymentName string) (int32, int32, error) {
 	deploymentsClient := c.clientset.AppsV1().Deployments(c.namespace)
 
-	deployment, err := deploymentsClient.Get(ctx, deploymentName, metav1.GetOptions{})
+	var deployment *appsv1.Deployment
+	err := withRetry(ctx, func() (err error) {
+		deployment, err = deploymentsClient.Get(ctx, deploymentName, metav1.GetOptions{})
+		return err
+	})
 	if err != nil {
 		return 0, 0, fmt.Errorf("failed to get deployment %s: %w", deploymentName, err)
 	}
@@ -49,6 +59,40 @@ func (c *Client) GetDeploymentReplicas(ctx context.Context, deploymentName strin
 	return desired, ready, nil
 }
 
+// EnsureReplicas scales a deployment up to at least replicas; one running more is left alone
+func (c *Client) EnsureReplicas(ctx context.Context, deploymentName string, replicas int32) error {
+	if replicas <= 0 {
+		return nil
+	}
+	desired, _, err := c.GetDeploymentReplicas(ctx, deploymentName)
+	if err != nil {
+		return err
+	}
+	if desired >= replicas {
+		return nil
+	}
+	return c.ScaleDeployment(ctx, deploymentName, replicas)
+}
+
+var errNoContainers = errors.New("pod template has no containers")
+
+// getDeployment reads a deployment that has a pod template to run
+func (c *Client) getDeployment(ctx context.Context, name string) (*appsv1.Deployment, error) {
+	deploymentsClient := c.clientset.AppsV1().Deployments(c.namespace)
+	var deployment *appsv1.Deployment
+	err := withRetry(ctx, func() (err error) {
+		deployment, err = deploymentsClient.Get(ctx, name, metav1.GetOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deployment %s: %w", name, err)
+	}
+	if len(deployment.Spec.Template.Spec.Containers) == 0 {
+		return nil, fmt.Errorf("deployment %s: %w", name, errNoContainers)
+	}
+	return deployment, nil
+}
+
 // ScaleToWarm scales a deployment to 1 replica (WARM state)
 func (c *Client) ScaleToWarm(ctx context.Context, deploymentName string) error {
 	return c.ScaleDeployment(ctx, deploymentName, 1)
@@ -68,12 +112,14 @@ func (c *Client) IsDeploymentReady(ctx context.Context, deploymentName string) (
 	return desired > 0 && ready >= desired, nil
 }
 
-// ScaleAllToCold scales all workload deployments to 0 replicas
+// ScaleAllToCold scales all workload deployments to 0 replicas. Every deployment
+// is attempted; the returned error joins the failures.
 func (c *Client) ScaleAllToCold(ctx context.Context, deploymentNames []string) error {
+	var errs []error
 	for _, name := range deploymentNames {
 		if err := c.ScaleToCold(ctx, name); err != nil {
-			log.Printf("Warning: failed to scale %s to cold: %v", name, err)
+			errs = append(errs, err)
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
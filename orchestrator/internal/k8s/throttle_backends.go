@@ -0,0 +1,368 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Throttle backends. Only the resources backend changes container limits; the
+// others leave running pods alone where they can.
+const (
+	BackendResources     = "resources"      // Rewrite container CPU/memory requests and limits
+	BackendPriorityClass = "priority-class" // Swap the pod template's PriorityClass; pods are replaced to pick it up
+	BackendHPA           = "hpa"            // Narrow the deployment's HorizontalPodAutoscaler min/max replicas
+	BackendSidecar       = "sidecar"        // Pause and resume pods through a sidecar control endpoint
+)
+
+// Annotations recording what the non-resource backends changed
+const (
+	AnnotationOriginalPriorityClass = "gavigo.io/original-priority-class" // On the deployment; "" when it had none
+	AnnotationOriginalReplicas      = "gavigo.io/original-replicas"       // On the HPA; JSON HPAReplicas
+)
+
+// HPAReplicas bounds the replicas of a HorizontalPodAutoscaler
+type HPAReplicas struct {
+	MinReplicas int32 `json:"min_replicas"`
+	MaxReplicas int32 `json:"max_replicas"`
+}
+
+// SidecarConfig locates the control endpoint of a pause/resume sidecar
+type SidecarConfig struct {
+	Port       int    `json:"port"`
+	PausePath  string `json:"pause_path"`
+	ResumePath string `json:"resume_path"`
+}
+
+// validateBackends checks the backend selection and each selected backend's settings
+func (c ThrottleConfig) validateBackends() error {
+	used := map[string]bool{c.backendOrDefault(c.DefaultBackend): true}
+	for deployment, backend := range c.Backends {
+		used[c.backendOrDefault(backend)] = true
+		if deployment == "" {
+			return errors.New("throttle backend has no deployment name")
+		}
+	}
+
+	var errs []error
+	for backend := range used {
+		switch backend {
+		case BackendResources:
+		case BackendPriorityClass:
+			if len(c.PriorityClasses) == 0 {
+				errs = append(errs, errors.New("priority-class backend needs priority_classes"))
+			}
+			for level := range c.PriorityClasses {
+				if level != LevelActive && level != LevelWarm && level != LevelBackground {
+					errs = append(errs, fmt.Errorf("priority class for unknown level %q", level))
+				}
+			}
+		case BackendHPA:
+			if c.HPABackground.MinReplicas < 1 || c.HPABackground.MaxReplicas < c.HPABackground.MinReplicas {
+				errs = append(errs, fmt.Errorf("hpa backend needs 1 <= hpa_background min_replicas <= max_replicas, got %d/%d",
+					c.HPABackground.MinReplicas, c.HPABackground.MaxReplicas))
+			}
+		case BackendSidecar:
+			if c.Sidecar.Port <= 0 || c.Sidecar.PausePath == "" || c.Sidecar.ResumePath == "" {
+				errs = append(errs, errors.New("sidecar backend needs sidecar port, pause_path and resume_path"))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("unknown throttle backend %q", backend))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c ThrottleConfig) backendOrDefault(backend string) string {
+	if backend == "" {
+		return BackendResources
+	}
+	return backend
+}
+
+// backendFor returns the throttle backend selected for a deployment
+func (t *Throttler) backendFor(deploymentName string) string {
+	if backend, ok := t.config.Backends[deploymentName]; ok {
+		return t.config.backendOrDefault(backend)
+	}
+	return t.config.backendOrDefault(t.config.DefaultBackend)
+}
+
+// applyPriorityClass gives a deployment's pods the PriorityClass configured
+// for level, or their original one if the level has none
+func (t *Throttler) applyPriorityClass(ctx context.Context, deploymentName, level string) error {
+	deployment, err := t.client.getDeployment(ctx, deploymentName)
+	if err != nil {
+		return err
+	}
+
+	original, ok := deployment.Annotations[AnnotationOriginalPriorityClass]
+	if !ok {
+		original = deployment.Spec.Template.Spec.PriorityClassName
+	}
+	class, ok := t.config.PriorityClasses[level]
+	if !ok {
+		class = original
+	}
+
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				AnnotationThrottleLevel:         level,
+				AnnotationOriginalPriorityClass: original,
+			},
+		},
+	}
+	// Only touch the template when the class changes, since that replaces the pods
+	if class != deployment.Spec.Template.Spec.PriorityClassName {
+		patch["spec"] = priorityClassSpec(class)
+	}
+	return t.patchDeployment(ctx, deploymentName, patch)
+}
+
+// restorePriorityClass gives a deployment's pods back their original PriorityClass
+func (t *Throttler) restorePriorityClass(ctx context.Context, deploymentName string) error {
+	deployment, err := t.client.getDeployment(ctx, deploymentName)
+	if err != nil {
+		return err
+	}
+	original, ok := deployment.Annotations[AnnotationOriginalPriorityClass]
+	if !ok {
+		return fmt.Errorf("no original priority class stored for %s: %w", deploymentName, errNotThrottled)
+	}
+
+	patch := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{
+				AnnotationThrottleLevel:         nil,
+				AnnotationOriginalPriorityClass: nil,
+			},
+		},
+	}
+	if original != deployment.Spec.Template.Spec.PriorityClassName {
+		patch["spec"] = priorityClassSpec(original)
+	}
+	return t.patchDeployment(ctx, deploymentName, patch)
+}
+
+// priorityClassSpec is the deployment spec patch setting the pods' PriorityClass
+func priorityClassSpec(class string) map[string]interface{} {
+	var value interface{} = class
+	if class == "" {
+		value = nil // Remove the field
+	}
+	return map[string]interface{}{
+		"template": map[string]interface{}{
+			"spec": map[string]interface{}{"priorityClassName": value},
+		},
+	}
+}
+
+// applyHPA narrows the replica range of a deployment's autoscaler in the
+// background and gives it back its original range at other levels
+func (t *Throttler) applyHPA(ctx context.Context, deploymentName, level string) error {
+	hpa, err := t.findHPA(ctx, deploymentName)
+	if err != nil {
+		return err
+	}
+
+	original, ok, err := originalReplicas(hpa)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		original = hpaReplicas(hpa)
+	}
+	replicas := original
+	if level == LevelBackground {
+		replicas = t.config.HPABackground
+	}
+
+	data, err := json.Marshal(original)
+	if err != nil {
+		return err
+	}
+	err = t.patchHPA(ctx, hpa.Name, map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{AnnotationOriginalReplicas: string(data)},
+		},
+		"spec": map[string]interface{}{
+			"minReplicas": replicas.MinReplicas,
+			"maxReplicas": replicas.MaxReplicas,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return t.annotateLevel(ctx, deploymentName, level)
+}
+
+// restoreHPA gives a deployment's autoscaler back its original replica range
+func (t *Throttler) restoreHPA(ctx context.Context, deploymentName string) error {
+	hpa, err := t.findHPA(ctx, deploymentName)
+	if err != nil {
+		return err
+	}
+	original, ok, err := originalReplicas(hpa)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("no original replicas stored for %s: %w", hpa.Name, errNotThrottled)
+	}
+
+	err = t.patchHPA(ctx, hpa.Name, map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{AnnotationOriginalReplicas: nil},
+		},
+		"spec": map[string]interface{}{
+			"minReplicas": original.MinReplicas,
+			"maxReplicas": original.MaxReplicas,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return t.annotateLevel(ctx, deploymentName, "")
+}
+
+// findHPA returns the autoscaler that scales a deployment
+func (t *Throttler) findHPA(ctx context.Context, deploymentName string) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	hpaClient := t.client.clientset.AutoscalingV2().HorizontalPodAutoscalers(t.client.namespace)
+
+	var hpas *autoscalingv2.HorizontalPodAutoscalerList
+	err := withRetry(ctx, func() (err error) {
+		hpas, err = hpaClient.List(ctx, metav1.ListOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list autoscalers: %w", err)
+	}
+	for i := range hpas.Items {
+		target := hpas.Items[i].Spec.ScaleTargetRef
+		if target.Kind == "Deployment" && target.Name == deploymentName {
+			return &hpas.Items[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no autoscaler targets deployment %s", deploymentName)
+}
+
+// patchHPA applies a merge patch to an autoscaler
+func (t *Throttler) patchHPA(ctx context.Context, name string, patch map[string]interface{}) error {
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	hpaClient := t.client.clientset.AutoscalingV2().HorizontalPodAutoscalers(t.client.namespace)
+	err = withRetry(ctx, func() error {
+		_, err := hpaClient.Patch(ctx, name, types.MergePatchType, data, metav1.PatchOptions{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to patch autoscaler %s: %w", name, err)
+	}
+	return nil
+}
+
+func hpaReplicas(hpa *autoscalingv2.HorizontalPodAutoscaler) HPAReplicas {
+	replicas := HPAReplicas{MinReplicas: 1, MaxReplicas: hpa.Spec.MaxReplicas}
+	if hpa.Spec.MinReplicas != nil {
+		replicas.MinReplicas = *hpa.Spec.MinReplicas
+	}
+	return replicas
+}
+
+// originalReplicas reads the replica range persisted on an autoscaler
+func originalReplicas(hpa *autoscalingv2.HorizontalPodAutoscaler) (HPAReplicas, bool, error) {
+	data, ok := hpa.Annotations[AnnotationOriginalReplicas]
+	if !ok {
+		return HPAReplicas{}, false, nil
+	}
+	var replicas HPAReplicas
+	if err := json.Unmarshal([]byte(data), &replicas); err != nil {
+		return HPAReplicas{}, false, fmt.Errorf("autoscaler %s has invalid %s annotation: %w", hpa.Name, AnnotationOriginalReplicas, err)
+	}
+	return replicas, true, nil
+}
+
+// applySidecar pauses a deployment's pods in the background and resumes them otherwise
+func (t *Throttler) applySidecar(ctx context.Context, deploymentName, level string) error {
+	deployment, err := t.client.getDeployment(ctx, deploymentName)
+	if err != nil {
+		return err
+	}
+	path := t.config.Sidecar.ResumePath
+	if level == LevelBackground {
+		path = t.config.Sidecar.PausePath
+	}
+	if err := t.callSidecars(ctx, deployment, path); err != nil {
+		return err
+	}
+	return t.annotateLevel(ctx, deploymentName, level)
+}
+
+// restoreSidecar resumes the pods of a deployment the sidecar backend throttled
+func (t *Throttler) restoreSidecar(ctx context.Context, deploymentName string) error {
+	deployment, err := t.client.getDeployment(ctx, deploymentName)
+	if err != nil {
+		return err
+	}
+	if _, ok := deployment.Annotations[AnnotationThrottleLevel]; !ok {
+		return fmt.Errorf("%s is not paused: %w", deploymentName, errNotThrottled)
+	}
+	if err := t.callSidecars(ctx, deployment, t.config.Sidecar.ResumePath); err != nil {
+		return err
+	}
+	return t.annotateLevel(ctx, deploymentName, "")
+}
+
+// callSidecars posts to the sidecar control endpoint of every running pod of a deployment
+func (t *Throttler) callSidecars(ctx context.Context, deployment *appsv1.Deployment, path string) error {
+	pods, err := t.listPods(ctx, deployment)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, pod := range pods {
+		if pod.Status.PodIP == "" {
+			continue // Not scheduled yet; it starts resumed
+		}
+		url := "http://" + pod.Status.PodIP + ":" + strconv.Itoa(t.config.Sidecar.Port) + path
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("pod %s: %w", pod.Name, err))
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			errs = append(errs, fmt.Errorf("pod %s: sidecar returned %s", pod.Name, resp.Status))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// annotateLevel records the level last applied to a deployment; "" clears it
+func (t *Throttler) annotateLevel(ctx context.Context, deploymentName, level string) error {
+	var value interface{} = level
+	if level == "" {
+		value = nil
+	}
+	return t.patchDeployment(ctx, deploymentName, map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]interface{}{AnnotationThrottleLevel: value},
+		},
+	})
+}
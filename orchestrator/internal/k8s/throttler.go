@@ -2,31 +2,122 @@ package k8s
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"sort"
 	"sync"
 
+	"github.com/gavigo/orchestrator/internal/models"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
 )
 
-// ResourceConfig holds CPU and memory resource settings
+// Throttle levels
+const (
+	LevelActive     = "active"
+	LevelWarm       = "warm"
+	LevelBackground = "background"
+)
+
+// Resize policies
+const (
+	ResizeRollout = "rollout"  // Patch the pod template; the deployment rolls its pods
+	ResizeInPlace = "in-place" // Resize running pods without restarting them or changing the pod template (needs InPlacePodVerticalScaling)
+)
+
+// Metadata on managed deployments. The original resources are persisted on
+// the deployment so a restarted orchestrator can still restore them.
+const (
+	LabelManaged                = "gavigo.io/managed"            // "true" on deployments the throttler manages
+	AnnotationThrottleLevel     = "gavigo.io/throttle-level"     // Level last applied
+	AnnotationOriginalResources = "gavigo.io/original-resources" // JSON container -> resources before throttling
+)
+
+// ResourceConfig holds CPU and memory resource settings. Empty fields are
+// left as they are on the container.
 type ResourceConfig struct {
-	CPURequest    string
-	CPULimit      string
-	MemoryRequest string
-	MemoryLimit   string
+	CPURequest    string `json:"cpu_request,omitempty"`
+	CPULimit      string `json:"cpu_limit,omitempty"`
+	MemoryRequest string `json:"memory_request,omitempty"`
+	MemoryLimit   string `json:"memory_limit,omitempty"`
+}
+
+// Requirements parses the config into container resource requirements
+func (c ResourceConfig) Requirements() (corev1.ResourceRequirements, error) {
+	requirements := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{},
+		Limits:   corev1.ResourceList{},
+	}
+	fields := []struct {
+		value string
+		list  corev1.ResourceList
+		name  corev1.ResourceName
+		field string
+	}{
+		{c.CPURequest, requirements.Requests, corev1.ResourceCPU, "cpu_request"},
+		{c.CPULimit, requirements.Limits, corev1.ResourceCPU, "cpu_limit"},
+		{c.MemoryRequest, requirements.Requests, corev1.ResourceMemory, "memory_request"},
+		{c.MemoryLimit, requirements.Limits, corev1.ResourceMemory, "memory_limit"},
+	}
+	for _, f := range fields {
+		if f.value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(f.value)
+		if err != nil {
+			return corev1.ResourceRequirements{}, fmt.Errorf("%s %q: %w", f.field, f.value, err)
+		}
+		if quantity.Sign() < 0 {
+			return corev1.ResourceRequirements{}, fmt.Errorf("%s %q is negative", f.field, f.value)
+		}
+		f.list[f.name] = quantity
+	}
+
+	for name, request := range requirements.Requests {
+		if limit, ok := requirements.Limits[name]; ok && request.Cmp(limit) > 0 {
+			return corev1.ResourceRequirements{}, fmt.Errorf("%s request %s exceeds limit %s", name, request.String(), limit.String())
+		}
+	}
+	return requirements, nil
+}
+
+// ContainerProfile holds the resources of one container at each throttle level
+type ContainerProfile struct {
+	Active     ResourceConfig `json:"active"`
+	Warm       ResourceConfig `json:"warm"`
+	Background ResourceConfig `json:"background"`
 }
 
 // ThrottleConfig defines throttling levels for different modes
 type ThrottleConfig struct {
 	// Full resources for active (foreground) containers
-	ActiveResources ResourceConfig
+	ActiveResources ResourceConfig `json:"active"`
 	// Reduced resources for warm (standby) containers
-	WarmResources ResourceConfig
+	WarmResources ResourceConfig `json:"warm"`
 	// Minimal resources for background (throttled) containers
-	BackgroundResources ResourceConfig
+	BackgroundResources ResourceConfig `json:"background"`
+	// Per-container profiles by container name; other containers use the levels above
+	Containers map[string]ContainerProfile `json:"containers,omitempty"`
+	// ResizeRollout or ResizeInPlace
+	ResizePolicy string `json:"resize_policy,omitempty"`
+
+	// Throttle backend per deployment name; others use DefaultBackend
+	Backends map[string]string `json:"backends,omitempty"`
+	// Backend of deployments not in Backends; BackendResources if empty
+	DefaultBackend string `json:"default_backend,omitempty"`
+	// PriorityClass per level for the priority-class backend; levels without one keep the original
+	PriorityClasses map[string]string `json:"priority_classes,omitempty"`
+	// Autoscaler replica range in the background for the hpa backend
+	HPABackground HPAReplicas `json:"hpa_background"`
+	// Control endpoint for the sidecar backend
+	Sidecar SidecarConfig `json:"sidecar"`
 }
 
 // DefaultThrottleConfig returns the default throttling configuration
@@ -50,160 +141,599 @@ func DefaultThrottleConfig() ThrottleConfig {
 			MemoryRequest: "64Mi",
 			MemoryLimit:   "128Mi",
 		},
+		ResizePolicy:   ResizeRollout,
+		DefaultBackend: BackendResources,
+		HPABackground:  HPAReplicas{MinReplicas: 1, MaxReplicas: 1},
+		Sidecar: SidecarConfig{
+			Port:       9901,
+			PausePath:  "/pause",
+			ResumePath: "/resume",
+		},
+	}
+}
+
+// LoadThrottleConfig reads a JSON throttle config file over the defaults and validates it
+func LoadThrottleConfig(path string) (ThrottleConfig, error) {
+	config := DefaultThrottleConfig()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("failed to read throttle config %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("failed to parse throttle config %s: %w", path, err)
+	}
+	if err := config.Validate(); err != nil {
+		return config, fmt.Errorf("throttle config %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Validate checks that every resource setting parses and that the resize policy
+// and the selected backends are known and configured
+func (c ThrottleConfig) Validate() error {
+	_, err := c.compile()
+	return err
+}
+
+// compile parses every level of every profile. The "" profile holds the
+// levels for containers without their own profile.
+func (c ThrottleConfig) compile() (map[string]map[string]corev1.ResourceRequirements, error) {
+	switch c.ResizePolicy {
+	case "", ResizeRollout, ResizeInPlace:
+	default:
+		return nil, fmt.Errorf("unknown resize policy %q", c.ResizePolicy)
+	}
+	if err := c.validateBackends(); err != nil {
+		return nil, err
+	}
+
+	return compileProfiles(ContainerProfile{Active: c.ActiveResources, Warm: c.WarmResources, Background: c.BackgroundResources}, c.Containers)
+}
+
+// compileProfiles parses every level of a default profile and of per-container
+// profiles. The "" entry of the result holds the default's levels.
+func compileProfiles(defaults ContainerProfile, containers map[string]ContainerProfile) (map[string]map[string]corev1.ResourceRequirements, error) {
+	profiles := map[string]ContainerProfile{"": defaults}
+	for name, profile := range containers {
+		if name == "" {
+			return nil, errors.New("container profile has no name")
+		}
+		profiles[name] = profile
+	}
+
+	var errs []error
+	compiled := make(map[string]map[string]corev1.ResourceRequirements, len(profiles))
+	for name, profile := range profiles {
+		levels := map[string]ResourceConfig{
+			LevelActive:     profile.Active,
+			LevelWarm:       profile.Warm,
+			LevelBackground: profile.Background,
+		}
+		compiled[name] = make(map[string]corev1.ResourceRequirements, len(levels))
+		for level, config := range levels {
+			requirements, err := config.Requirements()
+			if err != nil {
+				if name == "" {
+					errs = append(errs, fmt.Errorf("%s resources: %w", level, err))
+				} else {
+					errs = append(errs, fmt.Errorf("container %s %s resources: %w", name, level, err))
+				}
+				continue
+			}
+			compiled[name][level] = requirements
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
 	}
+	return compiled, nil
 }
 
 // Throttler manages resource throttling for deployments
 type Throttler struct {
-	client         *Client
-	config         ThrottleConfig
-	originalLimits map[string]ResourceConfig
-	mu             sync.RWMutex
+	client             *Client
+	config             ThrottleConfig
+	profiles           map[string]map[string]corev1.ResourceRequirements            // container ("" = default) -> level -> resources
+	deploymentProfiles map[string]map[string]map[string]corev1.ResourceRequirements // deployment -> container ("" = default) -> level -> resources
+	originalLimits     map[string]map[string]corev1.ResourceRequirements            // deployment -> container -> resources before throttling
+	mu                 sync.RWMutex
 }
 
-// NewThrottler creates a new throttler instance
-func NewThrottler(client *Client, config ThrottleConfig) *Throttler {
+// NewThrottler creates a new throttler instance, rejecting a config whose
+// resource settings do not parse
+func NewThrottler(client *Client, config ThrottleConfig) (*Throttler, error) {
+	profiles, err := config.compile()
+	if err != nil {
+		return nil, fmt.Errorf("invalid throttle config: %w", err)
+	}
+	if config.ResizePolicy == "" {
+		config.ResizePolicy = ResizeRollout
+	}
 	return &Throttler{
-		client:         client,
-		config:         config,
-		originalLimits: make(map[string]ResourceConfig),
+		client:             client,
+		config:             config,
+		profiles:           profiles,
+		deploymentProfiles: make(map[string]map[string]map[string]corev1.ResourceRequirements),
+		originalLimits:     make(map[string]map[string]corev1.ResourceRequirements),
+	}, nil
+}
+
+// SetDeploymentResources gives a deployment its own resource profiles, which
+// take precedence over the config's. Nil resources go back to the config's.
+func (t *Throttler) SetDeploymentResources(deploymentName string, resources *ContentResources) error {
+	if resources == nil {
+		t.mu.Lock()
+		delete(t.deploymentProfiles, deploymentName)
+		t.mu.Unlock()
+		return nil
+	}
+	profiles, err := resources.compile()
+	if err != nil {
+		return fmt.Errorf("resources of %s: %w", deploymentName, err)
 	}
+	t.mu.Lock()
+	t.deploymentProfiles[deploymentName] = profiles
+	t.mu.Unlock()
+	return nil
 }
 
-// ThrottleDeployment reduces resources for a specific deployment
+// ThrottleDeployment applies a level to a deployment through its throttle backend
 func (t *Throttler) ThrottleDeployment(ctx context.Context, deploymentName string, level string) error {
-	var config ResourceConfig
-	switch level {
-	case "active":
-		config = t.config.ActiveResources
-	case "warm":
-		config = t.config.WarmResources
-	case "background":
-		config = t.config.BackgroundResources
-	default:
+	if _, ok := t.profiles[""][level]; !ok {
 		return fmt.Errorf("unknown throttle level: %s", level)
 	}
 
-	return t.applyResourceConfig(ctx, deploymentName, config)
+	var err error
+	backend := t.backendFor(deploymentName)
+	switch backend {
+	case BackendPriorityClass:
+		err = t.applyPriorityClass(ctx, deploymentName, level)
+	case BackendHPA:
+		err = t.applyHPA(ctx, deploymentName, level)
+	case BackendSidecar:
+		err = t.applySidecar(ctx, deploymentName, level)
+	default:
+		err = t.applyResources(ctx, deploymentName, level, t.resourcesFor(deploymentName, level))
+		backend += "/" + t.config.ResizePolicy
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("Applied %s throttle level to %s (%s)", level, deploymentName, backend)
+	return nil
 }
 
 // ThrottleForForeground throttles all background workloads when a foreground app is activated
 // activeDeployment: the deployment that should get full resources
 // allDeployments: all workload deployments to manage
+// Every deployment is attempted; the returned error joins the per-deployment failures.
 func (t *Throttler) ThrottleForForeground(ctx context.Context, activeDeployment string, allDeployments []string) error {
 	log.Printf("Throttling for foreground activation: %s", activeDeployment)
 
+	var errs []error
 	for _, deployment := range allDeployments {
 		var level string
 		if deployment == activeDeployment {
-			level = "active"
+			level = LevelActive
 		} else {
 			// Check if deployment has replicas running
 			desired, _, err := t.client.GetDeploymentReplicas(ctx, deployment)
 			if err != nil {
-				log.Printf("Warning: failed to get replicas for %s: %v", deployment, err)
+				errs = append(errs, err)
 				continue
 			}
 			if desired > 0 {
-				level = "background"
+				level = LevelBackground
 			} else {
 				continue // Skip COLD deployments
 			}
 		}
 
 		if err := t.ThrottleDeployment(ctx, deployment, level); err != nil {
-			log.Printf("Warning: failed to throttle %s to %s: %v", deployment, level, err)
+			errs = append(errs, fmt.Errorf("throttle %s to %s: %w", deployment, level, err))
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
-// RestoreResources restores resources when returning to mixed browsing mode
+// RestoreResources undoes the throttling of every deployment that was
+// throttled, giving it back exactly what it had, when returning to mixed
+// browsing mode. Every deployment is attempted; the returned error joins the
+// per-deployment failures.
 func (t *Throttler) RestoreResources(ctx context.Context, deployments []string) error {
 	log.Println("Restoring resources for mixed browsing mode")
 
+	var errs []error
 	for _, deployment := range deployments {
-		desired, _, err := t.client.GetDeploymentReplicas(ctx, deployment)
-		if err != nil {
-			log.Printf("Warning: failed to get replicas for %s: %v", deployment, err)
+		var err error
+		switch t.backendFor(deployment) {
+		case BackendPriorityClass:
+			err = t.restorePriorityClass(ctx, deployment)
+		case BackendHPA:
+			err = t.restoreHPA(ctx, deployment)
+		case BackendSidecar:
+			err = t.restoreSidecar(ctx, deployment)
+		default:
+			err = t.RestoreOriginalLimits(ctx, deployment)
+		}
+		if err != nil && !errors.Is(err, errNotThrottled) {
+			errs = append(errs, fmt.Errorf("restore %s: %w", deployment, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ManagedDeployments returns the names of the deployments labelled for throttling
+func (t *Throttler) ManagedDeployments(ctx context.Context) ([]string, error) {
+	deployments, err := t.listManaged(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(deployments))
+	for _, deployment := range deployments {
+		names = append(names, deployment.Name)
+	}
+	return names, nil
+}
+
+// Drift reports the container resources of managed deployments that differ
+// from the level last applied to them. Only settings the level configures are
+// compared, on deployments throttled by the resources backend.
+func (t *Throttler) Drift(ctx context.Context) ([]models.ResourceDrift, error) {
+	deployments, err := t.listManaged(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	drift := []models.ResourceDrift{}
+	for i := range deployments {
+		deployment := &deployments[i]
+		if t.backendFor(deployment.Name) != BackendResources {
 			continue
 		}
+		level := deployment.Annotations[AnnotationThrottleLevel]
+		if _, ok := t.profiles[""][level]; !ok {
+			continue // Not throttled
+		}
+		expected := t.resourcesFor(deployment.Name, level)
 
-		if desired > 0 {
-			// Restore to warm resources for running deployments
-			if err := t.ThrottleDeployment(ctx, deployment, "warm"); err != nil {
-				log.Printf("Warning: failed to restore %s: %v", deployment, err)
-			}
+		if t.config.ResizePolicy != ResizeInPlace {
+			drift = append(drift, compareResources(deployment.Name, "", level, deployment.Spec.Template.Spec.Containers, expected)...)
+			continue
+		}
+		pods, err := t.listPods(ctx, deployment)
+		if err != nil {
+			return nil, err
+		}
+		for _, pod := range pods {
+			drift = append(drift, compareResources(deployment.Name, pod.Name, level, pod.Spec.Containers, expected)...)
 		}
 	}
+	return drift, nil
+}
 
-	return nil
+// applyResources patches each container of a deployment to the resources
+// chosen for it and records level on the deployment. The resources it had
+// before the first change are remembered and persisted alongside.
+func (t *Throttler) applyResources(ctx context.Context, deploymentName, level string, resourcesFor func(container string) corev1.ResourceRequirements) error {
+	deployment, err := t.client.getDeployment(ctx, deploymentName)
+	if err != nil {
+		return err
+	}
+	originals, err := t.originals(deployment)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(originals)
+	if err != nil {
+		return err
+	}
+
+	containers := make([]map[string]interface{}, 0, len(deployment.Spec.Template.Spec.Containers))
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		containers = append(containers, map[string]interface{}{
+			"name":      container.Name,
+			"resources": resourcesFor(container.Name),
+		})
+	}
+	metadata := map[string]interface{}{
+		"annotations": map[string]string{
+			AnnotationThrottleLevel:     level,
+			AnnotationOriginalResources: string(data),
+		},
+	}
+	return t.patchResources(ctx, deployment, metadata, containers)
+}
+
+// restoreResources replaces each container's resources of a deployment with
+// the originals and clears the throttle annotations, so the next throttle
+// captures the resources as they are then
+func (t *Throttler) restoreResources(ctx context.Context, deployment *appsv1.Deployment, originals map[string]corev1.ResourceRequirements) error {
+	containers := make([]map[string]interface{}, 0, len(deployment.Spec.Template.Spec.Containers))
+	for _, container := range deployment.Spec.Template.Spec.Containers {
+		original, ok := originals[container.Name]
+		if !ok {
+			continue // Added after throttling; it never had other resources
+		}
+		// Replace rather than merge so settings the throttler added are dropped
+		resources := map[string]interface{}{"$patch": "replace"}
+		if len(original.Requests) > 0 {
+			resources["requests"] = original.Requests
+		}
+		if len(original.Limits) > 0 {
+			resources["limits"] = original.Limits
+		}
+		if len(original.Claims) > 0 {
+			resources["claims"] = original.Claims
+		}
+		containers = append(containers, map[string]interface{}{
+			"name":      container.Name,
+			"resources": resources,
+		})
+	}
+	metadata := map[string]interface{}{
+		"annotations": map[string]interface{}{
+			AnnotationThrottleLevel:     nil,
+			AnnotationOriginalResources: nil,
+		},
+	}
+	return t.patchResources(ctx, deployment, metadata, containers)
+}
+
+// patchResources patches container resources into the pod template, or into
+// the running pods when they are resized in place, along with the deployment metadata
+func (t *Throttler) patchResources(ctx context.Context, deployment *appsv1.Deployment, metadata map[string]interface{}, containers []map[string]interface{}) error {
+	podSpec := map[string]interface{}{"containers": containers}
+
+	if t.config.ResizePolicy == ResizeInPlace {
+		if err := t.resizePods(ctx, deployment, podSpec); err != nil {
+			return fmt.Errorf("failed to resize pods of %s: %w", deployment.Name, err)
+		}
+		return t.patchDeployment(ctx, deployment.Name, map[string]interface{}{"metadata": metadata})
+	}
+
+	return t.patchDeployment(ctx, deployment.Name, map[string]interface{}{
+		"metadata": metadata,
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{"spec": podSpec},
+		},
+	})
 }
 
-// applyResourceConfig applies resource limits to a deployment
-func (t *Throttler) applyResourceConfig(ctx context.Context, deploymentName string, config ResourceConfig) error {
+// patchDeployment applies a strategic merge patch to a deployment. Containers
+// are merged by name, so fields other controllers own are left alone.
+func (t *Throttler) patchDeployment(ctx context.Context, deploymentName string, patch map[string]interface{}) error {
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
 	deploymentsClient := t.client.clientset.AppsV1().Deployments(t.client.namespace)
+	err = withRetry(ctx, func() error {
+		_, err := deploymentsClient.Patch(ctx, deploymentName, types.StrategicMergePatchType, data, metav1.PatchOptions{})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to patch deployment %s: %w", deploymentName, err)
+	}
+	return nil
+}
 
-	deployment, err := deploymentsClient.Get(ctx, deploymentName, metav1.GetOptions{})
+// resizePods patches the resources of a deployment's running pods in place
+// through their resize subresource. The pod template is left alone, since
+// changing it would roll the pods; pods the deployment creates later start with
+// the template's resources until the next throttle action, and Drift reports them.
+func (t *Throttler) resizePods(ctx context.Context, deployment *appsv1.Deployment, podSpec map[string]interface{}) error {
+	data, err := json.Marshal(map[string]interface{}{"spec": podSpec})
+	if err != nil {
+		return err
+	}
+	pods, err := t.listPods(ctx, deployment)
 	if err != nil {
-		return fmt.Errorf("failed to get deployment %s: %w", deploymentName, err)
+		return err
 	}
+	podsClient := t.client.clientset.CoreV1().Pods(t.client.namespace)
 
-	// Store original limits if not already stored
-	t.mu.Lock()
-	if _, exists := t.originalLimits[deploymentName]; !exists && len(deployment.Spec.Template.Spec.Containers) > 0 {
-		container := deployment.Spec.Template.Spec.Containers[0]
-		t.originalLimits[deploymentName] = ResourceConfig{
-			CPURequest:    container.Resources.Requests.Cpu().String(),
-			CPULimit:      container.Resources.Limits.Cpu().String(),
-			MemoryRequest: container.Resources.Requests.Memory().String(),
-			MemoryLimit:   container.Resources.Limits.Memory().String(),
+	var errs []error
+	for _, pod := range pods {
+		name := pod.Name
+		err := withRetry(ctx, func() error {
+			_, err := podsClient.Patch(ctx, name, types.StrategicMergePatchType, data, metav1.PatchOptions{}, "resize")
+			return err
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("pod %s: %w", name, err))
 		}
 	}
-	t.mu.Unlock()
+	return errors.Join(errs...)
+}
+
+// listPods lists a deployment's pods that are not shutting down
+func (t *Throttler) listPods(ctx context.Context, deployment *appsv1.Deployment) ([]corev1.Pod, error) {
+	if deployment.Spec.Selector == nil {
+		return nil, fmt.Errorf("deployment %s has no selector", deployment.Name)
+	}
+	podsClient := t.client.clientset.CoreV1().Pods(t.client.namespace)
+
+	var pods *corev1.PodList
+	err := withRetry(ctx, func() (err error) {
+		pods, err = podsClient.List(ctx, metav1.ListOptions{
+			LabelSelector: labels.SelectorFromSet(deployment.Spec.Selector.MatchLabels).String(),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods of %s: %w", deployment.Name, err)
+	}
 
-	// Update container resources
-	if len(deployment.Spec.Template.Spec.Containers) > 0 {
-		deployment.Spec.Template.Spec.Containers[0].Resources = corev1.ResourceRequirements{
-			Requests: corev1.ResourceList{
-				corev1.ResourceCPU:    resource.MustParse(config.CPURequest),
-				corev1.ResourceMemory: resource.MustParse(config.MemoryRequest),
-			},
-			Limits: corev1.ResourceList{
-				corev1.ResourceCPU:    resource.MustParse(config.CPULimit),
-				corev1.ResourceMemory: resource.MustParse(config.MemoryLimit),
-			},
+	running := make([]corev1.Pod, 0, len(pods.Items))
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp == nil {
+			running = append(running, pod)
 		}
 	}
+	return running, nil
+}
+
+// listManaged lists the deployments labelled for throttling, ordered by name
+func (t *Throttler) listManaged(ctx context.Context) ([]appsv1.Deployment, error) {
+	deploymentsClient := t.client.clientset.AppsV1().Deployments(t.client.namespace)
 
-	_, err = deploymentsClient.Update(ctx, deployment, metav1.UpdateOptions{})
+	var deployments *appsv1.DeploymentList
+	err := withRetry(ctx, func() (err error) {
+		deployments, err = deploymentsClient.List(ctx, metav1.ListOptions{
+			LabelSelector: LabelManaged + "=true",
+		})
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("failed to update deployment %s resources: %w", deploymentName, err)
+		return nil, fmt.Errorf("failed to list managed deployments: %w", err)
 	}
 
-	log.Printf("Applied resource config to %s: CPU=%s/%s Memory=%s/%s",
-		deploymentName, config.CPURequest, config.CPULimit, config.MemoryRequest, config.MemoryLimit)
-	return nil
+	items := deployments.Items
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+	return items, nil
+}
+
+// errNotThrottled is returned when restoring a deployment that has no original resources on record
+var errNotThrottled = errors.New("not throttled")
+
+// originals returns a deployment's resources before it was first throttled:
+// those remembered, else those persisted on the deployment, else its current ones
+func (t *Throttler) originals(deployment *appsv1.Deployment) (map[string]corev1.ResourceRequirements, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if originals, exists := t.originalLimits[deployment.Name]; exists {
+		return originals, nil
+	}
+
+	originals, err := persistedOriginals(deployment)
+	if err != nil {
+		return nil, err
+	}
+	if originals == nil {
+		originals = make(map[string]corev1.ResourceRequirements, len(deployment.Spec.Template.Spec.Containers))
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			originals[container.Name] = *container.Resources.DeepCopy()
+		}
+	}
+	t.originalLimits[deployment.Name] = originals
+	return originals, nil
+}
+
+// resourcesFor returns each container's resources of a deployment at a
+// level, from the deployment's own profiles if it has them
+func (t *Throttler) resourcesFor(deploymentName, level string) func(container string) corev1.ResourceRequirements {
+	t.mu.RLock()
+	profiles, ok := t.deploymentProfiles[deploymentName]
+	t.mu.RUnlock()
+	if !ok {
+		profiles = t.profiles
+	}
+	return func(container string) corev1.ResourceRequirements {
+		if profile, ok := profiles[container]; ok {
+			return profile[level]
+		}
+		return profiles[""][level]
+	}
 }
 
-// GetOriginalLimits returns the original resource limits for a deployment
-func (t *Throttler) GetOriginalLimits(deploymentName string) (ResourceConfig, bool) {
+// GetOriginalLimits returns the original resources of each container of a deployment
+func (t *Throttler) GetOriginalLimits(deploymentName string) (map[string]corev1.ResourceRequirements, bool) {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
-	config, exists := t.originalLimits[deploymentName]
-	return config, exists
+	originals, exists := t.originalLimits[deploymentName]
+	return originals, exists
 }
 
-// RestoreOriginalLimits restores the original resource limits for a deployment
+// RestoreOriginalLimits gives a deployment back the exact resources it had
+// before it was first throttled, including after an orchestrator restart
 func (t *Throttler) RestoreOriginalLimits(ctx context.Context, deploymentName string) error {
+	deployment, err := t.client.getDeployment(ctx, deploymentName)
+	if err != nil {
+		return err
+	}
+
 	t.mu.RLock()
-	config, exists := t.originalLimits[deploymentName]
+	originals, exists := t.originalLimits[deploymentName]
 	t.mu.RUnlock()
-
 	if !exists {
-		return fmt.Errorf("no original limits stored for %s", deploymentName)
+		if originals, err = persistedOriginals(deployment); err != nil {
+			return err
+		}
+		if originals == nil {
+			return fmt.Errorf("no original limits stored for %s: %w", deploymentName, errNotThrottled)
+		}
+	}
+
+	if err := t.restoreResources(ctx, deployment, originals); err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	delete(t.originalLimits, deploymentName)
+	t.mu.Unlock()
+	log.Printf("Restored original resources of %s", deploymentName)
+	return nil
+}
+
+// persistedOriginals reads the original resources persisted on a deployment;
+// nil if there are none
+func persistedOriginals(deployment *appsv1.Deployment) (map[string]corev1.ResourceRequirements, error) {
+	data, ok := deployment.Annotations[AnnotationOriginalResources]
+	if !ok {
+		return nil, nil
+	}
+	var originals map[string]corev1.ResourceRequirements
+	if err := json.Unmarshal([]byte(data), &originals); err != nil {
+		return nil, fmt.Errorf("deployment %s has invalid %s annotation: %w", deployment.Name, AnnotationOriginalResources, err)
 	}
+	return originals, nil
+}
 
-	return t.applyResourceConfig(ctx, deploymentName, config)
+// compareResources reports the configured settings of containers that differ from expected
+func compareResources(deploymentName, podName, level string, containers []corev1.Container, expected func(container string) corev1.ResourceRequirements) []models.ResourceDrift {
+	var drift []models.ResourceDrift
+	for _, container := range containers {
+		want := expected(container.Name)
+		lists := []struct {
+			kind   string
+			want   corev1.ResourceList
+			actual corev1.ResourceList
+		}{
+			{"requests", want.Requests, container.Resources.Requests},
+			{"limits", want.Limits, container.Resources.Limits},
+		}
+		for _, list := range lists {
+			names := make([]string, 0, len(list.want))
+			for name := range list.want {
+				names = append(names, string(name))
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				wantQuantity := list.want[corev1.ResourceName(name)]
+				actual, ok := list.actual[corev1.ResourceName(name)]
+				if ok && actual.Cmp(wantQuantity) == 0 {
+					continue
+				}
+				actualValue := ""
+				if ok {
+					actualValue = actual.String()
+				}
+				drift = append(drift, models.ResourceDrift{
+					Deployment: deploymentName,
+					Pod:        podName,
+					Container:  container.Name,
+					Level:      level,
+					Resource:   list.kind + "." + name,
+					Expected:   wantQuantity.String(),
+					Actual:     actualValue,
+				})
+			}
+		}
+	}
+	return drift
 }
@@ -0,0 +1,68 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestInPlaceThrottleResizesPodsThroughSubresource(t *testing.T) {
+	ctx := context.Background()
+	deployment := testDeployment("game-a", 1)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "game-a-1", Namespace: testNamespace, Labels: map[string]string{"app": "game-a"}},
+		Spec:       deployment.Spec.Template.Spec,
+	}
+	clientset := fake.NewSimpleClientset(deployment, pod)
+
+	config := DefaultThrottleConfig()
+	config.ResizePolicy = ResizeInPlace
+	throttler, err := NewThrottler(NewClientForClientset(clientset, testNamespace), config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := throttler.ThrottleDeployment(ctx, "game-a", LevelBackground); err != nil {
+		t.Fatalf("ThrottleDeployment: %v", err)
+	}
+
+	var resized []string
+	for _, action := range clientset.Actions() {
+		patch, ok := action.(k8stesting.PatchAction)
+		if !ok {
+			continue
+		}
+		switch {
+		case patch.GetResource().Resource == "pods" && patch.GetSubresource() == "resize":
+			resized = append(resized, patch.GetName())
+		case patch.GetResource().Resource == "pods":
+			t.Errorf("pod %s patched without the resize subresource", patch.GetName())
+		}
+	}
+	if len(resized) != 1 || resized[0] != "game-a-1" {
+		t.Errorf("resized pods = %v, want game-a-1", resized)
+	}
+
+	// The template keeps its resources and the level is recorded
+	updated, err := clientset.AppsV1().Deployments(testNamespace).Get(ctx, "game-a", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resources := updated.Spec.Template.Spec.Containers[0].Resources; len(resources.Limits) != 0 {
+		t.Errorf("template resources = %v, want them unchanged", resources)
+	}
+	if level := updated.Annotations[AnnotationThrottleLevel]; level != LevelBackground {
+		t.Errorf("throttle level annotation = %q, want %q", level, LevelBackground)
+	}
+	resizedPod, err := clientset.CoreV1().Pods(testNamespace).Get(ctx, "game-a-1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limit := resizedPod.Spec.Containers[0].Resources.Limits[corev1.ResourceCPU]; limit.Cmp(resource.MustParse("100m")) != 0 {
+		t.Errorf("pod CPU limit = %s, want the background 100m", limit.String())
+	}
+}
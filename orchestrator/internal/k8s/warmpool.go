@@ -0,0 +1,266 @@
+package k8s
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Labels and annotations on warm pool pods. A claimed pod is specialized to
+// its content through LabelContent and AnnotationContent; the runtime in the
+// pod watches its own metadata (e.g. via the downward API) to load it.
+const (
+	LabelPool         = "gavigo.io/pool"       // Runtime class the pod was provisioned for
+	LabelPoolState    = "gavigo.io/pool-state" // PoolAvailable or PoolClaimed
+	LabelContent      = "gavigo.io/content"    // Content a claimed pod serves
+	AnnotationContent = "gavigo.io/content-id"
+	AnnotationClaimed = "gavigo.io/claimed-at"
+
+	PoolAvailable = "available"
+	PoolClaimed   = "claimed"
+)
+
+// ErrPoolEmpty is returned when a runtime class has no available pool pods
+var ErrPoolEmpty = errors.New("no available pool pods")
+
+// ErrPoolPodNotClaimed is returned when routing to a pod that is not a claimed pool pod
+var ErrPoolPodNotClaimed = errors.New("not a claimed pool pod")
+
+// WarmPoolConfig holds configuration for the warm pool
+type WarmPoolConfig struct {
+	Templates map[string]string // Runtime class -> deployment whose pod template generic pods use
+}
+
+// ParsePoolTemplates parses "runtime=deployment,..." into a template map
+func ParsePoolTemplates(s string) (map[string]string, error) {
+	templates := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		runtimeClass, deployment, ok := strings.Cut(pair, "=")
+		if !ok || runtimeClass == "" || deployment == "" {
+			return nil, fmt.Errorf("invalid pool template %q: want runtime=deployment", pair)
+		}
+		templates[runtimeClass] = deployment
+	}
+	return templates, nil
+}
+
+// WarmPool keeps generic pods per runtime class running so a cold activation
+// can claim one and specialize it instead of starting a pod from scratch.
+// Claimed pods are deleted on release rather than returned to the pool.
+type WarmPool struct {
+	client *Client
+	config WarmPoolConfig
+}
+
+// NewWarmPool creates a new warm pool
+func NewWarmPool(client *Client, config WarmPoolConfig) *WarmPool {
+	return &WarmPool{
+		client: client,
+		config: config,
+	}
+}
+
+// Resize creates or deletes available pods of a runtime class until it has size of them
+func (p *WarmPool) Resize(ctx context.Context, runtimeClass string, size int) error {
+	templateName, ok := p.config.Templates[runtimeClass]
+	if !ok {
+		return nil // No pool for this runtime class
+	}
+
+	available, err := p.listPods(ctx, runtimeClass, PoolAvailable)
+	if err != nil {
+		return err
+	}
+	podsClient := p.client.clientset.CoreV1().Pods(p.client.namespace)
+
+	var errs []error
+	if len(available) < size {
+		deployment, err := p.client.getDeployment(ctx, templateName)
+		if err != nil {
+			return fmt.Errorf("pool template for %s: %w", runtimeClass, err)
+		}
+		for i := len(available); i < size; i++ {
+			suffix, err := randomHex(4)
+			if err != nil {
+				return err
+			}
+			pod := podFromTemplate(deployment, "pool-"+runtimeClass+"-"+suffix, map[string]string{
+				LabelPool:      runtimeClass,
+				LabelPoolState: PoolAvailable,
+			})
+			pod.Name = strings.ReplaceAll(pod.Name, "_", "-")
+			err = withRetry(ctx, func() error {
+				_, err := podsClient.Create(ctx, pod, metav1.CreateOptions{})
+				return err
+			})
+			if err != nil {
+				errs = append(errs, fmt.Errorf("create pool pod %s: %w", pod.Name, err))
+			}
+		}
+	}
+
+	// Drop the newest surplus pods; older ones are more likely ready
+	for i := len(available) - 1; i >= size; i-- {
+		name := available[i].Name
+		err := withRetry(ctx, func() error {
+			return podsClient.Delete(ctx, name, metav1.DeleteOptions{})
+		})
+		if err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("delete pool pod %s: %w", name, err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	if len(available) != size {
+		log.Printf("Warm pool %s resized from %d to %d available pods", runtimeClass, len(available), size)
+	}
+	return nil
+}
+
+// Claim takes an available pod of a runtime class and specializes it to
+// content. Ready pods are preferred. It returns ErrPoolEmpty if there are none.
+func (p *WarmPool) Claim(ctx context.Context, runtimeClass, contentID string) (string, error) {
+	if _, ok := p.config.Templates[runtimeClass]; !ok {
+		return "", ErrPoolEmpty
+	}
+	podsClient := p.client.clientset.CoreV1().Pods(p.client.namespace)
+
+	var claimed string
+	// A pod claimed concurrently by another activation fails the update with a
+	// conflict, which is retried against a fresh list
+	err := withRetry(ctx, func() error {
+		available, err := p.listPods(ctx, runtimeClass, PoolAvailable)
+		if err != nil {
+			return err
+		}
+		if len(available) == 0 {
+			return ErrPoolEmpty
+		}
+		sort.SliceStable(available, func(i, j int) bool {
+			return podReady(&available[i]) && !podReady(&available[j])
+		})
+
+		pod := available[0].DeepCopy()
+		pod.Labels[LabelPoolState] = PoolClaimed
+		pod.Labels[LabelContent] = contentID
+		if pod.Annotations == nil {
+			pod.Annotations = make(map[string]string)
+		}
+		pod.Annotations[AnnotationContent] = contentID
+		pod.Annotations[AnnotationClaimed] = time.Now().Format(time.RFC3339)
+		if _, err := podsClient.Update(ctx, pod, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+		claimed = pod.Name
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("claim %s pool pod for %s: %w", runtimeClass, contentID, err)
+	}
+
+	log.Printf("Warm pool %s: claimed pod %s for %s", runtimeClass, claimed, contentID)
+	return claimed, nil
+}
+
+// Release deletes the pods claimed for content
+func (p *WarmPool) Release(ctx context.Context, contentID string) error {
+	podsClient := p.client.clientset.CoreV1().Pods(p.client.namespace)
+
+	var pods *corev1.PodList
+	err := withRetry(ctx, func() (err error) {
+		pods, err = podsClient.List(ctx, metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("%s=%s,%s=%s", LabelPoolState, PoolClaimed, LabelContent, contentID),
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("list pool pods of %s: %w", contentID, err)
+	}
+
+	var errs []error
+	for _, pod := range pods.Items {
+		name := pod.Name
+		err := withRetry(ctx, func() error {
+			return podsClient.Delete(ctx, name, metav1.DeleteOptions{})
+		})
+		if err != nil && !apierrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("delete pool pod %s: %w", name, err))
+			continue
+		}
+		log.Printf("Warm pool: released pod %s of %s", name, contentID)
+	}
+	return errors.Join(errs...)
+}
+
+// Route returns the URL a claimed pool pod serves its content at, the
+// endpoint of activations that claimed it. It returns ErrInstanceNotReady
+// until the pod has an IP.
+func (p *WarmPool) Route(ctx context.Context, podName string) (*url.URL, error) {
+	pod, err := p.client.clientset.CoreV1().Pods(p.client.namespace).Get(ctx, podName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, ErrPoolPodNotClaimed
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool pod %s: %w", podName, err)
+	}
+	if pod.Labels[LabelPool] == "" || pod.Labels[LabelPoolState] != PoolClaimed {
+		return nil, ErrPoolPodNotClaimed
+	}
+	target, err := podURL(pod)
+	if err != nil {
+		return nil, fmt.Errorf("pool pod %s: %w", podName, err)
+	}
+	return target, nil
+}
+
+// Available returns how many unclaimed pods a runtime class has
+func (p *WarmPool) Available(ctx context.Context, runtimeClass string) (int, error) {
+	pods, err := p.listPods(ctx, runtimeClass, PoolAvailable)
+	return len(pods), err
+}
+
+// listPods lists a runtime class's pool pods in a state, oldest first
+func (p *WarmPool) listPods(ctx context.Context, runtimeClass, state string) ([]corev1.Pod, error) {
+	podsClient := p.client.clientset.CoreV1().Pods(p.client.namespace)
+
+	var pods *corev1.PodList
+	err := withRetry(ctx, func() (err error) {
+		pods, err = podsClient.List(ctx, metav1.ListOptions{
+			LabelSelector: fmt.Sprintf("%s=%s,%s=%s", LabelPool, runtimeClass, LabelPoolState, state),
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list %s pool pods: %w", runtimeClass, err)
+	}
+
+	items := pods.Items
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].CreationTimestamp.Before(&items[j].CreationTimestamp)
+	})
+	return items, nil
+}
+
+func podReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
@@ -2,92 +2,331 @@ package k8s
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"sort"
 	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
 )
 
 type PodStatus struct {
-	DeploymentName string
-	PodName        string
-	Phase          corev1.PodPhase
-	Ready          bool
-	Timestamp      time.Time
+	DeploymentName string          `json:"deployment_name"`
+	PodName        string          `json:"pod_name"`
+	Phase          corev1.PodPhase `json:"phase"`
+	Ready          bool            `json:"ready"`
+	Restarts       int32           `json:"restarts"`                  // Summed over the pod's containers
+	Failure        string          `json:"failure,omitempty"`         // Why a container cannot run, such as CrashLoopBackOff or OOMKilled
+	FailureMessage string          `json:"failure_message,omitempty"` // Details of the failure from the container status
+	Deleted        bool            `json:"deleted,omitempty"`         // Only in callbacks
+	Timestamp      time.Time       `json:"timestamp"`
 }
 
+// Container waiting reasons that mean the container cannot run
+var failureReasons = map[string]bool{
+	"CrashLoopBackOff":           true,
+	"ImagePullBackOff":           true,
+	"ErrImagePull":               true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+	"CreateContainerError":       true,
+	"RunContainerError":          true,
+}
+
+// oomKilled is the termination reason of a container killed for exceeding its memory limit
+const oomKilled = "OOMKilled"
+
 type PodStatusCallback func(status PodStatus)
 
-// WatchPods watches pods in the namespace and calls the callback on changes
-func (c *Client) WatchPods(ctx context.Context, callback PodStatusCallback) error {
-	podsClient := c.clientset.CoreV1().Pods(c.namespace)
+// watcherSyncTimeout bounds the wait for the watcher's caches to fill
+const watcherSyncTimeout = 30 * time.Second
+
+// DeploymentStatus is the replica state of a watched deployment
+type DeploymentStatus struct {
+	Name              string    `json:"name"`
+	DesiredReplicas   int32     `json:"desired_replicas"`
+	ReadyReplicas     int32     `json:"ready_replicas"`
+	AvailableReplicas int32     `json:"available_replicas"`
+	Deleted           bool      `json:"deleted,omitempty"` // Only in callbacks
+	Timestamp         time.Time `json:"timestamp"`
+}
+
+// WatcherConfig holds configuration for the pod and deployment watcher
+type WatcherConfig struct {
+	PodSelector        string        // Label selector of workload pods
+	DeploymentSelector string        // Label selector of workload deployments; "" watches all
+	DeploymentLabel    string        // Pod label naming the pod's deployment
+	ResyncPeriod       time.Duration // How often every cached pod and deployment is redelivered to the callbacks, so a missed failure is handled; 0 disables
+}
+
+// DefaultWatcherConfig returns the default watcher configuration
+func DefaultWatcherConfig() WatcherConfig {
+	return WatcherConfig{
+		PodSelector:     "type=workload",
+		DeploymentLabel: "app",
+		ResyncPeriod:    5 * time.Minute,
+	}
+}
+
+// Watcher keeps local caches of workload pods and deployments with shared
+// informers. The informers list, then watch from the listed resourceVersion,
+// and relist with backoff when a watch fails, so no change is missed.
+type Watcher struct {
+	config            WatcherConfig
+	podFactory        informers.SharedInformerFactory
+	deploymentFactory informers.SharedInformerFactory
+	pods              cache.SharedIndexInformer
+	deployments       cache.SharedIndexInformer
+
+	// Callback when a workload pod was added, changed or deleted, and for
+	// every cached pod on resync; a status may repeat
+	OnPodStatus PodStatusCallback
+	// Callback when a workload deployment was added, changed or deleted, and
+	// for every cached deployment on resync
+	OnDeploymentStatus func(status DeploymentStatus)
+}
+
+// NewWatcher creates a watcher for a client's namespace. Set the callbacks,
+// then call Start.
+func NewWatcher(client *Client, config WatcherConfig) *Watcher {
+	selecting := func(selector string) informers.SharedInformerOption {
+		return informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+			options.LabelSelector = selector
+		})
+	}
+	w := &Watcher{
+		config: config,
+		podFactory: informers.NewSharedInformerFactoryWithOptions(client.clientset, config.ResyncPeriod,
+			informers.WithNamespace(client.namespace), selecting(config.PodSelector)),
+		deploymentFactory: informers.NewSharedInformerFactoryWithOptions(client.clientset, config.ResyncPeriod,
+			informers.WithNamespace(client.namespace), selecting(config.DeploymentSelector)),
+	}
+	w.pods = w.podFactory.Core().V1().Pods().Informer()
+	w.deployments = w.deploymentFactory.Apps().V1().Deployments().Informer()
+
+	for name, informer := range map[string]cache.SharedIndexInformer{"pod": w.pods, "deployment": w.deployments} {
+		name := name
+		informer.SetWatchErrorHandler(func(_ *cache.Reflector, err error) {
+			log.Printf("Watch of %ss failed, relisting with backoff: %v", name, err)
+		})
+	}
 
-	watcher, err := podsClient.Watch(ctx, metav1.ListOptions{
-		LabelSelector: "type=workload", // Only watch workload pods
+	w.pods.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { w.podChanged(obj, false) },
+		UpdateFunc: func(_, newObj interface{}) { w.podChanged(newObj, false) },
+		DeleteFunc: func(obj interface{}) { w.podChanged(obj, true) },
 	})
-	if err != nil {
-		return err
+	w.deployments.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { w.deploymentChanged(obj, false) },
+		UpdateFunc: func(_, newObj interface{}) { w.deploymentChanged(newObj, false) },
+		DeleteFunc: func(obj interface{}) { w.deploymentChanged(obj, true) },
+	})
+	return w
+}
+
+// Start runs the informers until ctx is done and waits for their caches to
+// fill. If they do not fill in time, the informers keep trying in the background.
+func (w *Watcher) Start(ctx context.Context) error {
+	w.podFactory.Start(ctx.Done())
+	w.deploymentFactory.Start(ctx.Done())
+
+	syncCtx, cancel := context.WithTimeout(ctx, watcherSyncTimeout)
+	defer cancel()
+	if !cache.WaitForCacheSync(syncCtx.Done(), w.pods.HasSynced, w.deployments.HasSynced) {
+		return fmt.Errorf("pod watcher caches did not sync: %w", syncCtx.Err())
+	}
+	log.Printf("Watching pods (%s) and deployments (%s)", selectorOrAll(w.config.PodSelector), selectorOrAll(w.config.DeploymentSelector))
+	return nil
+}
+
+// HasSynced reports whether the watcher's caches have filled
+func (w *Watcher) HasSynced() bool {
+	return w.pods.HasSynced() && w.deployments.HasSynced()
+}
+
+// PodStatuses returns the cached status of every workload pod, ordered by name
+func (w *Watcher) PodStatuses() []PodStatus {
+	statuses := []PodStatus{}
+	for _, obj := range w.pods.GetStore().List() {
+		if status, ok := w.podStatus(obj); ok {
+			statuses = append(statuses, status)
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].PodName < statuses[j].PodName
+	})
+	return statuses
+}
+
+// DeploymentStatuses returns the cached status of every workload deployment, ordered by name
+func (w *Watcher) DeploymentStatuses() []DeploymentStatus {
+	statuses := []DeploymentStatus{}
+	for _, obj := range w.deployments.GetStore().List() {
+		if deployment, ok := obj.(*appsv1.Deployment); ok {
+			statuses = append(statuses, deploymentStatus(deployment))
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+func (w *Watcher) podChanged(obj interface{}, deleted bool) {
+	status, ok := w.podStatus(obj)
+	if !ok || w.OnPodStatus == nil {
+		return
 	}
-	defer watcher.Stop()
-
-	log.Println("Started watching pods in namespace:", c.namespace)
-
-	for {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case event, ok := <-watcher.ResultChan():
-			if !ok {
-				log.Println("Pod watcher channel closed, restarting...")
-				return c.WatchPods(ctx, callback) // Restart watcher
-			}
-
-			if event.Type == watch.Error {
-				log.Printf("Watch error: %v", event.Object)
-				continue
-			}
-
-			pod, ok := event.Object.(*corev1.Pod)
-			if !ok {
-				continue
-			}
-
-			// Extract deployment name from labels
-			deploymentName := pod.Labels["app"]
-			if deploymentName == "" {
-				continue
-			}
-
-			// Check if pod is ready
-			ready := false
-			for _, condition := range pod.Status.Conditions {
-				if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
-					ready = true
-					break
-				}
-			}
-
-			status := PodStatus{
-				DeploymentName: deploymentName,
-				PodName:        pod.Name,
-				Phase:          pod.Status.Phase,
-				Ready:          ready,
-				Timestamp:      time.Now(),
-			}
-
-			callback(status)
+	status.Deleted = deleted
+	w.OnPodStatus(status)
+}
+
+func (w *Watcher) deploymentChanged(obj interface{}, deleted bool) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	deployment, ok := obj.(*appsv1.Deployment)
+	if !ok || w.OnDeploymentStatus == nil {
+		return
+	}
+	status := deploymentStatus(deployment)
+	status.Deleted = deleted
+	w.OnDeploymentStatus(status)
+}
+
+// podStatus reads the status of a workload pod; pods without a deployment label are skipped
+func (w *Watcher) podStatus(obj interface{}) (PodStatus, bool) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return PodStatus{}, false
+	}
+	deploymentName := pod.Labels[w.config.DeploymentLabel]
+	if deploymentName == "" {
+		return PodStatus{}, false
+	}
+	return newPodStatus(deploymentName, pod), true
+}
+
+// newPodStatus reads a pod's readiness, restarts and container failures
+func newPodStatus(deploymentName string, pod *corev1.Pod) PodStatus {
+	status := PodStatus{
+		DeploymentName: deploymentName,
+		PodName:        pod.Name,
+		Phase:          pod.Status.Phase,
+		Ready:          podReady(pod),
+		Timestamp:      time.Now(),
+	}
+	containers := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, container := range containers {
+		status.Restarts += container.RestartCount
+		if status.Failure == "" {
+			status.Failure, status.FailureMessage = containerFailure(container)
+		}
+	}
+	if status.Failure == "" && pod.Status.Phase == corev1.PodFailed {
+		status.Failure, status.FailureMessage = pod.Status.Reason, pod.Status.Message
+		if status.Failure == "" {
+			status.Failure = string(corev1.PodFailed)
 		}
 	}
+	return status
 }
 
-// GetAllPodStatuses returns the current status of all workload pods
+// containerFailure returns why a container cannot run, or "" if it is healthy
+// or still starting. A container killed for memory is reported as OOMKilled
+// while it waits to restart.
+func containerFailure(container corev1.ContainerStatus) (reason, message string) {
+	if terminated := container.State.Terminated; terminated != nil && terminated.Reason == oomKilled {
+		return oomKilled, fmt.Sprintf("container %s exceeded its memory limit", container.Name)
+	}
+	waiting := container.State.Waiting
+	if waiting == nil || !failureReasons[waiting.Reason] {
+		return "", ""
+	}
+	if terminated := container.LastTerminationState.Terminated; terminated != nil && terminated.Reason == oomKilled {
+		return oomKilled, fmt.Sprintf("container %s exceeded its memory limit (%d restarts)", container.Name, container.RestartCount)
+	}
+	message = fmt.Sprintf("container %s is in %s", container.Name, waiting.Reason)
+	if waiting.Message != "" {
+		message += ": " + waiting.Message
+	}
+	return waiting.Reason, message
+}
+
+func deploymentStatus(deployment *appsv1.Deployment) DeploymentStatus {
+	desired := int32(0)
+	if deployment.Spec.Replicas != nil {
+		desired = *deployment.Spec.Replicas
+	}
+	return DeploymentStatus{
+		Name:              deployment.Name,
+		DesiredReplicas:   desired,
+		ReadyReplicas:     deployment.Status.ReadyReplicas,
+		AvailableReplicas: deployment.Status.AvailableReplicas,
+		Timestamp:         time.Now(),
+	}
+}
+
+func selectorOrAll(selector string) string {
+	if selector == "" {
+		return "all"
+	}
+	return selector
+}
+
+// StartWatcher starts a watcher created with NewWatcher, whose caches back
+// GetAllPodStatuses once they have filled. Set its callbacks first. It returns
+// once the caches are filled; the watcher runs until ctx is done, and keeps
+// trying to fill them if it times out.
+func (c *Client) StartWatcher(ctx context.Context, w *Watcher) error {
+	c.watcherMu.Lock()
+	c.watcher = w
+	c.watcherMu.Unlock()
+	return w.Start(ctx)
+}
+
+// WatchPods watches workload pods in the namespace and calls the callback on
+// changes until ctx is done
+func (c *Client) WatchPods(ctx context.Context, callback PodStatusCallback) error {
+	w := NewWatcher(c, DefaultWatcherConfig())
+	w.OnPodStatus = callback
+	if err := w.Start(ctx); err != nil {
+		return err
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// GetAllPodStatuses returns the current status of all workload pods, from the
+// watcher's cache once it has filled. Until then the pods are listed with the
+// watcher's selector.
 func (c *Client) GetAllPodStatuses(ctx context.Context) ([]PodStatus, error) {
+	c.watcherMu.Lock()
+	w := c.watcher
+	c.watcherMu.Unlock()
+
+	config := DefaultWatcherConfig()
+	if w != nil {
+		if w.HasSynced() {
+			return w.PodStatuses(), nil
+		}
+		config = w.config
+	}
 	podsClient := c.clientset.CoreV1().Pods(c.namespace)
 
-	pods, err := podsClient.List(ctx, metav1.ListOptions{
-		LabelSelector: "type=workload",
+	var pods *corev1.PodList
+	err := withRetry(ctx, func() (err error) {
+		pods, err = podsClient.List(ctx, metav1.ListOptions{
+			LabelSelector: config.PodSelector,
+		})
+		return err
 	})
 	if err != nil {
 		return nil, err
@@ -95,26 +334,11 @@ func (c *Client) GetAllPodStatuses(ctx context.Context) ([]PodStatus, error) {
 
 	statuses := make([]PodStatus, 0, len(pods.Items))
 	for _, pod := range pods.Items {
-		deploymentName := pod.Labels["app"]
+		deploymentName := pod.Labels[config.DeploymentLabel]
 		if deploymentName == "" {
 			continue
 		}
-
-		ready := false
-		for _, condition := range pod.Status.Conditions {
-			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
-				ready = true
-				break
-			}
-		}
-
-		statuses = append(statuses, PodStatus{
-			DeploymentName: deploymentName,
-			PodName:        pod.Name,
-			Phase:          pod.Status.Phase,
-			Ready:          ready,
-			Timestamp:      time.Now(),
-		})
+		statuses = append(statuses, newPodStatus(deploymentName, &pod))
 	}
 
 	return statuses, nil
@@ -0,0 +1,236 @@
+package k8s
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// testPod returns a workload pod of a deployment
+func testPod(name, deployment, resourceVersion string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       testNamespace,
+			ResourceVersion: resourceVersion,
+			Labels:          map[string]string{"type": "workload", "app": deployment},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodPending},
+	}
+}
+
+// podEvents records pod callbacks
+type podEvents struct {
+	mu     sync.Mutex
+	events []PodStatus
+}
+
+func (e *podEvents) record(status PodStatus) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, status)
+}
+
+// wait returns the recorded callbacks once there are at least n
+func (e *podEvents) wait(t *testing.T, n int) []PodStatus {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		e.mu.Lock()
+		events := append([]PodStatus(nil), e.events...)
+		e.mu.Unlock()
+		if len(events) >= n || time.Now().After(deadline) {
+			if len(events) < n {
+				t.Fatalf("%d pod callbacks, want %d: %+v", len(events), n, events)
+			}
+			return events
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// watchStarts signals each pod watch the watcher sets up
+func watchStarts(clientset *fake.Clientset) chan struct{} {
+	watching := make(chan struct{}, 16)
+	clientset.PrependWatchReactor("pods", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		watching <- struct{}{}
+		return false, nil, nil
+	})
+	return watching
+}
+
+func startTestWatcher(t *testing.T, client *Client) (*Watcher, *podEvents) {
+	t.Helper()
+	return startTestWatcherResyncing(t, client, 0)
+}
+
+// startTestWatcherResyncing starts a watcher resyncing its caches every period
+func startTestWatcherResyncing(t *testing.T, client *Client, period time.Duration) (*Watcher, *podEvents) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	config := DefaultWatcherConfig()
+	config.ResyncPeriod = period
+	w := NewWatcher(client, config)
+	events := &podEvents{}
+	w.OnPodStatus = events.record
+	if err := client.StartWatcher(ctx, w); err != nil {
+		t.Fatalf("StartWatcher: %v", err)
+	}
+	return w, events
+}
+
+func TestWatcherPodCallbacks(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset(testPod("game-a-1", "game-a", "1"))
+	watching := watchStarts(clientset)
+	client := NewClientForClientset(clientset, testNamespace)
+	_, events := startTestWatcher(t, client)
+	<-watching // Changes before the watch starts would be lost by the fake
+
+	pods := clientset.CoreV1().Pods(testNamespace)
+	pod := testPod("game-a-1", "game-a", "2")
+	pod.Status.Phase = corev1.PodRunning
+	pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "game", RestartCount: 2}}
+	if _, err := pods.Update(ctx, pod, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := pods.Delete(ctx, "game-a-1", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	// Pods without the deployment label are skipped
+	unlabelled := testPod("stray", "", "1")
+	delete(unlabelled.Labels, "app")
+	if _, err := pods.Create(ctx, unlabelled, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pods.Create(ctx, testPod("game-b-1", "game-b", "1"), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := events.wait(t, 4)
+	want := []struct {
+		pod, deployment string
+		ready, deleted  bool
+		restarts        int32
+	}{
+		{"game-a-1", "game-a", false, false, 0}, // Listed
+		{"game-a-1", "game-a", true, false, 2},  // Updated
+		{"game-a-1", "game-a", true, true, 2},   // Deleted
+		{"game-b-1", "game-b", false, false, 0}, // Added
+	}
+	if len(got) != len(want) {
+		t.Fatalf("callbacks = %+v, want %d", got, len(want))
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.PodName != w.pod || g.DeploymentName != w.deployment || g.Ready != w.ready || g.Deleted != w.deleted || g.Restarts != w.restarts {
+			t.Errorf("callback %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
+
+func TestWatcherResyncRedeliversPodStatus(t *testing.T) {
+	pod := testPod("game-a-1", "game-a", "1")
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name:  "game",
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+	}}
+	client := NewClientForClientset(fake.NewSimpleClientset(pod), testNamespace)
+	_, events := startTestWatcherResyncing(t, client, time.Second)
+
+	// The unchanged failing pod is reported again on every resync
+	for i, status := range events.wait(t, 3)[:3] {
+		if status.PodName != "game-a-1" || status.Failure != "CrashLoopBackOff" {
+			t.Errorf("callback %d = %+v, want game-a-1 in CrashLoopBackOff", i, status)
+		}
+	}
+}
+
+func TestWatcherRelistsAfterWatchError(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset(testPod("game-a-1", "game-a", "1"))
+
+	// The first watch is ours to break; later ones reach the fake's tracker
+	broken := watch.NewFake()
+	var first atomic.Bool
+	clientset.PrependWatchReactor("pods", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		if first.CompareAndSwap(false, true) {
+			return true, broken, nil
+		}
+		return false, nil, nil
+	})
+	var lists atomic.Int32
+	clientset.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		lists.Add(1)
+		return false, nil, nil
+	})
+
+	client := NewClientForClientset(clientset, testNamespace)
+	_, events := startTestWatcher(t, client)
+	events.wait(t, 1)
+
+	// Created while the watch is broken, so only a relist can find it
+	if _, err := clientset.CoreV1().Pods(testNamespace).Create(ctx, testPod("game-b-1", "game-b", "1"), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	broken.Error(&metav1.Status{Status: metav1.StatusFailure, Code: 410, Reason: metav1.StatusReasonExpired, Message: "too old resource version"})
+
+	// The relist reports game-a-1 again and adds game-b-1
+	got := events.wait(t, 3)
+	added := false
+	for _, status := range got[1:] {
+		added = added || status.PodName == "game-b-1"
+	}
+	if !added {
+		t.Errorf("callbacks after the watch error = %+v, want game-b-1 added", got[1:])
+	}
+	if lists.Load() < 2 {
+		t.Errorf("pods listed %d times, want a relist", lists.Load())
+	}
+}
+
+func TestGetAllPodStatusesUsesCacheOnceSynced(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset(
+		testPod("game-a-1", "game-a", "1"),
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "game-c-1", Namespace: testNamespace, Labels: map[string]string{"tier": "game", "app": "game-c"}}},
+	)
+	var lists atomic.Int32
+	clientset.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		lists.Add(1)
+		return false, nil, nil
+	})
+	client := NewClientForClientset(clientset, testNamespace)
+
+	// Before the watcher syncs, pods are listed with its selector
+	config := DefaultWatcherConfig()
+	config.PodSelector = "tier=game"
+	client.watcher = NewWatcher(client, config)
+	statuses, err := client.GetAllPodStatuses(ctx)
+	if err != nil || len(statuses) != 1 || statuses[0].PodName != "game-c-1" {
+		t.Errorf("unsynced statuses = %+v, %v; want game-c-1 listed with the watcher's selector", statuses, err)
+	}
+
+	// Once synced, the cache answers without listing
+	startTestWatcher(t, client)
+	before := lists.Load()
+	statuses, err = client.GetAllPodStatuses(ctx)
+	if err != nil || len(statuses) != 1 || statuses[0].PodName != "game-a-1" {
+		t.Errorf("cached statuses = %+v, %v; want game-a-1", statuses, err)
+	}
+	if lists.Load() != before {
+		t.Error("GetAllPodStatuses listed pods with a synced watcher")
+	}
+}
@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// Activation is sent to a session when its content is HOT and ready to use
+type Activation struct {
+	ContentID    string          `json:"content_id"`
+	EndpointURL  string          `json:"endpoint_url"`
+	Status       ContainerStatus `json:"status"`
+	InstanceID   string          `json:"instance_id,omitempty"`   // Set when the session has its own workload instance
+	SessionToken string          `json:"session_token,omitempty"` // Authenticates the session to its instance
+}
+
+// ActivationQueued tells a session its activation is waiting for HOT capacity
+type ActivationQueued struct {
+	ContentID   string `json:"content_id"`
+	Position    int    `json:"position"` // 1-based place in the queue
+	QueueLength int    `json:"queue_length"`
+	EtaMs       int64  `json:"eta_ms"` // Estimated wait until admission
+}
+
+// ActivationCancelled tells a session a queued activation will not be admitted
+type ActivationCancelled struct {
+	ContentID string `json:"content_id"`
+	Reason    string `json:"reason"`
+}
+
+// PodFailure is a workload pod whose container cannot run
+type PodFailure struct {
+	Deployment string
+	Pod        string
+	Reason     string // Such as CrashLoopBackOff, ImagePullBackOff or OOMKilled
+	Message    string
+	Restarts   int32
+}
+
+// ActivationFailed tells clients a content item's workload failed and which
+// fallback the orchestrator chose
+type ActivationFailed struct {
+	ContentID string     `json:"content_id"`
+	Reason    string     `json:"reason"`
+	Message   string     `json:"message,omitempty"`
+	PodName   string     `json:"pod_name,omitempty"`
+	Restarts  int32      `json:"restarts"`
+	Fallback  ActionType `json:"fallback"`              // RETRY_WARM, COOL_DOWN or MARK_UNAVAILABLE
+	Attempt   int        `json:"attempt,omitempty"`     // Retry number for RETRY_WARM
+	RetryInMs int64      `json:"retry_in_ms,omitempty"` // Wait before the retry for RETRY_WARM
+}
+
+// Instance modes
+const (
+	InstanceDedicated = "dedicated" // A pod of its own
+	InstancePooled    = "pooled"    // A slot in a pod of the shared deployment
+)
+
+// WorkloadInstance is a session's own instance of a content item's workload
+type WorkloadInstance struct {
+	ID           string    `json:"id"`
+	SessionID    string    `json:"session_id"`
+	ContentID    string    `json:"content_id"`
+	Deployment   string    `json:"deployment"`
+	Mode         string    `json:"mode"`
+	PodName      string    `json:"pod_name,omitempty"` // Dedicated pod, or the pod a pooled slot is bound to
+	Slot         int       `json:"slot"`               // Pooled slot on PodName; -1 until bound
+	EndpointURL  string    `json:"endpoint_url"`
+	SessionToken string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,425 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+var (
+	// ErrContentNotFound is returned when a content ID is not in the catalog
+	ErrContentNotFound = errors.New("content not found")
+	// ErrContentExists is returned when adding a content ID that is already in the catalog
+	ErrContentExists = errors.New("content already exists")
+)
+
+// ValidationError describes an invalid field on a content item
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Message)
+}
+
+var (
+	contentIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}[a-z0-9]$`)
+	themePattern     = regexp.MustCompile(`^[a-z][a-z0-9-]{0,31}$`)
+)
+
+// ValidateContentItem checks IDs, types, themes and deployment names
+func ValidateContentItem(item *ContentItem) error {
+	if !contentIDPattern.MatchString(item.ID) {
+		return &ValidationError{"id", "must be 2-64 lowercase letters, digits or hyphens"}
+	}
+
+	switch item.Type {
+	case ContentTypeGame, ContentTypeAIService, ContentTypeVideo:
+	default:
+		return &ValidationError{"type", fmt.Sprintf("unknown content type %q", item.Type)}
+	}
+
+	if !themePattern.MatchString(item.Theme) {
+		return &ValidationError{"theme", "must be 1-32 lowercase letters, digits or hyphens"}
+	}
+
+	if strings.TrimSpace(item.Title) == "" {
+		return &ValidationError{"title", "is required"}
+	}
+
+	if item.Type.UsesWorkload() {
+		if errs := validation.IsDNS1123Label(item.DeploymentName); len(errs) > 0 {
+			return &ValidationError{"deployment_name", strings.Join(errs, "; ")}
+		}
+	} else {
+		if item.DeploymentName != "" {
+			return &ValidationError{"deployment_name", "must be empty for " + string(item.Type)}
+		}
+		if item.MediaURL == "" {
+			return &ValidationError{"media_url", "is required for " + string(item.Type)}
+		}
+	}
+
+	return nil
+}
+
+// normalizeCatalogItem clears runtime fields that are not part of the catalog definition
+func normalizeCatalogItem(item ContentItem) ContentItem {
+	item.ContainerStatus = StatusCold
+	item.PersonalScore = 0
+	item.GlobalScore = 0
+	item.CombinedScore = 0
+	return item
+}
+
+// CatalogSource loads content definitions for the catalog
+type CatalogSource interface {
+	Load() ([]ContentItem, error)
+}
+
+// CatalogWriter is a CatalogSource that can persist catalog changes
+type CatalogWriter interface {
+	CatalogSource
+	Save(items []ContentItem) error
+}
+
+// EmbeddedSource serves the content compiled into the binary
+type EmbeddedSource struct{}
+
+// Load returns the embedded default content
+func (EmbeddedSource) Load() ([]ContentItem, error) {
+	return DefaultContent(), nil
+}
+
+// FileSource reads content definitions from a JSON array on disk
+type FileSource struct {
+	Path string
+}
+
+// Load reads and parses the catalog file
+func (s *FileSource) Load() ([]ContentItem, error) {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog file %s: %w", s.Path, err)
+	}
+	var items []ContentItem
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("failed to parse catalog file %s: %w", s.Path, err)
+	}
+	return items, nil
+}
+
+// Save atomically writes the catalog file
+func (s *FileSource) Save(items []ContentItem) error {
+	data, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.Path), ".catalog-*.json")
+	if err != nil {
+		return fmt.Errorf("failed to write catalog file %s: %w", s.Path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write catalog file %s: %w", s.Path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write catalog file %s: %w", s.Path, err)
+	}
+	return os.Rename(tmp.Name(), s.Path)
+}
+
+// ModTime returns the catalog file's modification time
+func (s *FileSource) ModTime() (time.Time, error) {
+	info, err := os.Stat(s.Path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// ContentCatalog is the runtime-managed set of content definitions
+type ContentCatalog struct {
+	mu     sync.RWMutex
+	items  []ContentItem
+	source CatalogSource
+	stopCh chan struct{} // Closed to stop auto-reload; guarded by mu
+
+	// Serializes Add, Update, Remove and Reload, including their callbacks, so a
+	// reload cannot drop a change made while the source was loading
+	writeMu sync.Mutex
+
+	// Change callbacks (called without the catalog lock held)
+	OnContentAdded   func(item ContentItem)
+	OnContentUpdated func(item ContentItem)
+	OnContentRemoved func(contentID string)
+	OnReloadError    func(err error)
+}
+
+// NewContentCatalog creates a catalog and loads it from source (embedded content if nil)
+func NewContentCatalog(source CatalogSource) (*ContentCatalog, error) {
+	if source == nil {
+		source = EmbeddedSource{}
+	}
+	c := &ContentCatalog{source: source}
+
+	items, err := c.loadValidated()
+	if err != nil {
+		return nil, err
+	}
+	c.items = items
+	return c, nil
+}
+
+// loadValidated loads items from the source and rejects invalid or duplicate entries
+func (c *ContentCatalog) loadValidated() ([]ContentItem, error) {
+	loaded, err := c.source.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(loaded))
+	items := make([]ContentItem, 0, len(loaded))
+	for _, item := range loaded {
+		if err := ValidateContentItem(&item); err != nil {
+			return nil, fmt.Errorf("catalog item %q: %w", item.ID, err)
+		}
+		if seen[item.ID] {
+			return nil, fmt.Errorf("catalog item %q: %w", item.ID, ErrContentExists)
+		}
+		seen[item.ID] = true
+		items = append(items, normalizeCatalogItem(item))
+	}
+	return items, nil
+}
+
+// List returns a copy of all catalog items in order
+func (c *ContentCatalog) List() []ContentItem {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cloneItems()
+}
+
+// Get returns a copy of a catalog item
+func (c *ContentCatalog) Get(contentID string) (ContentItem, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if i := c.indexOf(contentID); i >= 0 {
+		return c.items[i], true
+	}
+	return ContentItem{}, false
+}
+
+// indexOf returns the position of a content ID, or -1. Caller must hold c.mu.
+func (c *ContentCatalog) indexOf(contentID string) int {
+	for i := range c.items {
+		if c.items[i].ID == contentID {
+			return i
+		}
+	}
+	return -1
+}
+
+// Add validates and appends a new item
+func (c *ContentCatalog) Add(item ContentItem) (ContentItem, error) {
+	if err := ValidateContentItem(&item); err != nil {
+		return ContentItem{}, err
+	}
+	item = normalizeCatalogItem(item)
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.mu.Lock()
+	if c.indexOf(item.ID) >= 0 {
+		c.mu.Unlock()
+		return ContentItem{}, ErrContentExists
+	}
+	err := c.commit(append(c.cloneItems(), item))
+	c.mu.Unlock()
+	if err != nil {
+		return ContentItem{}, err
+	}
+
+	if c.OnContentAdded != nil {
+		c.OnContentAdded(item)
+	}
+	return item, nil
+}
+
+// Update validates and replaces an existing item
+func (c *ContentCatalog) Update(item ContentItem) (ContentItem, error) {
+	if err := ValidateContentItem(&item); err != nil {
+		return ContentItem{}, err
+	}
+	item = normalizeCatalogItem(item)
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.mu.Lock()
+	i := c.indexOf(item.ID)
+	if i < 0 {
+		c.mu.Unlock()
+		return ContentItem{}, ErrContentNotFound
+	}
+	items := c.cloneItems()
+	items[i] = item
+	err := c.commit(items)
+	c.mu.Unlock()
+	if err != nil {
+		return ContentItem{}, err
+	}
+
+	if c.OnContentUpdated != nil {
+		c.OnContentUpdated(item)
+	}
+	return item, nil
+}
+
+// Remove deletes an item from the catalog
+func (c *ContentCatalog) Remove(contentID string) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.mu.Lock()
+	i := c.indexOf(contentID)
+	if i < 0 {
+		c.mu.Unlock()
+		return ErrContentNotFound
+	}
+	items := c.cloneItems()
+	err := c.commit(append(items[:i], items[i+1:]...))
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if c.OnContentRemoved != nil {
+		c.OnContentRemoved(contentID)
+	}
+	return nil
+}
+
+// cloneItems returns a copy of the items. Caller must hold c.mu.
+func (c *ContentCatalog) cloneItems() []ContentItem {
+	items := make([]ContentItem, len(c.items), len(c.items)+1)
+	copy(items, c.items)
+	return items
+}
+
+// commit saves items if the source is writable and only then makes them current,
+// so a failed write leaves the catalog unchanged. Caller must hold c.mu.
+func (c *ContentCatalog) commit(items []ContentItem) error {
+	if writer, ok := c.source.(CatalogWriter); ok {
+		if err := writer.Save(items); err != nil {
+			return err
+		}
+	}
+	c.items = items
+	return nil
+}
+
+// Reload re-reads the source and applies the differences, firing change callbacks.
+// The current catalog is kept if the source fails to load or validate.
+func (c *ContentCatalog) Reload() (added, updated, removed int, err error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	items, err := c.loadValidated()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	c.mu.Lock()
+	previous := make(map[string]ContentItem, len(c.items))
+	for _, item := range c.items {
+		previous[item.ID] = item
+	}
+	c.items = items
+	c.mu.Unlock()
+
+	for _, item := range items {
+		old, existed := previous[item.ID]
+		delete(previous, item.ID)
+		switch {
+		case !existed:
+			added++
+			if c.OnContentAdded != nil {
+				c.OnContentAdded(item)
+			}
+		case old != item:
+			updated++
+			if c.OnContentUpdated != nil {
+				c.OnContentUpdated(item)
+			}
+		}
+	}
+	for id := range previous {
+		removed++
+		if c.OnContentRemoved != nil {
+			c.OnContentRemoved(id)
+		}
+	}
+	return added, updated, removed, nil
+}
+
+// modTimeSource is a CatalogSource that can report when it last changed
+type modTimeSource interface {
+	ModTime() (time.Time, error)
+}
+
+// StartAutoReload polls the source every interval and reloads when it changes.
+// It does nothing if the source cannot report modification times.
+func (c *ContentCatalog) StartAutoReload(interval time.Duration) {
+	src, ok := c.source.(modTimeSource)
+	if !ok || interval <= 0 {
+		return
+	}
+	c.mu.Lock()
+	if c.stopCh != nil {
+		c.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	c.stopCh = stop
+	c.mu.Unlock()
+	lastMod, _ := src.ModTime()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				mod, err := src.ModTime()
+				if err != nil || mod.Equal(lastMod) {
+					continue
+				}
+				lastMod = mod
+				if _, _, _, err := c.Reload(); err != nil && c.OnReloadError != nil {
+					c.OnReloadError(err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// StopAutoReload stops polling the source
+func (c *ContentCatalog) StopAutoReload() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopCh != nil {
+		close(c.stopCh)
+		c.stopCh = nil
+	}
+}
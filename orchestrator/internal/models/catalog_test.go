@@ -0,0 +1,119 @@
+package models
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// memSource is a writable in-memory catalog source whose Load can be held
+type memSource struct {
+	mu    sync.Mutex
+	items []ContentItem
+	mod   time.Time
+	hold  chan struct{} // Load blocks until closed, when set
+}
+
+func (s *memSource) Load() ([]ContentItem, error) {
+	s.mu.Lock()
+	hold := s.hold
+	items := append([]ContentItem(nil), s.items...)
+	s.mu.Unlock()
+	if hold != nil {
+		<-hold
+	}
+	return items, nil
+}
+
+func (s *memSource) Save(items []ContentItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = append([]ContentItem(nil), items...)
+	s.mod = s.mod.Add(time.Second)
+	return nil
+}
+
+func (s *memSource) ModTime() (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mod, nil
+}
+
+func testGame(id string) ContentItem {
+	return ContentItem{ID: id, Type: ContentTypeGame, Theme: "puzzle", Title: id, DeploymentName: id}
+}
+
+func TestCatalogReloadKeepsConcurrentAdd(t *testing.T) {
+	source := &memSource{items: []ContentItem{testGame("game-a")}}
+	catalog, err := NewContentCatalog(source)
+	if err != nil {
+		t.Fatalf("NewContentCatalog: %v", err)
+	}
+
+	hold := make(chan struct{})
+	source.mu.Lock()
+	source.hold = hold
+	source.mu.Unlock()
+
+	reloaded := make(chan error)
+	go func() {
+		_, _, _, err := catalog.Reload()
+		reloaded <- err
+	}()
+	added := make(chan error)
+	go func() {
+		time.Sleep(10 * time.Millisecond) // Let the reload start loading
+		source.mu.Lock()
+		source.hold = nil
+		source.mu.Unlock()
+		_, err := catalog.Add(testGame("game-b"))
+		added <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	close(hold)
+	if err := <-reloaded; err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if err := <-added; err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if _, ok := catalog.Get("game-b"); !ok {
+		t.Errorf("item added during a reload was lost: %v", catalog.List())
+	}
+}
+
+func TestCatalogAutoReloadStartStop(t *testing.T) {
+	source := &memSource{items: []ContentItem{testGame("game-a")}}
+	catalog, err := NewContentCatalog(source)
+	if err != nil {
+		t.Fatalf("NewContentCatalog: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			catalog.StartAutoReload(time.Millisecond)
+			catalog.StopAutoReload()
+		}()
+	}
+	wg.Wait()
+
+	// Nothing may reload after Stop
+	var mu sync.Mutex
+	reloads := 0
+	catalog.OnContentAdded = func(ContentItem) {
+		mu.Lock()
+		reloads++
+		mu.Unlock()
+	}
+	source.Save([]ContentItem{testGame("game-a"), testGame("game-c")})
+	time.Sleep(20 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if reloads != 0 {
+		t.Errorf("catalog reloaded %d times after StopAutoReload", reloads)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	_ "embed"
 	"encoding/json"
 	"log"
+	"strings"
 )
 
 type ContentType string
@@ -11,14 +12,26 @@ type ContentType string
 const (
 	ContentTypeGame      ContentType = "GAME"
 	ContentTypeAIService ContentType = "AI_SERVICE"
+	ContentTypeVideo     ContentType = "VIDEO"
 )
 
+// UsesWorkload reports whether content of this type runs in a pod. Videos are
+// warmed by prefetching media instead of scaling a deployment.
+func (t ContentType) UsesWorkload() bool {
+	return t != ContentTypeVideo
+}
+
 type ContainerStatus string
 
 const (
 	StatusCold ContainerStatus = "COLD"
 	StatusWarm ContainerStatus = "WARM"
 	StatusHot  ContainerStatus = "HOT"
+
+	// Intermediate and error states (see ContainerStateMachine)
+	StatusWarming ContainerStatus = "WARMING" // Scaling up, preview not ready yet
+	StatusCooling ContainerStatus = "COOLING" // Scaling down
+	StatusFailed  ContainerStatus = "FAILED"  // Workload could not be started
 )
 
 type ContentItem struct {
@@ -33,11 +46,26 @@ type ContentItem struct {
 	PersonalScore   float64         `json:"personal_score"`
 	GlobalScore     float64         `json:"global_score"`
 	CombinedScore   float64         `json:"combined_score"`
+	MediaURL        string          `json:"media_url,omitempty"`     // VIDEO only: source to prefetch
+	DurationSec     int             `json:"duration_sec,omitempty"`  // VIDEO only
+	RuntimeClass    string          `json:"runtime_class,omitempty"` // Warm pool the workload can be served from; defaults by type
+}
+
+// Runtime returns the runtime class of a content item's workload: its
+// RuntimeClass, or one per content type when unset
+func (c *ContentItem) Runtime() string {
+	if c.RuntimeClass != "" {
+		return c.RuntimeClass
+	}
+	return strings.ToLower(string(c.Type))
 }
 
 //go:embed games.json
 var gamesJSON []byte
 
+//go:embed defaultVideos.json
+var videosJSON []byte
+
 // Parsed shared game data structures
 type gameEntry struct {
 	ID          string `json:"id"`
@@ -48,16 +76,32 @@ type gameEntry struct {
 }
 
 type studioEntry struct {
+	Name  string      `json:"name"`
 	Games []gameEntry `json:"games"`
 }
 
+// Parsed shared video catalogue (mirrors the Supabase videos table)
+type videoEntry struct {
+	ID           string `json:"id"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	Theme        string `json:"theme"`
+	VideoURL     string `json:"video_url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	Duration     int    `json:"duration"`
+	IsActive     bool   `json:"is_active"`
+}
+
 type gamesData struct {
 	Studios              []studioEntry     `json:"studios"`
 	CrossDomainRelations map[string]string `json:"crossDomainRelations"`
 }
 
-// CrossDomainRelations maps content IDs to related content IDs
-var CrossDomainRelations map[string]string
+// curatedRelations maps content IDs to their hand-picked related content ID
+var curatedRelations map[string]string
+
+// contentStudios maps content IDs to the studio that publishes them
+var contentStudios = make(map[string]string)
 
 // defaultContent holds the parsed content items
 var defaultContent []ContentItem
@@ -68,10 +112,11 @@ func init() {
 		log.Fatalf("failed to parse embedded games.json: %v", err)
 	}
 
-	CrossDomainRelations = data.CrossDomainRelations
+	curatedRelations = data.CrossDomainRelations
 
 	for _, studio := range data.Studios {
 		for _, game := range studio.Games {
+			contentStudios[game.ID] = studio.Name
 			defaultContent = append(defaultContent, ContentItem{
 				ID:              game.ID,
 				Type:            ContentTypeGame,
@@ -96,6 +141,28 @@ func init() {
 		ContainerStatus: StatusCold,
 		DeploymentName:  "ai-service",
 	})
+
+	var videos []videoEntry
+	if err := json.Unmarshal(videosJSON, &videos); err != nil {
+		log.Fatalf("failed to parse embedded defaultVideos.json: %v", err)
+	}
+
+	for _, video := range videos {
+		if !video.IsActive {
+			continue
+		}
+		defaultContent = append(defaultContent, ContentItem{
+			ID:              video.ID,
+			Type:            ContentTypeVideo,
+			Theme:           video.Theme,
+			Title:           video.Title,
+			Description:     video.Description,
+			ThumbnailURL:    video.ThumbnailURL,
+			ContainerStatus: StatusCold,
+			MediaURL:        video.VideoURL,
+			DurationSec:     video.Duration,
+		})
+	}
 }
 
 // DefaultContent returns the initial content items for the demo
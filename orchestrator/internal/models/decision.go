@@ -1,6 +1,7 @@
 package models
 
 import (
+	"math"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,6 +18,7 @@ const (
 	TriggerInitialWarm      TriggerType = "INITIAL_WARM"      // 页面加载时预热
 	TriggerLookahead        TriggerType = "LOOKAHEAD_WARM"    // 滚动前瞻预热
 	TriggerManual           TriggerType = "MANUAL"            // Manual demo control operations
+	TriggerWorkloadFailure  TriggerType = "WORKLOAD_FAILURE"  // A workload pod cannot run
 )
 
 type ActionType string
@@ -28,6 +30,9 @@ const (
 	ActionThrottleBackground ActionType = "THROTTLE_BACKGROUND"
 	ActionRestoreResources   ActionType = "RESTORE_RESOURCES"
 	ActionChangeMode         ActionType = "CHANGE_MODE"
+	ActionRetryWarm          ActionType = "RETRY_WARM"
+	ActionCoolDown           ActionType = "COOL_DOWN"
+	ActionMarkUnavailable    ActionType = "MARK_UNAVAILABLE"
 )
 
 type InputScores struct {
@@ -36,15 +41,54 @@ type InputScores struct {
 	CombinedScore float64 `json:"combined_score"`
 }
 
+// DecisionExplanation is the structured reasoning behind a decision
+type DecisionExplanation struct {
+	RuleID     string              `json:"rule_id"`
+	Thresholds map[string]float64  `json:"thresholds,omitempty"` // Configured limits the rule compared against
+	Features   map[string]float64  `json:"features,omitempty"`   // Input values the rule evaluated
+	Candidates []DecisionCandidate `json:"candidates,omitempty"` // Alternatives considered, including the chosen one
+}
+
+// DecisionCandidate is one content item a rule considered
+type DecisionCandidate struct {
+	ContentID string  `json:"content_id"`
+	Score     float64 `json:"score"`
+	Selected  bool    `json:"selected"`
+	Reason    string  `json:"reason,omitempty"` // Why it was or was not selected
+}
+
 type AIDecision struct {
-	DecisionID        string      `json:"decision_id"`
-	Timestamp         time.Time   `json:"timestamp"`
-	TriggerType       TriggerType `json:"trigger_type"`
-	AffectedContentID string      `json:"affected_content_id"`
-	ReasoningText     string      `json:"reasoning_text"`
-	InputScores       InputScores `json:"input_scores"`
-	ResultingAction   ActionType  `json:"resulting_action"`
-	Success           bool        `json:"success"`
+	DecisionID        string               `json:"decision_id"`
+	Timestamp         time.Time            `json:"timestamp"`
+	TriggerType       TriggerType          `json:"trigger_type"`
+	AffectedContentID string               `json:"affected_content_id"`
+	ReasoningText     string               `json:"reasoning_text"`
+	Explanation       *DecisionExplanation `json:"explanation,omitempty"`
+	InputScores       InputScores          `json:"input_scores"`
+	ResultingAction   ActionType           `json:"resulting_action"`
+	Success           bool                 `json:"success"`
+	Error             string               `json:"error,omitempty"`        // Why the action failed
+	CompletedAt       *time.Time           `json:"completed_at,omitempty"` // Unset while the action is pending
+}
+
+// Complete records the outcome of the decision's action
+func (d *AIDecision) Complete(err error) {
+	d.CompleteAt(time.Now(), err)
+}
+
+// CompleteAt records the outcome of the decision's action at the given time
+func (d *AIDecision) CompleteAt(at time.Time, err error) {
+	d.CompletedAt = &at
+	d.Success = err == nil
+	d.Error = ""
+	if err != nil {
+		d.Error = err.Error()
+	}
+}
+
+// Pending reports whether the decision's action has not completed yet
+func (d *AIDecision) Pending() bool {
+	return d.CompletedAt == nil
 }
 
 // NewDecision creates a new AI decision with a generated ID
@@ -57,7 +101,7 @@ func NewDecision(trigger TriggerType, contentID, reasoning string, scores InputS
 		ReasoningText:     reasoning,
 		InputScores:       scores,
 		ResultingAction:   action,
-		Success:           false, // Set to true after action completes
+		Success:           false, // Set by Complete once the action finishes
 	}
 }
 
@@ -70,11 +114,103 @@ type TrendScore struct {
 }
 
 type ResourceAllocation struct {
-	Timestamp            time.Time `json:"timestamp"`
-	ActiveAllocation     float64   `json:"active_allocation"`
-	WarmAllocation       float64   `json:"warm_allocation"`
-	BackgroundAllocation float64   `json:"background_allocation"`
-	Mode                 string    `json:"mode"`
+	Timestamp            time.Time             `json:"timestamp"`
+	ActiveAllocation     float64               `json:"active_allocation"`
+	WarmAllocation       float64               `json:"warm_allocation"`
+	BackgroundAllocation float64               `json:"background_allocation"`
+	Mode                 string                `json:"mode"`
+	Source               string                `json:"source"`                // AllocationDefault, AllocationRequests or AllocationUsage
+	Deployments          []DeploymentResources `json:"deployments,omitempty"` // Per-deployment breakdown; empty for AllocationDefault
+}
+
+// Where an allocation's percentages come from
+const (
+	AllocationDefault  = "default"  // Fixed percentages for the mode
+	AllocationRequests = "requests" // CPU requests of the running deployments
+	AllocationUsage    = "usage"    // Live CPU usage from the metrics API
+)
+
+// Resource tiers of workload deployments
+const (
+	TierActive     = "active"     // Serves HOT content
+	TierWarm       = "warm"       // Serves WARM content
+	TierBackground = "background" // Running without HOT or WARM content
+)
+
+// DeploymentResources is what a workload deployment's desired pods are given
+// and, when metrics are available, what its pods use
+type DeploymentResources struct {
+	Deployment         string `json:"deployment"`
+	Tier               string `json:"tier"`
+	Replicas           int32  `json:"replicas"`
+	CPURequestMillis   int64  `json:"cpu_request_millis"`
+	CPULimitMillis     int64  `json:"cpu_limit_millis"`
+	MemoryRequestBytes int64  `json:"memory_request_bytes"`
+	MemoryLimitBytes   int64  `json:"memory_limit_bytes"`
+	CPUUsageMillis     int64  `json:"cpu_usage_millis,omitempty"`
+	MemoryUsageBytes   int64  `json:"memory_usage_bytes,omitempty"`
+}
+
+// ReplicaIntent is the workload state one orchestrator replica wants, which
+// it shares with the leader. The leader alone changes workloads, from the
+// intents of every replica.
+type ReplicaIntent struct {
+	Replica          string                     `json:"replica"`
+	States           map[string]ContainerStatus `json:"states"`                      // Deployment -> COLD, WARM or HOT
+	Mode             OperationalMode            `json:"mode,omitempty"`              // Mode to throttle workloads for; empty before any throttle action
+	ActiveDeployment string                     `json:"active_deployment,omitempty"` // Foreground deployment for Mode
+	Slots            map[string]int             `json:"slots,omitempty"`             // Deployment -> pooled instance slots its sessions hold
+	UpdatedAt        time.Time                  `json:"updated_at"`
+}
+
+// NewResourceAllocation computes each tier's share of CPU across deployments:
+// of live usage if withUsage, else of requests. Without any CPU to share it
+// falls back to the mode's default percentages.
+func NewResourceAllocation(mode OperationalMode, deployments []DeploymentResources, withUsage bool) ResourceAllocation {
+	shares := make(map[string]int64)
+	var total int64
+	for _, d := range deployments {
+		cpu := d.CPURequestMillis
+		if withUsage {
+			cpu = d.CPUUsageMillis
+		}
+		shares[d.Tier] += cpu
+		total += cpu
+	}
+	if total == 0 {
+		allocation := DefaultResourceAllocation(mode)
+		allocation.Deployments = deployments
+		return allocation
+	}
+
+	percent := func(tier string) float64 {
+		return math.Round(float64(shares[tier])*1000/float64(total)) / 10
+	}
+	source := AllocationRequests
+	if withUsage {
+		source = AllocationUsage
+	}
+	return ResourceAllocation{
+		Timestamp:            time.Now(),
+		ActiveAllocation:     percent(TierActive),
+		WarmAllocation:       percent(TierWarm),
+		BackgroundAllocation: percent(TierBackground),
+		Mode:                 string(mode),
+		Source:               source,
+		Deployments:          deployments,
+	}
+}
+
+// ResourceDrift is a container resource setting that no longer matches what
+// the throttler last applied, e.g. after another controller changed it
+type ResourceDrift struct {
+	Deployment string `json:"deployment"`
+	Pod        string `json:"pod,omitempty"` // Set when pods are resized in place
+	Container  string `json:"container"`
+	Level      string `json:"level"`
+	Resource   string `json:"resource"` // e.g. "limits.cpu"
+	Expected   string `json:"expected"`
+	Actual     string `json:"actual"`
 }
 
 // DefaultResourceAllocation returns resource allocation based on mode
@@ -82,6 +218,7 @@ func DefaultResourceAllocation(mode OperationalMode) ResourceAllocation {
 	allocation := ResourceAllocation{
 		Timestamp: time.Now(),
 		Mode:      string(mode),
+		Source:    AllocationDefault,
 	}
 
 	switch mode {
@@ -89,6 +226,11 @@ func DefaultResourceAllocation(mode OperationalMode) ResourceAllocation {
 		allocation.ActiveAllocation = 70
 		allocation.WarmAllocation = 20
 		allocation.BackgroundAllocation = 10
+	case ModeVideoWatching:
+		// Playback is client-side; keep prefetch bandwidth for upcoming items
+		allocation.ActiveAllocation = 30
+		allocation.WarmAllocation = 50
+		allocation.BackgroundAllocation = 20
 	default: // ModeMixedStreamBrowsing
 		allocation.ActiveAllocation = 0
 		allocation.WarmAllocation = 40
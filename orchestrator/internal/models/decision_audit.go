@@ -0,0 +1,214 @@
+package models
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Audit log event types
+const (
+	AuditDecisionMade    = "decision_made"    // Decision recorded, action usually still pending
+	AuditDecisionUpdated = "decision_updated" // Decision's action succeeded or failed
+)
+
+// DecisionAuditEntry is one append-only record in the decision audit log
+type DecisionAuditEntry struct {
+	Sequence   int64      `json:"seq"`
+	Event      string     `json:"event"`
+	RecordedAt time.Time  `json:"recorded_at"`
+	Decision   AIDecision `json:"decision"`
+}
+
+// DecisionFilter selects decisions from the audit log. Zero fields match everything.
+type DecisionFilter struct {
+	ContentID string
+	Trigger   TriggerType
+	Action    ActionType
+	Since     time.Time // Inclusive, on the decision timestamp
+	Until     time.Time // Exclusive, on the decision timestamp
+	Limit     int       // Maximum number of decisions (0 for no limit)
+}
+
+// Matches reports whether a decision passes the filter
+func (f DecisionFilter) Matches(d *AIDecision) bool {
+	switch {
+	case f.ContentID != "" && d.AffectedContentID != f.ContentID:
+		return false
+	case f.Trigger != "" && d.TriggerType != f.Trigger:
+		return false
+	case f.Action != "" && d.ResultingAction != f.Action:
+		return false
+	case !f.Since.IsZero() && d.Timestamp.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !d.Timestamp.Before(f.Until):
+		return false
+	}
+	return true
+}
+
+// DecisionAuditLog is an append-only log of decisions and their outcomes. Entries
+// are never modified; an update to a decision is appended as a new entry. When
+// backed by a file, every entry is written as one NDJSON line and the file is
+// replayed on open, so history survives restarts. At least the newest maxEntries are
+// kept in memory for queries; the file keeps everything.
+type DecisionAuditLog struct {
+	mu         sync.RWMutex
+	entries    []DecisionAuditEntry // oldest first
+	maxEntries int
+	nextSeq    int64
+	file       *os.File
+}
+
+// NewDecisionAuditLog opens an audit log. An empty path keeps the log in memory
+// only; maxEntries <= 0 keeps every entry in memory.
+func NewDecisionAuditLog(path string, maxEntries int) (*DecisionAuditLog, error) {
+	l := &DecisionAuditLog{maxEntries: maxEntries, nextSeq: 1}
+	if path == "" {
+		return l, nil
+	}
+
+	if err := l.replay(path); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open decision audit log: %w", err)
+	}
+	l.file = f
+	return l, nil
+}
+
+// replay loads the entries of an existing audit log file
+func (l *DecisionAuditLog) replay(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open decision audit log: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry DecisionAuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return fmt.Errorf("decision audit log %s line %d: %w", path, line, err)
+		}
+		l.push(entry)
+		if entry.Sequence >= l.nextSeq {
+			l.nextSeq = entry.Sequence + 1
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read decision audit log: %w", err)
+	}
+	return nil
+}
+
+// push adds an entry in memory, dropping the oldest beyond maxEntries. Trimming is
+// batched so appends stay amortized O(1). Caller must hold l.mu.
+func (l *DecisionAuditLog) push(entry DecisionAuditEntry) {
+	l.entries = append(l.entries, entry)
+	if l.maxEntries > 0 && len(l.entries) > l.maxEntries+l.maxEntries/4 {
+		l.entries = append([]DecisionAuditEntry(nil), l.entries[len(l.entries)-l.maxEntries:]...)
+	}
+}
+
+// Append records a decision event. The entry is kept in memory even if writing
+// it to the file fails; the write error is returned.
+func (l *DecisionAuditLog) Append(event string, decision *AIDecision) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry := DecisionAuditEntry{
+		Sequence:   l.nextSeq,
+		Event:      event,
+		RecordedAt: time.Now(),
+		Decision:   *decision,
+	}
+	l.nextSeq++
+	l.push(entry)
+
+	if l.file == nil {
+		return nil
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("encode audit entry %d: %w", entry.Sequence, err)
+	}
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("write audit entry %d: %w", entry.Sequence, err)
+	}
+	return nil
+}
+
+// Query returns the latest version of each decision matching the filter, newest first
+func (l *DecisionAuditLog) Query(filter DecisionFilter) []*AIDecision {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	decisions := []*AIDecision{}
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		d := l.entries[i].Decision
+		if seen[d.DecisionID] {
+			continue
+		}
+		seen[d.DecisionID] = true
+		if !filter.Matches(&d) {
+			continue
+		}
+		decisions = append(decisions, &d)
+		if filter.Limit > 0 && len(decisions) >= filter.Limit {
+			break
+		}
+	}
+	return decisions
+}
+
+// Export writes the entries whose decision matches the filter as NDJSON, oldest
+// first. The filter's Limit keeps only the newest matching entries. The
+// entries are copied first so a slow writer does not block recording.
+func (l *DecisionAuditLog) Export(w io.Writer, filter DecisionFilter) error {
+	l.mu.RLock()
+	var matched []DecisionAuditEntry
+	for i := range l.entries {
+		if filter.Matches(&l.entries[i].Decision) {
+			matched = append(matched, l.entries[i])
+		}
+	}
+	l.mu.RUnlock()
+	if filter.Limit > 0 && len(matched) > filter.Limit {
+		matched = matched[len(matched)-filter.Limit:]
+	}
+
+	enc := json.NewEncoder(w)
+	for i := range matched {
+		if err := enc.Encode(&matched[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the backing file, if any
+func (l *DecisionAuditLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// stalledWriter blocks every write until released
+type stalledWriter struct {
+	writing chan struct{}
+	release chan struct{}
+	buf     bytes.Buffer
+}
+
+func (w *stalledWriter) Write(p []byte) (int, error) {
+	select {
+	case w.writing <- struct{}{}:
+	default:
+	}
+	<-w.release
+	return w.buf.Write(p)
+}
+
+func TestDecisionAuditExportDoesNotBlockAppend(t *testing.T) {
+	l, err := NewDecisionAuditLog("", 0)
+	if err != nil {
+		t.Fatalf("NewDecisionAuditLog: %v", err)
+	}
+	if err := l.Append(AuditDecisionMade, &AIDecision{DecisionID: "d1", AffectedContentID: "game-a"}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	w := &stalledWriter{writing: make(chan struct{}, 1), release: make(chan struct{})}
+	exported := make(chan error, 1)
+	go func() { exported <- l.Export(w, DecisionFilter{}) }()
+	<-w.writing
+
+	appended := make(chan error, 1)
+	go func() {
+		appended <- l.Append(AuditDecisionMade, &AIDecision{DecisionID: "d2", AffectedContentID: "game-a"})
+	}()
+	select {
+	case err := <-appended:
+		if err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Append blocked by a stalled export")
+	}
+
+	close(w.release)
+	if err := <-exported; err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if lines := bytes.Count(w.buf.Bytes(), []byte("\n")); lines != 1 {
+		t.Errorf("exported %d entries, want the 1 recorded before the export", lines)
+	}
+}
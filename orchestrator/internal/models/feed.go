@@ -0,0 +1,26 @@
+package models
+
+// FeedExplanation describes how a feed item's rank was computed
+type FeedExplanation struct {
+	CombinedScore     float64 `json:"combined_score"`
+	ThemeAffinity     float64 `json:"theme_affinity"`
+	Freshness         float64 `json:"freshness"`
+	RankScore         float64 `json:"rank_score"`
+	DiversityAdjusted bool    `json:"diversity_adjusted"` // Served early to break a same-theme run
+	Reason            string  `json:"reason"`
+}
+
+// FeedItem is a ranked content item in a personalized feed
+type FeedItem struct {
+	Content     ContentItem     `json:"content"`
+	Position    int             `json:"position"`
+	Explanation FeedExplanation `json:"explanation"`
+}
+
+// FeedPage is one page of a personalized feed
+type FeedPage struct {
+	SessionID  string     `json:"session_id"`
+	Items      []FeedItem `json:"items"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+	Total      int        `json:"total"`
+}
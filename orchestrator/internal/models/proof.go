@@ -13,6 +13,8 @@ const (
 	ProofExecutionReady              ProofEventType = "execution_ready"
 	ProofRestoreStart                ProofEventType = "restore_start"
 	ProofRestoreComplete             ProofEventType = "restore_complete"
+	ProofCoolingStart                ProofEventType = "cooling_start"
+	ProofColdStateEntered            ProofEventType = "cold_state_entered"
 )
 
 // ActivationPathType classifies how content reached activation
@@ -22,6 +24,7 @@ const (
 	PathCold    ActivationPathType = "COLD_PATH"
 	PathPrewarm ActivationPathType = "PREWARM_PATH"
 	PathRestore ActivationPathType = "RESTORE_PATH"
+	PathPool    ActivationPathType = "POOL_PATH" // Served by a generic pod claimed from the warm pool
 )
 
 // ProofSignalEvent is a normalized, timestamped proof event
@@ -0,0 +1,305 @@
+package models
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// RelationType describes why two content items are related
+type RelationType string
+
+const (
+	RelationCurated      RelationType = "CURATED"       // Hand-picked crossDomainRelations from games.json
+	RelationTheme        RelationType = "THEME"         // Same theme
+	RelationStudio       RelationType = "STUDIO"        // Same studio
+	RelationCoEngagement RelationType = "CO_ENGAGEMENT" // Learned from sessions focusing on both items
+)
+
+// Default edge weights per relation type
+const (
+	curatedRelationWeight   = 0.8
+	studioRelationWeight    = 0.4
+	themeRelationWeight     = 0.3
+	maxCoEngagementWeight   = 0.9
+	maxCombinedRelationEdge = 1.0
+)
+
+// RelationEdge is a weighted, typed edge between two content items
+type RelationEdge struct {
+	From   string       `json:"from"`
+	To     string       `json:"to"`
+	Type   RelationType `json:"type"`
+	Weight float64      `json:"weight"`
+}
+
+// RelationCandidate is a content item reachable from a source item in the graph
+type RelationCandidate struct {
+	ContentID string       `json:"content_id"`
+	Weight    float64      `json:"weight"` // Product of edge weights along the best path, decayed per hop
+	Path      []string     `json:"path"`   // Content IDs from source (exclusive) to candidate (inclusive)
+	Via       RelationType `json:"via"`    // Strongest relation type on the first hop
+	Hops      int          `json:"hops"`
+}
+
+// RelationGraph is a thread-safe weighted graph of content relations
+type RelationGraph struct {
+	mu    sync.RWMutex
+	edges map[string]map[string]map[RelationType]float64 // from -> to -> type -> weight
+}
+
+// NewRelationGraph creates an empty relation graph
+func NewRelationGraph() *RelationGraph {
+	return &RelationGraph{
+		edges: make(map[string]map[string]map[RelationType]float64),
+	}
+}
+
+// DefaultRelationGraph builds a relation graph from the embedded content data
+func DefaultRelationGraph() *RelationGraph {
+	return NewCatalogRelationGraph(defaultContent)
+}
+
+// NewCatalogRelationGraph builds a relation graph between the items of a
+// catalog: curated relations, shared studios and shared themes
+func NewCatalogRelationGraph(catalog []ContentItem) *RelationGraph {
+	g := NewRelationGraph()
+	for _, item := range catalog {
+		g.SyncContent(item, catalog)
+	}
+	return g
+}
+
+// catalogRelation reports whether a relation type is derived from content
+// definitions rather than learned
+func catalogRelation(relType RelationType) bool {
+	return relType == RelationCurated || relType == RelationStudio || relType == RelationTheme
+}
+
+// SyncContent replaces the catalog relations of an added or updated item with
+// those to the other items of the catalog. Learned relations are kept.
+func (g *RelationGraph) SyncContent(item ContentItem, catalog []ContentItem) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.dropLocked(item.ID, catalogRelation)
+
+	studio := contentStudios[item.ID]
+	for _, other := range catalog {
+		if other.ID == item.ID {
+			continue
+		}
+		if curatedRelations[item.ID] == other.ID {
+			g.edgeTypes(item.ID, other.ID)[RelationCurated] = curatedRelationWeight
+		}
+		if curatedRelations[other.ID] == item.ID {
+			g.edgeTypes(other.ID, item.ID)[RelationCurated] = curatedRelationWeight
+		}
+		if studio != "" && contentStudios[other.ID] == studio {
+			g.edgeTypes(item.ID, other.ID)[RelationStudio] = studioRelationWeight
+			g.edgeTypes(other.ID, item.ID)[RelationStudio] = studioRelationWeight
+		}
+		if item.Theme != "" && other.Theme == item.Theme {
+			g.edgeTypes(item.ID, other.ID)[RelationTheme] = themeRelationWeight
+			g.edgeTypes(other.ID, item.ID)[RelationTheme] = themeRelationWeight
+		}
+	}
+}
+
+// RemoveContent drops every relation to and from a removed item
+func (g *RelationGraph) RemoveContent(contentID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.dropLocked(contentID, func(RelationType) bool { return true })
+}
+
+// ForgetLearned drops the relations learned from sessions, keeping the catalog's
+func (g *RelationGraph) ForgetLearned() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for from, targets := range g.edges {
+		for to := range targets {
+			g.dropEdgeLocked(from, to, func(relType RelationType) bool { return !catalogRelation(relType) })
+		}
+	}
+}
+
+// dropLocked removes the matching relation types of every edge to and from
+// an item. Caller must hold g.mu.
+func (g *RelationGraph) dropLocked(contentID string, match func(RelationType) bool) {
+	for to := range g.edges[contentID] {
+		g.dropEdgeLocked(contentID, to, match)
+	}
+	for from := range g.edges {
+		g.dropEdgeLocked(from, contentID, match)
+	}
+}
+
+// dropEdgeLocked removes the matching relation types of an edge, and the edge
+// once none are left. Caller must hold g.mu.
+func (g *RelationGraph) dropEdgeLocked(from, to string, match func(RelationType) bool) {
+	types, ok := g.edges[from][to]
+	if !ok {
+		return
+	}
+	for relType := range types {
+		if match(relType) {
+			delete(types, relType)
+		}
+	}
+	if len(types) == 0 {
+		delete(g.edges[from], to)
+	}
+	if len(g.edges[from]) == 0 {
+		delete(g.edges, from)
+	}
+}
+
+// AddEdge adds or strengthens a directed edge, keeping the larger weight
+func (g *RelationGraph) AddEdge(from, to string, relType RelationType, weight float64) {
+	if from == to {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	types := g.edgeTypes(from, to)
+	if weight > types[relType] {
+		types[relType] = weight
+	}
+}
+
+// Reinforce increases a bidirectional edge of the given type by delta
+func (g *RelationGraph) Reinforce(a, b string, relType RelationType, delta float64) {
+	if a == b {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	limit := maxCombinedRelationEdge
+	if relType == RelationCoEngagement {
+		limit = maxCoEngagementWeight
+	}
+	for _, pair := range [][2]string{{a, b}, {b, a}} {
+		types := g.edgeTypes(pair[0], pair[1])
+		types[relType] = math.Min(limit, types[relType]+delta)
+	}
+}
+
+// edgeTypes returns the per-type weights for an edge, creating it if needed.
+// Caller must hold g.mu.
+func (g *RelationGraph) edgeTypes(from, to string) map[RelationType]float64 {
+	targets, exists := g.edges[from]
+	if !exists {
+		targets = make(map[string]map[RelationType]float64)
+		g.edges[from] = targets
+	}
+	types, exists := targets[to]
+	if !exists {
+		types = make(map[RelationType]float64)
+		targets[to] = types
+	}
+	return types
+}
+
+// Edges returns all outgoing edges for a content item, strongest first
+func (g *RelationGraph) Edges(from string) []RelationEdge {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	var edges []RelationEdge
+	for to, types := range g.edges[from] {
+		for relType, weight := range types {
+			edges = append(edges, RelationEdge{From: from, To: to, Type: relType, Weight: weight})
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Weight != edges[j].Weight {
+			return edges[i].Weight > edges[j].Weight
+		}
+		return edges[i].To < edges[j].To
+	})
+	return edges
+}
+
+// Weight returns the combined weight of all relation types between two items (capped at 1.0)
+func (g *RelationGraph) Weight(from, to string) float64 {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	weight, _ := g.combined(from, to)
+	return weight
+}
+
+// combined sums the per-type weights of an edge and returns the strongest type.
+// Caller must hold g.mu.
+func (g *RelationGraph) combined(from, to string) (float64, RelationType) {
+	var total, best float64
+	var bestType RelationType
+	for relType, weight := range g.edges[from][to] {
+		total += weight
+		if weight > best || (weight == best && relType < bestType) {
+			best = weight
+			bestType = relType
+		}
+	}
+	return math.Min(maxCombinedRelationEdge, total), bestType
+}
+
+// Candidates returns all items reachable from source within maxHops, keeping the
+// best path to each. Path weight is the product of combined edge weights, multiplied
+// by hopDecay for every hop beyond the first.
+func (g *RelationGraph) Candidates(source string, maxHops int, hopDecay float64) []RelationCandidate {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	best := make(map[string]*RelationCandidate)
+	frontier := []*RelationCandidate{{ContentID: source, Weight: 1.0}}
+
+	for hop := 1; hop <= maxHops && len(frontier) > 0; hop++ {
+		var next []*RelationCandidate
+		for _, node := range frontier {
+			for to := range g.edges[node.ContentID] {
+				if to == source {
+					continue
+				}
+				edgeWeight, relType := g.combined(node.ContentID, to)
+				weight := node.Weight * edgeWeight
+				if hop > 1 {
+					weight *= hopDecay
+				}
+				if existing, ok := best[to]; ok && existing.Weight >= weight {
+					continue
+				}
+
+				via := relType
+				if hop > 1 {
+					via = node.Via
+				}
+				path := make([]string, len(node.Path), len(node.Path)+1)
+				copy(path, node.Path)
+				cand := &RelationCandidate{
+					ContentID: to,
+					Weight:    weight,
+					Path:      append(path, to),
+					Via:       via,
+					Hops:      hop,
+				}
+				best[to] = cand
+				next = append(next, cand)
+			}
+		}
+		frontier = next
+	}
+
+	result := make([]RelationCandidate, 0, len(best))
+	for _, cand := range best {
+		result = append(result, *cand)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Weight != result[j].Weight {
+			return result[i].Weight > result[j].Weight
+		}
+		return result[i].ContentID < result[j].ContentID
+	})
+	return result
+}
@@ -8,19 +8,21 @@ const (
 	ModeMixedStreamBrowsing OperationalMode = "MIXED_STREAM_BROWSING"
 	ModeGameFocus           OperationalMode = "GAME_FOCUS_MODE"
 	ModeAIServiceFocus      OperationalMode = "AI_SERVICE_MODE"
+	ModeVideoWatching       OperationalMode = "VIDEO_WATCHING_MODE"
 )
 
 type UserSession struct {
-	SessionID        string                 `json:"session_id"`
-	UserID           string                 `json:"user_id,omitempty"` // Firebase UID when authenticated
-	CurrentMode      OperationalMode        `json:"current_mode"`
-	ScrollPosition   int                    `json:"scroll_position"`
-	ScrollVelocity   float64                `json:"scroll_velocity"`
-	FocusTimes       map[string]int         `json:"focus_times"` // theme -> milliseconds
-	ActiveContentID  string                 `json:"active_content_id,omitempty"`
-	LastActivity     time.Time              `json:"last_activity"`
-	InjectedContent  []string               `json:"injected_content"`
-	VisibleContent   []string               `json:"visible_content"`
+	SessionID       string          `json:"session_id"`
+	UserID          string          `json:"user_id,omitempty"` // Firebase UID when authenticated
+	CurrentMode     OperationalMode `json:"current_mode"`
+	ScrollPosition  int             `json:"scroll_position"`
+	ScrollVelocity  float64         `json:"scroll_velocity"`
+	FocusTimes      map[string]int  `json:"focus_times"`   // theme -> milliseconds
+	ContentFocus    map[string]int  `json:"content_focus"` // content_id -> milliseconds
+	ActiveContentID string          `json:"active_content_id,omitempty"`
+	LastActivity    time.Time       `json:"last_activity"`
+	InjectedContent []string        `json:"injected_content"`
+	VisibleContent  []string        `json:"visible_content"`
 }
 
 // NewSession creates a new user session with default values
@@ -31,6 +33,7 @@ func NewSession(sessionID string) *UserSession {
 		ScrollPosition:  0,
 		ScrollVelocity:  0,
 		FocusTimes:      make(map[string]int),
+		ContentFocus:    make(map[string]int),
 		ActiveContentID: "",
 		LastActivity:    time.Now(),
 		InjectedContent: []string{},
@@ -38,6 +41,22 @@ func NewSession(sessionID string) *UserSession {
 	}
 }
 
+// Clone returns a deep copy of the session
+func (s *UserSession) Clone() *UserSession {
+	clone := *s
+	clone.FocusTimes = make(map[string]int, len(s.FocusTimes))
+	for k, v := range s.FocusTimes {
+		clone.FocusTimes[k] = v
+	}
+	clone.ContentFocus = make(map[string]int, len(s.ContentFocus))
+	for k, v := range s.ContentFocus {
+		clone.ContentFocus[k] = v
+	}
+	clone.InjectedContent = append([]string(nil), s.InjectedContent...)
+	clone.VisibleContent = append([]string(nil), s.VisibleContent...)
+	return &clone
+}
+
 // GetFocusTime returns the focus time for a theme in milliseconds
 func (s *UserSession) GetFocusTime(theme string) int {
 	if time, ok := s.FocusTimes[theme]; ok {
@@ -54,6 +73,19 @@ func (s *UserSession) AddFocusTime(theme string, ms int) {
 	s.FocusTimes[theme] += ms
 }
 
+// GetContentFocus returns the accumulated focus time for a content item in milliseconds
+func (s *UserSession) GetContentFocus(contentID string) int {
+	return s.ContentFocus[contentID]
+}
+
+// AddContentFocus adds focus time for a content item
+func (s *UserSession) AddContentFocus(contentID string, ms int) {
+	if s.ContentFocus == nil {
+		s.ContentFocus = make(map[string]int)
+	}
+	s.ContentFocus[contentID] += ms
+}
+
 // HasInjected checks if content has already been injected
 func (s *UserSession) HasInjected(contentID string) bool {
 	for _, id := range s.InjectedContent {
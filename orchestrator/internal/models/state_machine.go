@@ -0,0 +1,204 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrIllegalTransition is returned when a state change is not allowed from the current state
+	ErrIllegalTransition = errors.New("illegal container state transition")
+	// ErrStateConflict is returned when the container is no longer in the expected state
+	ErrStateConflict = errors.New("container state changed concurrently")
+)
+
+// TransitionError describes a rejected container state change
+type TransitionError struct {
+	ContentID string
+	From      ContainerStatus
+	To        ContainerStatus
+	Err       error // ErrIllegalTransition, ErrStateConflict or ErrContentNotFound
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("%s: %s -> %s: %v", e.ContentID, e.From, e.To, e.Err)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return e.Err
+}
+
+// TransitionCause records why a container changed state
+type TransitionCause string
+
+const (
+	CauseScaleAction    TransitionCause = "scale_action"    // Rules engine decision
+	CauseReady          TransitionCause = "container_ready" // Warming finished
+	CauseActivation     TransitionCause = "user_activation"
+	CauseRestore        TransitionCause = "session_reactivation"
+	CauseDeactivation   TransitionCause = "user_left"
+	CauseIdleTimeout    TransitionCause = "idle_timeout"
+	CauseManual         TransitionCause = "manual"
+	CauseContentRemoved TransitionCause = "content_removed"
+	CauseFailure        TransitionCause = "failure"
+)
+
+// containerTransitions lists the states reachable from each state
+var containerTransitions = map[ContainerStatus][]ContainerStatus{
+	StatusCold:    {StatusWarming, StatusHot},
+	StatusWarming: {StatusWarm, StatusHot, StatusCold, StatusFailed},
+	StatusWarm:    {StatusHot, StatusCooling, StatusFailed},
+	StatusHot:     {StatusWarm, StatusCooling, StatusFailed},
+	StatusCooling: {StatusCold, StatusWarm, StatusHot},
+	StatusFailed:  {StatusCold, StatusWarming},
+}
+
+// CanTransition reports whether a container may move directly from one state to another
+func CanTransition(from, to ContainerStatus) bool {
+	for _, allowed := range containerTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// StateTransition is one recorded container state change
+type StateTransition struct {
+	ContentID string          `json:"content_id"`
+	From      ContainerStatus `json:"from"`
+	To        ContainerStatus `json:"to"`
+	Cause     TransitionCause `json:"cause"`
+	SessionID string          `json:"session_id,omitempty"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// Changed reports whether the transition moved the container to a different state
+func (t StateTransition) Changed() bool {
+	return t.From != t.To
+}
+
+// maxTransitionHistory is the number of transitions kept per content item
+const maxTransitionHistory = 50
+
+// ContainerStateMachine validates container state changes against the allowed
+// transitions, applies them atomically to the state store and keeps a
+// per-content transition history.
+type ContainerStateMachine struct {
+	store *StateStore
+	clock interface{ Now() time.Time } // Stamps transitions
+
+	mu      sync.RWMutex
+	history map[string][]StateTransition // contentID -> transitions, oldest first
+}
+
+// NewContainerStateMachine creates a state machine over the store's container states
+func NewContainerStateMachine(store *StateStore) *ContainerStateMachine {
+	return &ContainerStateMachine{
+		store:   store,
+		clock:   wallClock{},
+		history: make(map[string][]StateTransition),
+	}
+}
+
+// wallClock tells the wall clock time
+type wallClock struct{}
+
+func (wallClock) Now() time.Time { return time.Now() }
+
+// SetClock sets the clock stamping transitions, such as an engine clock. Call
+// it before the first transition.
+func (m *ContainerStateMachine) SetClock(clock interface{ Now() time.Time }) {
+	m.clock = clock
+}
+
+// Current returns a container's current state
+func (m *ContainerStateMachine) Current(contentID string) ContainerStatus {
+	return m.store.ContainerState(contentID)
+}
+
+// Transition moves a container to a new state from whatever state it is in.
+// Moving to the current state is a no-op and is not recorded.
+func (m *ContainerStateMachine) Transition(contentID string, to ContainerStatus, cause TransitionCause, sessionID string) (StateTransition, error) {
+	for {
+		from := m.store.ContainerState(contentID)
+		t, err := m.TransitionFrom(contentID, from, to, cause, sessionID)
+		if errors.Is(err, ErrStateConflict) {
+			continue // Lost a race with another transition; re-validate against the new state
+		}
+		return t, err
+	}
+}
+
+// TransitionFrom moves a container to a new state only if it is currently in from
+func (m *ContainerStateMachine) TransitionFrom(contentID string, from, to ContainerStatus, cause TransitionCause, sessionID string) (StateTransition, error) {
+	t := StateTransition{
+		ContentID: contentID,
+		From:      from,
+		To:        to,
+		Cause:     cause,
+		SessionID: sessionID,
+	}
+
+	if from == to {
+		if current := m.store.ContainerState(contentID); current != from {
+			t.From = current
+			return t, &TransitionError{contentID, current, to, ErrStateConflict}
+		}
+		return t, nil
+	}
+	if !CanTransition(from, to) {
+		return t, &TransitionError{contentID, from, to, ErrIllegalTransition}
+	}
+	if current, err := m.store.CompareAndSetContainerState(contentID, from, to); err != nil {
+		t.From = current
+		return t, &TransitionError{contentID, current, to, err}
+	}
+
+	t.Timestamp = m.clock.Now()
+	m.mu.Lock()
+	history := append(m.history[contentID], t)
+	if len(history) > maxTransitionHistory {
+		history = history[len(history)-maxTransitionHistory:]
+	}
+	m.history[contentID] = history
+	m.mu.Unlock()
+
+	return t, nil
+}
+
+// History returns a copy of a content item's transitions, oldest first
+func (m *ContainerStateMachine) History(contentID string) []StateTransition {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	history := make([]StateTransition, len(m.history[contentID]))
+	copy(history, m.history[contentID])
+	return history
+}
+
+// LastTransition returns a content item's most recent transition
+func (m *ContainerStateMachine) LastTransition(contentID string) (StateTransition, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	history := m.history[contentID]
+	if len(history) == 0 {
+		return StateTransition{}, false
+	}
+	return history[len(history)-1], true
+}
+
+// Forget drops the history of a content item
+func (m *ContainerStateMachine) Forget(contentID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.history, contentID)
+}
+
+// Reset clears all transition history
+func (m *ContainerStateMachine) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.history = make(map[string][]StateTransition)
+}
@@ -0,0 +1,187 @@
+package models
+
+import "sync"
+
+// StateStore holds the orchestrator's content, container state and operational
+// mode (decision history lives in DecisionAuditLog). It is safe for concurrent
+// use; every read returns a copy so callers never share memory with the store.
+type StateStore struct {
+	mu              sync.RWMutex
+	content         []ContentItem
+	containerStates map[string]ContainerStatus
+	mode            OperationalMode
+}
+
+// NewStateStore creates a store serving the given content
+func NewStateStore(content []ContentItem) *StateStore {
+	s := &StateStore{}
+	s.Reset(content)
+	return s
+}
+
+// Reset replaces the content and returns to browsing mode
+func (s *StateStore) Reset(content []ContentItem) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.content = make([]ContentItem, len(content))
+	copy(s.content, content)
+	s.containerStates = make(map[string]ContainerStatus, len(content))
+	for _, c := range s.content {
+		s.containerStates[c.ID] = c.ContainerStatus
+	}
+	s.mode = ModeMixedStreamBrowsing
+}
+
+// indexOf returns the position of a content ID, or -1. Caller must hold s.mu.
+func (s *StateStore) indexOf(contentID string) int {
+	for i := range s.content {
+		if s.content[i].ID == contentID {
+			return i
+		}
+	}
+	return -1
+}
+
+// Content returns a snapshot of all content items
+func (s *StateStore) Content() []ContentItem {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	content := make([]ContentItem, len(s.content))
+	copy(content, s.content)
+	return content
+}
+
+// ContentByID returns a snapshot of a content item
+func (s *StateStore) ContentByID(contentID string) (ContentItem, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if i := s.indexOf(contentID); i >= 0 {
+		return s.content[i], true
+	}
+	return ContentItem{}, false
+}
+
+// AddContent adds a content item as COLD, or updates it if it already exists
+func (s *StateStore) AddContent(item ContentItem) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i := s.indexOf(item.ID); i >= 0 {
+		s.content[i] = s.withRuntimeState(item, s.content[i])
+		return
+	}
+	item.ContainerStatus = StatusCold
+	s.content = append(s.content, item)
+	s.containerStates[item.ID] = StatusCold
+}
+
+// UpdateContent replaces a content definition, keeping its runtime state
+func (s *StateStore) UpdateContent(item ContentItem) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(item.ID)
+	if i < 0 {
+		return false
+	}
+	s.content[i] = s.withRuntimeState(item, s.content[i])
+	return true
+}
+
+// withRuntimeState copies state and scores from the current item onto a new definition
+func (s *StateStore) withRuntimeState(item, current ContentItem) ContentItem {
+	item.ContainerStatus = current.ContainerStatus
+	item.PersonalScore = current.PersonalScore
+	item.GlobalScore = current.GlobalScore
+	item.CombinedScore = current.CombinedScore
+	return item
+}
+
+// RemoveContent removes a content item and returns the state it was in
+func (s *StateStore) RemoveContent(contentID string) (ContainerStatus, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(contentID)
+	if i < 0 {
+		return "", false
+	}
+	state := s.content[i].ContainerStatus
+	s.content = append(s.content[:i], s.content[i+1:]...)
+	delete(s.containerStates, contentID)
+	return state, true
+}
+
+// ContainerState returns the state of a container (COLD if unknown)
+func (s *StateStore) ContainerState(contentID string) ContainerStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if state, ok := s.containerStates[contentID]; ok {
+		return state
+	}
+	return StatusCold
+}
+
+// ContainerStates returns a snapshot of all container states
+func (s *StateStore) ContainerStates() map[string]ContainerStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	states := make(map[string]ContainerStatus, len(s.containerStates))
+	for id, state := range s.containerStates {
+		states[id] = state
+	}
+	return states
+}
+
+// CompareAndSetContainerState sets a container's state only if it is currently
+// expected. It returns the state found, and ErrContentNotFound for content
+// that is not served or ErrStateConflict if the state was not expected. State
+// changes should go through ContainerStateMachine, which validates them.
+func (s *StateStore) CompareAndSetContainerState(contentID string, expected, state ContainerStatus) (ContainerStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := s.indexOf(contentID)
+	if i < 0 {
+		return StatusCold, ErrContentNotFound
+	}
+	current, ok := s.containerStates[contentID]
+	if !ok {
+		current = StatusCold
+	}
+	if current != expected {
+		return current, ErrStateConflict
+	}
+	s.containerStates[contentID] = state
+	s.content[i].ContainerStatus = state
+	return current, nil
+}
+
+// ActiveContentID returns the first HOT content item, if any
+func (s *StateStore) ActiveContentID() (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, c := range s.content {
+		if s.containerStates[c.ID] == StatusHot {
+			return c.ID, true
+		}
+	}
+	return "", false
+}
+
+// Mode returns the current operational mode
+func (s *StateStore) Mode() OperationalMode {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.mode
+}
+
+// SetMode sets the operational mode and returns the previous mode
+func (s *StateStore) SetMode(mode OperationalMode) OperationalMode {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old := s.mode
+	s.mode = mode
+	return old
+}
@@ -0,0 +1,172 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Run with -race: these tests exist to catch unsynchronized access
+
+func stressContent(n int) []ContentItem {
+	content := make([]ContentItem, 0, n)
+	for i := 0; i < n; i++ {
+		item := testGame(fmt.Sprintf("game-%d", i))
+		item.ContainerStatus = StatusCold
+		content = append(content, item)
+	}
+	return content
+}
+
+func TestStateStoreConcurrentFocusScrollActivation(t *testing.T) {
+	content := stressContent(8)
+	store := NewStateStore(content)
+	machine := NewContainerStateMachine(store)
+
+	const workers, rounds = 8, 200
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(3)
+
+		// Focus: switch modes and read the focused item
+		go func(w int) {
+			defer wg.Done()
+			modes := []OperationalMode{ModeGameFocus, ModeAIServiceFocus, ModeVideoWatching, ModeMixedStreamBrowsing}
+			for i := 0; i < rounds; i++ {
+				store.SetMode(modes[(w+i)%len(modes)])
+				if item, ok := store.ContentByID(content[i%len(content)].ID); ok {
+					item.Title = "mutated copy" // Must not reach the store
+				}
+				store.Mode()
+			}
+		}(w)
+
+		// Scroll: read snapshots and update definitions
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				for _, item := range store.Content() {
+					_ = item.ContainerStatus
+				}
+				store.ContainerStates()
+				store.ActiveContentID()
+				item := content[(w+i)%len(content)]
+				item.Description = fmt.Sprintf("worker %d round %d", w, i)
+				store.UpdateContent(item)
+			}
+		}(w)
+
+		// Activation: walk items through warm, hot and back
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				id := content[(w+i)%len(content)].ID
+				for _, to := range []ContainerStatus{StatusWarming, StatusWarm, StatusHot, StatusCooling, StatusCold} {
+					if _, err := machine.Transition(id, to, CauseManual, ""); err != nil && !errors.Is(err, ErrIllegalTransition) {
+						t.Errorf("transition %s to %s: %v", id, to, err)
+						return
+					}
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if transitions := len(machine.History(content[0].ID)); transitions == 0 {
+		t.Error("no activation transitions were applied")
+	}
+	for _, item := range store.Content() {
+		if item.Title == "mutated copy" {
+			t.Errorf("%s: snapshot changes reached the store", item.ID)
+		}
+		if state := store.ContainerState(item.ID); item.ContainerStatus != state {
+			t.Errorf("%s: content status %s differs from container state %s", item.ID, item.ContainerStatus, state)
+		}
+	}
+}
+
+func TestStateMachineActivationRaceHasOneWinner(t *testing.T) {
+	store := NewStateStore(stressContent(1))
+	machine := NewContainerStateMachine(store)
+	id := "game-0"
+	if _, err := machine.Transition(id, StatusWarming, CauseScaleAction, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := machine.Transition(id, StatusWarm, CauseReady, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	var wins, conflicts atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := machine.TransitionFrom(id, StatusWarm, StatusHot, CauseActivation, fmt.Sprintf("session-%d", i))
+			switch {
+			case err == nil:
+				wins.Add(1)
+			case errors.Is(err, ErrStateConflict):
+				conflicts.Add(1)
+			default:
+				t.Errorf("activation: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if wins.Load() != 1 || conflicts.Load() != 31 {
+		t.Errorf("wins = %d, conflicts = %d; want 1 and 31", wins.Load(), conflicts.Load())
+	}
+	if history := machine.History(id); len(history) != 3 {
+		t.Errorf("history has %d transitions, want 3", len(history))
+	}
+}
+
+func TestStateStoreCompareAndSetUnknownContent(t *testing.T) {
+	store := NewStateStore(stressContent(1))
+	machine := NewContainerStateMachine(store)
+
+	if _, err := store.CompareAndSetContainerState("missing", StatusCold, StatusWarming); !errors.Is(err, ErrContentNotFound) {
+		t.Fatalf("CompareAndSetContainerState = %v, want ErrContentNotFound", err)
+	}
+	if _, err := machine.Transition("missing", StatusWarming, CauseManual, ""); !errors.Is(err, ErrContentNotFound) {
+		t.Fatalf("Transition = %v, want ErrContentNotFound", err)
+	}
+	if _, ok := store.ContainerStates()["missing"]; ok {
+		t.Error("unknown content got a container state")
+	}
+	if history := machine.History("missing"); len(history) != 0 {
+		t.Errorf("history = %v, want none", history)
+	}
+
+	// Known content still conflicts on an unexpected state
+	if _, err := store.CompareAndSetContainerState("game-0", StatusWarm, StatusHot); !errors.Is(err, ErrStateConflict) {
+		t.Errorf("CompareAndSetContainerState = %v, want ErrStateConflict", err)
+	}
+}
+
+// fixedClock always tells the same time
+type fixedClock struct{ now time.Time }
+
+func (c fixedClock) Now() time.Time { return c.now }
+
+func TestStateMachineStampsTransitionsWithItsClock(t *testing.T) {
+	machine := NewContainerStateMachine(NewStateStore(stressContent(1)))
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	machine.SetClock(fixedClock{start})
+
+	transition, err := machine.TransitionFrom("game-0", StatusCold, StatusWarming, CauseManual, "")
+	if err != nil {
+		t.Fatalf("TransitionFrom: %v", err)
+	}
+	if !transition.Timestamp.Equal(start) {
+		t.Errorf("transition stamped %v, want the clock's %v", transition.Timestamp, start)
+	}
+	if last, _ := machine.LastTransition("game-0"); !last.Timestamp.Equal(start) {
+		t.Errorf("recorded transition stamped %v, want %v", last.Timestamp, start)
+	}
+}
@@ -0,0 +1,239 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/gavigo/orchestrator/internal/engine"
+	"github.com/gavigo/orchestrator/internal/models"
+)
+
+// ErrContentLeased is returned when content cannot be cooled because other
+// sessions are using it
+var ErrContentLeased = errors.New("content is in use by other sessions")
+
+// Reasons a queued activation is cancelled
+const (
+	CancelDeactivated  = "deactivated"
+	CancelDisconnected = "disconnected"
+)
+
+// restorePriority queues restores of content the user left recently ahead of
+// fresh activations
+const restorePriority = 1
+
+// Activate brings a content item HOT for a session. Content the user left
+// recently is restored rather than started fresh. When HOT capacity is used
+// up the request is queued, the session is told its place, and the content is
+// activated once a slot frees up.
+func (s *Service) Activate(sessionID, userID, contentID string) error {
+	content, err := s.lookup(contentID)
+	if err != nil {
+		return err
+	}
+	if s.isUnavailable(contentID) {
+		return fmt.Errorf("activate %s: %w", contentID, ErrContentUnavailable)
+	}
+
+	s.reaper.Touch(contentID)
+
+	reserved := false
+	if content.ContainerStatus != models.StatusHot {
+		priority := 0
+		if s.spine.IsPreviousHot(contentID) {
+			priority = restorePriority
+		}
+		admitted, admission, err := s.admission.Admit(engine.ActivationRequest{
+			SessionID: sessionID,
+			UserID:    userKey(sessionID, userID),
+			ContentID: contentID,
+			Priority:  priority,
+		})
+		if err != nil {
+			return fmt.Errorf("activate %s: %w", contentID, err)
+		}
+		if !admitted {
+			return nil // OnQueueChanged tells the session its place
+		}
+		reserved = admission.Request.Reserved
+	}
+
+	if err := s.activate(sessionID, content); err != nil {
+		if reserved {
+			s.admission.Release(contentID)
+		}
+		return err
+	}
+	return nil
+}
+
+// activate scales admitted content HOT, gives the session a lease on it and
+// its own instance if instances are enabled, and tells the session it is ready
+func (s *Service) activate(sessionID string, content *models.ContentItem) error {
+	contentID := content.ID
+
+	instance, err := s.allocateInstance(sessionID, content)
+	if err != nil {
+		return fmt.Errorf("activate %s: %w", contentID, err)
+	}
+
+	// Claim a warm pool pod for cold content, then record the activation
+	// request in proof manager (classifies path)
+	podName := s.claimPooled(content)
+	s.proof.OnActivationRequest(contentID, content.ContainerStatus, podName != "")
+
+	// Scale to HOT as a restore or a fresh activation
+	isRestore := s.spine.IsPreviousHot(contentID)
+	cause := models.CauseActivation
+	if isRestore {
+		cause = models.CauseRestore
+	}
+	if _, err := s.lifecycle.Transition(contentID, models.StatusHot, cause, sessionID); err != nil {
+		if instance != nil {
+			s.reclaimInstance(sessionID, contentID)
+		}
+		if podName != "" {
+			s.releasePooled(contentID)
+		}
+		return fmt.Errorf("activate %s: %w", contentID, err)
+	}
+	holders := s.leases.Acquire(contentID, sessionID)
+	s.rules.ObserveLease(contentID)
+
+	if isRestore {
+		// Spine: record restore completion
+		s.clock.AfterFunc(engine.SimulatedRestoreDelay(s.rng), func() {
+			s.spine.RecordPhase(contentID, sessionID, models.PhaseRestoreComplete, "restore_complete", models.WeightFull, true)
+			s.proof.OnRestoreComplete(contentID)
+		})
+	} else {
+		// Mark execution ready for non-restore paths
+		s.proof.OnExecutionReady(contentID)
+	}
+
+	// Videos play straight from their media URL
+	endpointURL := "/workloads/" + content.DeploymentName
+	if podName != "" {
+		endpointURL = "/workloads/pool/" + podName
+	}
+	if !content.Type.UsesWorkload() {
+		endpointURL = content.MediaURL
+	}
+
+	activation := &models.Activation{
+		ContentID:   contentID,
+		EndpointURL: endpointURL,
+		Status:      models.StatusHot,
+	}
+	if instance != nil {
+		activation.EndpointURL = instance.EndpointURL
+		activation.InstanceID = instance.ID
+		activation.SessionToken = instance.SessionToken
+	}
+
+	log.Printf("Content activated: %s (%d sessions)", contentID, holders)
+	s.events.SendActivationReady(sessionID, activation)
+	return nil
+}
+
+// activateQueued activates a request admitted from the queue, which may have
+// waited long enough for its content to change or disappear. A slot it did
+// not reserve stays with the content that already held it.
+func (s *Service) activateQueued(req engine.ActivationRequest) {
+	content, err := s.lookup(req.ContentID)
+	if err == nil {
+		err = s.activate(req.SessionID, content)
+	}
+	if err != nil {
+		log.Printf("Queued activation failed: %v", err)
+		if req.Reserved {
+			s.admission.Release(req.ContentID)
+		}
+		s.events.SendActivationCancelled(req.SessionID, &models.ActivationCancelled{
+			ContentID: req.ContentID,
+			Reason:    err.Error(),
+		})
+	}
+}
+
+// Deactivate drops a session's lease on a HOT content item and, once no
+// session holds it, scales it back to WARM, opening its restore window. An
+// activation still waiting in the queue is cancelled instead.
+func (s *Service) Deactivate(sessionID, contentID string) error {
+	if s.admission.Cancel(sessionID, contentID, CancelDeactivated) {
+		return nil
+	}
+
+	remaining := s.leases.Release(contentID, sessionID)
+	s.reclaimInstance(sessionID, contentID)
+	if remaining > 0 {
+		log.Printf("Content %s stays HOT: %d sessions still hold a lease", contentID, remaining)
+		return nil
+	}
+	return s.deactivate(sessionID, contentID)
+}
+
+// deactivate scales a HOT content item no session holds back to WARM
+func (s *Service) deactivate(sessionID, contentID string) error {
+	if _, err := s.lifecycle.TransitionFrom(contentID, models.StatusHot, models.StatusWarm, models.CauseDeactivation, sessionID); err != nil {
+		return fmt.Errorf("deactivate %s: %w", contentID, err)
+	}
+	s.reaper.MarkDeactivated(contentID)
+
+	log.Printf("Content deactivated: %s", contentID)
+	return nil
+}
+
+// releaseUnleased deactivates content whose last lease a session gave up
+// without deactivating it
+func (s *Service) releaseUnleased(sessionID, contentID string) {
+	if s.lifecycle.Current(contentID) != models.StatusHot {
+		return
+	}
+	if err := s.deactivate(sessionID, contentID); err != nil {
+		log.Printf("Failed to release unleased content: %v", err)
+	}
+}
+
+// Heartbeat renews a session's leases and keeps the content they hold from
+// going idle
+func (s *Service) Heartbeat(sessionID string) {
+	for _, contentID := range s.leases.Renew(sessionID) {
+		s.reaper.Touch(contentID)
+	}
+}
+
+// Disconnect cancels a session's queued activations, releases its leases and
+// instances and drops its engagement tracking. Its user session is kept for
+// personalized feeds until the session TTL passes.
+func (s *Service) Disconnect(sessionID string) {
+	s.admission.CancelSession(sessionID, CancelDisconnected)
+	released := s.leases.ReleaseSession(sessionID)
+	contentIDs := make([]string, 0, len(released))
+	for contentID := range released {
+		contentIDs = append(contentIDs, contentID)
+	}
+	sort.Strings(contentIDs)
+	for _, contentID := range contentIDs {
+		s.reclaimInstance(sessionID, contentID)
+		if released[contentID] == 0 {
+			s.releaseUnleased(sessionID, contentID)
+		}
+	}
+
+	s.engagementMu.Lock()
+	delete(s.engagement, sessionID)
+	s.engagementMu.Unlock()
+	s.expireSession(sessionID)
+}
+
+// userKey identifies whose per-user HOT limit a session counts against.
+// Anonymous sessions each count as their own user.
+func userKey(sessionID, userID string) string {
+	if userID != "" {
+		return userID
+	}
+	return sessionID
+}
@@ -0,0 +1,44 @@
+package orchestrator
+
+import (
+	"log"
+
+	"github.com/gavigo/orchestrator/internal/models"
+)
+
+// AddContent starts serving a new catalog item as COLD
+func (s *Service) AddContent(item models.ContentItem) {
+	s.state.AddContent(item)
+	if content := s.contentByID(item.ID); content != nil {
+		s.registerRuntime(content)
+		s.rules.Relations().SyncContent(*content, s.state.Content())
+		s.events.BroadcastContentAdded(content)
+	}
+}
+
+// UpdateContent replaces a content definition, keeping its runtime state
+func (s *Service) UpdateContent(item models.ContentItem) {
+	if !s.state.UpdateContent(item) {
+		return
+	}
+	if content := s.contentByID(item.ID); content != nil {
+		s.registerRuntime(content)
+		s.rules.Relations().SyncContent(*content, s.state.Content())
+		s.events.BroadcastContentUpdated(content)
+	}
+}
+
+// RemoveContent scales a content item's workload down and stops serving it
+func (s *Service) RemoveContent(contentID string) {
+	if err := s.lifecycle.Cool(contentID, models.CauseContentRemoved, ""); err != nil {
+		log.Printf("Failed to cool down removed content %s: %v", contentID, err)
+	}
+	if _, ok := s.state.RemoveContent(contentID); !ok {
+		return
+	}
+	s.machine.Forget(contentID)
+	s.proof.InvalidateAttempt(contentID)
+	s.reaper.Forget(contentID)
+	s.rules.Relations().RemoveContent(contentID)
+	s.events.BroadcastContentRemoved(contentID)
+}
@@ -0,0 +1,85 @@
+package orchestrator
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/gavigo/orchestrator/internal/engine"
+	"github.com/gavigo/orchestrator/internal/models"
+)
+
+// Demo control actions sent by the dashboard
+const (
+	DemoTrendSpike = "trigger_trend_spike"
+	DemoReset      = "reset_demo"
+	DemoForceWarm  = "force_warm"
+	DemoForceCold  = "force_cold"
+)
+
+// HandleDemoControl applies an operator demo control. Trend spikes and forced
+// warms are recorded as MANUAL decisions.
+func (s *Service) HandleDemoControl(sessionID, action, targetContentID string, value float64) error {
+	switch action {
+	case DemoTrendSpike:
+		s.scorer.SetTrendScore(targetContentID, value, "RISING")
+		scores := s.scorer.GetScores(sessionID, targetContentID)
+		s.HandleTrendSpike(targetContentID, value, scores)
+
+		trendScores := models.InputScores{}
+		if scores != nil {
+			trendScores = *scores
+		}
+		s.recordManualDecision(targetContentID, "Manual demo control: trigger trend spike", trendScores,
+			map[string]float64{"viral_score": value})
+
+	case DemoReset:
+		s.Reset()
+
+	case DemoForceWarm:
+		if err := s.lifecycle.Warm(targetContentID, models.CauseManual, sessionID); err != nil {
+			return fmt.Errorf("force warm: %w", err)
+		}
+		s.recordManualDecision(targetContentID, "Manual demo control: force warm", models.InputScores{}, nil)
+
+	case DemoForceCold:
+		// Only cool content the operator alone is using
+		others := s.leases.Count(targetContentID)
+		if s.leases.Holds(targetContentID, sessionID) {
+			others--
+		}
+		if others > 0 {
+			return fmt.Errorf("force cold %s: %w (%d sessions)", targetContentID, ErrContentLeased, others)
+		}
+		if err := s.lifecycle.Cool(targetContentID, models.CauseManual, sessionID); err != nil {
+			return fmt.Errorf("force cold: %w", err)
+		}
+
+	default:
+		return fmt.Errorf("unknown demo control %q", action)
+	}
+
+	log.Printf("Demo control: action=%s, target=%s, value=%.2f", action, targetContentID, value)
+	return nil
+}
+
+// HandleTrendSpike runs the swarm rules for content whose trend score was just
+// raised. Scores default to the shared "default" session's.
+func (s *Service) HandleTrendSpike(contentID string, viralScore float64, scores *models.InputScores) {
+	if scores == nil {
+		scores = s.scorer.GetScores("default", contentID)
+	}
+	if content := s.contentByID(contentID); content != nil {
+		s.rules.ProcessTrendSpike(contentID, viralScore, scores, content.ContainerStatus)
+	}
+}
+
+// recordManualDecision records a completed MANUAL decision to scale content WARM
+func (s *Service) recordManualDecision(contentID, reasoning string, scores models.InputScores, features map[string]float64) {
+	decision := models.NewDecision(models.TriggerManual, contentID, reasoning, scores, models.ActionScaleWarm)
+	decision.Explanation = &models.DecisionExplanation{
+		RuleID:   engine.RuleManualControl,
+		Features: features,
+	}
+	decision.CompleteAt(s.clock.Now(), nil)
+	s.recordDecision(decision)
+}
@@ -0,0 +1,188 @@
+package orchestrator
+
+import (
+	"log"
+
+	"github.com/gavigo/orchestrator/internal/engine"
+	"github.com/gavigo/orchestrator/internal/models"
+)
+
+// engagementSummaryEvery is how many focus events pass between engagement
+// broadcasts for a session (focus events arrive about once a second)
+const engagementSummaryEvery = 3
+
+// engagementState is a session's engagement since it connected
+type engagementState struct {
+	focusCount      int
+	themeFocusTimes map[string]int
+	scrollPosition  int
+	scrollVelocity  float64
+}
+
+// HandleScroll records a session's scroll position and warms the items the
+// user is approaching
+func (s *Service) HandleScroll(sessionID string, position int, velocity float64, visibleContent []string) {
+	// Track scroll state for engagement broadcasts
+	s.engagementMu.Lock()
+	if state, ok := s.engagement[sessionID]; ok {
+		state.scrollPosition = position
+		state.scrollVelocity = velocity
+	}
+	s.engagementMu.Unlock()
+
+	// Process scroll update for lookahead warming
+	s.rules.ProcessScrollUpdate(visibleContent, s.contentPtrs())
+
+	log.Printf("Scroll update: position=%d, velocity=%.2f, visible=%d items",
+		position, velocity, len(visibleContent))
+}
+
+// HandleFocus records that a session has been looking at a content item and
+// runs the engagement rules: intent detection, score-driven warming, cross-domain
+// injection and mode changes
+func (s *Service) HandleFocus(sessionID, contentID string, durationMS int, theme string) error {
+	s.Heartbeat(sessionID) // A focusing session is still using what it holds
+
+	// Content that is not served is neither scored nor kept from idling
+	content, err := s.lookup(contentID)
+	if err != nil {
+		return err
+	}
+	scores := s.scorer.RecordFocusEvent(sessionID, contentID, durationMS, theme)
+	s.reaper.Touch(contentID)
+
+	// Spine: record INTENT if combined score > 0.3 and no INTENT yet
+	if scores.CombinedScore > 0.3 && !s.spine.HasIntent(contentID) {
+		s.spine.RecordPhase(contentID, sessionID, models.PhaseIntent, "focus_engagement", models.WeightIdle, false)
+		s.proof.OnIntentDetected(contentID)
+	}
+
+	if summary := s.trackEngagement(sessionID, content, durationMS, theme); summary != nil {
+		s.events.BroadcastEngagement(summary)
+	}
+
+	// The rules run on a snapshot so other sessions are not blocked behind
+	// their callbacks; what they change is merged back afterwards
+	s.sessionsMu.Lock()
+	session := s.sessionLocked(sessionID)
+	session.AddFocusTime(theme, durationMS)
+	session.LastActivity = s.clock.Now()
+	before := session.Clone()
+	s.sessionsMu.Unlock()
+
+	after := before.Clone()
+	s.rules.ProcessFocusEvent(after, content, durationMS, s.contentPtrs(), s.scorer.GetAllScores(sessionID))
+
+	s.sessionsMu.Lock()
+	mergeRuleChanges(s.sessionLocked(sessionID), before, after)
+	s.sessionsMu.Unlock()
+
+	log.Printf("Focus event processed: session=%s, content=%s, duration=%dms, score=%.2f",
+		sessionID, contentID, durationMS, scores.CombinedScore)
+	return nil
+}
+
+// trackEngagement adds a focus event to the session's engagement and returns a
+// summary to broadcast every few events
+func (s *Service) trackEngagement(sessionID string, content *models.ContentItem, durationMS int, theme string) *models.EngagementSummary {
+	s.engagementMu.Lock()
+	defer s.engagementMu.Unlock()
+
+	state, ok := s.engagement[sessionID]
+	if !ok {
+		state = &engagementState{themeFocusTimes: make(map[string]int)}
+		s.engagement[sessionID] = state
+	}
+	state.focusCount++
+	state.themeFocusTimes[theme] += durationMS
+
+	if state.focusCount%engagementSummaryEvery != 0 {
+		return nil
+	}
+	themeFocusTimes := make(map[string]int, len(state.themeFocusTimes))
+	for t, ms := range state.themeFocusTimes {
+		themeFocusTimes[t] = ms
+	}
+	return &models.EngagementSummary{
+		SessionID:          sessionID,
+		ActiveContentID:    content.ID,
+		ActiveContentTitle: content.Title,
+		FocusDurationMs:    durationMS,
+		Theme:              theme,
+		ScrollPosition:     state.scrollPosition,
+		ScrollVelocity:     state.scrollVelocity,
+		ThemeFocusTimes:    themeFocusTimes,
+		Timestamp:          s.clock.Now(),
+	}
+}
+
+// Session returns a snapshot of a user session, or nil if unknown
+func (s *Service) Session(sessionID string) *models.UserSession {
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	if session, ok := s.sessions[sessionID]; ok {
+		return session.Clone()
+	}
+	return nil
+}
+
+// sessionLocked returns the live session for a connection, creating it if
+// needed. The caller holds sessionsMu.
+func (s *Service) sessionLocked(sessionID string) *models.UserSession {
+	session, ok := s.sessions[sessionID]
+	if !ok {
+		session = models.NewSession(sessionID)
+		s.sessions[sessionID] = session
+	}
+	if timer, ok := s.sessionExpiry[sessionID]; ok {
+		timer.Stop() // Active again
+		delete(s.sessionExpiry, sessionID)
+	}
+	return session
+}
+
+// mergeRuleChanges applies what the rules engine changed on a session snapshot
+// to the live session, which concurrent focus events may have changed too
+func mergeRuleChanges(live, before, after *models.UserSession) {
+	if after.CurrentMode != before.CurrentMode {
+		live.CurrentMode = after.CurrentMode
+	}
+	for contentID, ms := range after.ContentFocus {
+		if added := ms - before.ContentFocus[contentID]; added > 0 {
+			live.AddContentFocus(contentID, added)
+		}
+	}
+	for _, contentID := range after.InjectedContent[len(before.InjectedContent):] {
+		if !live.HasInjected(contentID) {
+			live.MarkInjected(contentID)
+		}
+	}
+}
+
+// expireSession evicts a disconnected session once sessionTTL passes without
+// it becoming active again
+func (s *Service) expireSession(sessionID string) {
+	if s.sessionTTL <= 0 {
+		return
+	}
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	if _, ok := s.sessions[sessionID]; !ok {
+		return
+	}
+	if timer, ok := s.sessionExpiry[sessionID]; ok {
+		timer.Stop()
+	}
+	var timer engine.Timer
+	timer = s.clock.AfterFunc(s.sessionTTL, func() {
+		s.sessionsMu.Lock()
+		defer s.sessionsMu.Unlock()
+		if s.sessionExpiry[sessionID] != timer {
+			return // Reconnected, reset or rescheduled meanwhile
+		}
+		delete(s.sessionExpiry, sessionID)
+		delete(s.sessions, sessionID)
+		log.Printf("Session expired after disconnect: %s", sessionID)
+	})
+	s.sessionExpiry[sessionID] = timer
+}
@@ -0,0 +1,27 @@
+package orchestrator
+
+import "github.com/gavigo/orchestrator/internal/models"
+
+// EventSink receives the events the service emits. The WebSocket hub
+// implements it by broadcasting each event to every connected client, and
+// sending Send* events only to the session they are addressed to.
+type EventSink interface {
+	BroadcastDecision(decision *models.AIDecision)
+	BroadcastDecisionUpdated(decision *models.AIDecision)
+	BroadcastScoreUpdate(contentID string, scores *models.InputScores)
+	BroadcastContainerStateChange(contentID string, oldState, newState models.ContainerStatus)
+	BroadcastModeChange(oldMode, newMode models.OperationalMode, reason string)
+	BroadcastStreamInject(content *models.ContentItem, position int, reason string)
+	BroadcastResourceUpdate(allocation *models.ResourceAllocation)
+	BroadcastEngagement(summary *models.EngagementSummary)
+	BroadcastActivationSpine(event *models.ActivationSpineEvent)
+	BroadcastProofSignal(event *models.ProofSignalEvent)
+	BroadcastTelemetryUpdate(snapshot *models.TelemetrySnapshot)
+	BroadcastContentAdded(content *models.ContentItem)
+	BroadcastContentUpdated(content *models.ContentItem)
+	BroadcastContentRemoved(contentID string)
+	SendActivationReady(sessionID string, activation *models.Activation)
+	SendActivationQueued(sessionID string, queued *models.ActivationQueued)
+	SendActivationCancelled(sessionID string, cancelled *models.ActivationCancelled)
+	BroadcastActivationFailed(failed *models.ActivationFailed)
+}
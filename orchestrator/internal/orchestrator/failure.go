@@ -0,0 +1,152 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/gavigo/orchestrator/internal/engine"
+	"github.com/gavigo/orchestrator/internal/models"
+)
+
+// ErrContentUnavailable is returned for content whose workload cannot start
+// until it is fixed
+var ErrContentUnavailable = errors.New("content is unavailable")
+
+// HandlePodFailure fails the WARMING, WARM and HOT content a failed pod's
+// deployment serves, tells clients with an activation_failed event and applies
+// the failure policy's fallback as a WORKLOAD_FAILURE decision
+func (s *Service) HandlePodFailure(failure models.PodFailure) {
+	for _, content := range s.state.Content() {
+		if content.Type.UsesWorkload() && content.DeploymentName == failure.Deployment {
+			s.failContent(content.ID, failure)
+		}
+	}
+}
+
+// HandlePodReady makes the content a ready pod's deployment serves available
+// again, warming it to match the running pod. Its retries are cleared only
+// once the pod stays ready through the failure policy's stable period without
+// restarting.
+func (s *Service) HandlePodReady(deployment, pod string, restarts int32) {
+	for _, content := range s.state.Content() {
+		if !content.Type.UsesWorkload() || content.DeploymentName != deployment {
+			continue
+		}
+		s.failures.Ready(content.ID, pod, restarts)
+
+		s.unavailableMu.Lock()
+		_, wasUnavailable := s.unavailable[content.ID]
+		delete(s.unavailable, content.ID)
+		s.unavailableMu.Unlock()
+		if wasUnavailable {
+			log.Printf("Content available again: %s (deployment %s is ready)", content.ID, deployment)
+			if err := s.lifecycle.Warm(content.ID, models.CauseReady, ""); err != nil {
+				log.Printf("Failed to warm recovered %s: %v", content.ID, err)
+			}
+		}
+	}
+}
+
+// Unavailable returns the content marked unavailable and the failure that caused it
+func (s *Service) Unavailable() map[string]string {
+	s.unavailableMu.Lock()
+	defer s.unavailableMu.Unlock()
+	unavailable := make(map[string]string, len(s.unavailable))
+	for contentID, reason := range s.unavailable {
+		unavailable[contentID] = reason
+	}
+	return unavailable
+}
+
+// isUnavailable reports whether content was marked unavailable
+func (s *Service) isUnavailable(contentID string) bool {
+	s.unavailableMu.Lock()
+	defer s.unavailableMu.Unlock()
+	_, ok := s.unavailable[contentID]
+	return ok
+}
+
+// failContent moves content expecting a running workload to FAILED and
+// applies the fallback. Content that is COLD, COOLING or already FAILED has no
+// pod to lose and is left alone.
+func (s *Service) failContent(contentID string, failure models.PodFailure) {
+	from := s.lifecycle.Current(contentID)
+	if from != models.StatusWarming && from != models.StatusWarm && from != models.StatusHot {
+		return
+	}
+	if _, err := s.lifecycle.TransitionFrom(contentID, from, models.StatusFailed, models.CauseFailure, ""); err != nil {
+		log.Printf("Skipping failure of %s: %v", contentID, err)
+		return
+	}
+	fallback := s.failures.Decide(contentID, failure.Reason)
+	log.Printf("Content failed: %s (%s in pod %s, %d restarts), fallback %s",
+		contentID, failure.Reason, failure.Pod, failure.Restarts, fallback.Action)
+
+	s.events.BroadcastActivationFailed(&models.ActivationFailed{
+		ContentID: contentID,
+		Reason:    failure.Reason,
+		Message:   failure.Message,
+		PodName:   failure.Pod,
+		Restarts:  failure.Restarts,
+		Fallback:  fallback.Action,
+		Attempt:   fallback.Attempt,
+		RetryInMs: fallback.Backoff.Milliseconds(),
+	})
+
+	decision := models.NewDecision(models.TriggerWorkloadFailure, contentID,
+		fallbackReasoning(failure, fallback, s.failures.MaxRetries()),
+		*s.scorer.GetScores("default", contentID), fallback.Action)
+	decision.Explanation = &models.DecisionExplanation{
+		RuleID:     engine.RuleWorkloadFailure,
+		Thresholds: map[string]float64{"max_retries": float64(s.failures.MaxRetries())},
+		Features: map[string]float64{
+			"restarts": float64(failure.Restarts),
+			"attempt":  float64(fallback.Attempt),
+		},
+	}
+	decision.CompleteAt(s.clock.Now(), s.applyFallback(contentID, failure.Reason, fallback))
+	s.recordDecision(decision)
+}
+
+// applyFallback re-warms failed content after its backoff, or cools it down
+// and, if retrying cannot help, marks it unavailable. Unavailable content
+// keeps its deployment at warm replicas, so a fixed image or template brings
+// up the ready pod that makes it available again.
+func (s *Service) applyFallback(contentID, reason string, fallback engine.Fallback) error {
+	switch fallback.Action {
+	case models.ActionRetryWarm:
+		s.clock.AfterFunc(fallback.Backoff, func() {
+			if s.lifecycle.Current(contentID) != models.StatusFailed || s.isUnavailable(contentID) {
+				return // Cooled down or reset while waiting
+			}
+			if err := s.lifecycle.Warm(contentID, models.CauseFailure, ""); err != nil {
+				log.Printf("Retry %d of %s failed: %v", fallback.Attempt, contentID, err)
+			}
+		})
+		return nil
+	case models.ActionMarkUnavailable:
+		s.unavailableMu.Lock()
+		s.unavailable[contentID] = reason
+		s.unavailableMu.Unlock()
+	}
+	if err := s.lifecycle.Cool(contentID, models.CauseFailure, ""); err != nil {
+		return fmt.Errorf("cool down failed %s: %w", contentID, err)
+	}
+	return nil
+}
+
+// fallbackReasoning explains a fallback decision
+func fallbackReasoning(failure models.PodFailure, fallback engine.Fallback, maxRetries int) string {
+	switch fallback.Action {
+	case models.ActionRetryWarm:
+		return fmt.Sprintf("Pod %s failed with %s; re-warming in %s (retry %d of %d)",
+			failure.Pod, failure.Reason, fallback.Backoff, fallback.Attempt, maxRetries)
+	case models.ActionMarkUnavailable:
+		return fmt.Sprintf("Pod %s failed with %s, which a retry cannot fix; content marked unavailable until its workload is fixed and ready",
+			failure.Pod, failure.Reason)
+	default:
+		return fmt.Sprintf("Pod %s failed with %s after %d retries; cooling down",
+			failure.Pod, failure.Reason, maxRetries)
+	}
+}
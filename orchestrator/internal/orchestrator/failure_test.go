@@ -0,0 +1,42 @@
+package orchestrator
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gavigo/orchestrator/internal/models"
+)
+
+func TestUnavailableContentKeepsWarmReplicasUntilFixed(t *testing.T) {
+	s, clock, _, _ := newTestService(t)
+	workloads := &recordingWorkloads{}
+	s.Scale = workloads.scale
+
+	s.HandlePodFailure(models.PodFailure{Deployment: "game-a", Pod: "game-a-1", Reason: "ImagePullBackOff"})
+	if state := s.State().ContainerState("game-a"); state != models.StatusCold {
+		t.Fatalf("game-a is %s after an unfixable failure, want COLD", state)
+	}
+	if _, ok := s.Unavailable()["game-a"]; !ok {
+		t.Fatal("game-a not marked unavailable")
+	}
+	if calls := workloads.snapshot(); count(calls, "scale:game-a:COLD") != 0 || count(calls, "scale:game-a:WARM") != 1 {
+		t.Errorf("scale calls = %v, want the deployment kept at warm replicas", calls)
+	}
+	if err := s.Activate("s1", "", "game-a"); !errors.Is(err, ErrContentUnavailable) {
+		t.Errorf("Activate = %v, want ErrContentUnavailable", err)
+	}
+
+	// The fixed image brings up a ready pod
+	s.HandlePodReady("game-a", "game-a-2", 0)
+	if _, ok := s.Unavailable()["game-a"]; ok {
+		t.Error("game-a still unavailable with a ready pod")
+	}
+	clock.Advance(10 * time.Second)
+	if state := s.State().ContainerState("game-a"); state != models.StatusWarm {
+		t.Errorf("game-a is %s after recovering, want WARM", state)
+	}
+	if err := s.Activate("s1", "", "game-a"); err != nil {
+		t.Errorf("Activate after recovering: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package orchestrator
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gavigo/orchestrator/internal/models"
+)
+
+// instanceTimeout bounds each call to the instance allocator
+const instanceTimeout = 30 * time.Second
+
+// InstanceAllocator provisions per-session workload instances. The Kubernetes
+// allocator implements it with dedicated pods or pooled slots.
+type InstanceAllocator interface {
+	Allocate(ctx context.Context, sessionID, contentID, deploymentName string) (*models.WorkloadInstance, error)
+	Reclaim(ctx context.Context, sessionID, contentID string) error
+	Slots() map[string]int // Pooled slots this replica's sessions hold per deployment
+}
+
+// allocateInstance provisions a session's instance of content, or returns nil
+// when sessions share the content's deployment
+func (s *Service) allocateInstance(sessionID string, content *models.ContentItem) (*models.WorkloadInstance, error) {
+	if s.Instances == nil || !content.Type.UsesWorkload() {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), instanceTimeout)
+	defer cancel()
+	instance, err := s.Instances.Allocate(ctx, sessionID, content.ID, content.DeploymentName)
+	if err != nil {
+		return nil, err
+	}
+	s.workloadChanged(content.ID) // The leader scales for the slot
+	return instance, nil
+}
+
+// reclaimInstance releases a session's instance of content, if it has one
+func (s *Service) reclaimInstance(sessionID, contentID string) {
+	if s.Instances == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), instanceTimeout)
+	defer cancel()
+	if err := s.Instances.Reclaim(ctx, sessionID, contentID); err != nil {
+		log.Printf("Failed to reclaim instance of %s for session %s: %v", contentID, sessionID, err)
+	}
+	s.workloadChanged(contentID)
+}
@@ -0,0 +1,127 @@
+package orchestrator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gavigo/orchestrator/internal/k8s"
+	"github.com/gavigo/orchestrator/internal/models"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// withDedicatedInstances gives a service dedicated instances of game-a backed
+// by a fake clientset
+func withDedicatedInstances(s *Service) *fake.Clientset {
+	return withInstances(s, models.InstanceDedicated)
+}
+
+// withInstances gives a service instances of game-a in a mode backed by a
+// fake clientset
+func withInstances(s *Service, mode string) *fake.Clientset {
+	replicas := int32(1)
+	clientset := fake.NewSimpleClientset(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "game-a", Namespace: "gavigo"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "game-a", Image: "gavigo/game-a"}},
+			}},
+		},
+	})
+	config := k8s.DefaultInstanceConfig()
+	config.Mode = mode
+	s.Instances = k8s.NewInstanceAllocator(k8s.NewClientForClientset(clientset, "gavigo"), config)
+	return clientset
+}
+
+func instancePods(t *testing.T, clientset *fake.Clientset) []string {
+	t.Helper()
+	pods, err := clientset.CoreV1().Pods("gavigo").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(pods.Items))
+	for _, pod := range pods.Items {
+		names = append(names, pod.Labels[k8s.LabelSession])
+	}
+	return names
+}
+
+func TestServiceReclaimsInstanceOnDeactivation(t *testing.T) {
+	s, _, sink, _ := newTestService(t)
+	clientset := withDedicatedInstances(s)
+
+	for _, sessionID := range []string{"s1", "s2"} {
+		if err := s.Activate(sessionID, "", "game-a"); err != nil {
+			t.Fatalf("Activate %s: %v", sessionID, err)
+		}
+	}
+	ready := sink.activations["s1"][0]
+	if ready.InstanceID == "" || ready.SessionToken == "" || ready.EndpointURL != "/workloads/game-a/"+ready.InstanceID {
+		t.Errorf("activation_ready = %+v, want s1's own instance and token", ready)
+	}
+	if pods := instancePods(t, clientset); len(pods) != 2 {
+		t.Fatalf("instance pods for sessions %v, want s1 and s2", pods)
+	}
+
+	// The other session's lease keeps the content HOT, but s1's pod goes
+	if err := s.Deactivate("s1", "game-a"); err != nil {
+		t.Fatalf("Deactivate: %v", err)
+	}
+	if pods := instancePods(t, clientset); len(pods) != 1 || pods[0] != "s2" {
+		t.Errorf("instance pods for sessions %v after s1 deactivated, want s2", pods)
+	}
+	if state := s.State().ContainerState("game-a"); state != models.StatusHot {
+		t.Errorf("game-a is %s, want HOT while s2 holds it", state)
+	}
+}
+
+func TestServiceReclaimsInstanceOnLeaseExpiry(t *testing.T) {
+	s, clock, _, cfg := newTestService(t)
+	clientset := withDedicatedInstances(s)
+
+	if err := s.Activate("s1", "", "game-a"); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	if pods := instancePods(t, clientset); len(pods) != 1 {
+		t.Fatalf("instance pods for sessions %v, want s1", pods)
+	}
+
+	// No heartbeats: the lease lapses and takes the instance with it
+	clock.Advance(time.Duration(cfg.LeaseTimeoutMs+cfg.LeaseSweepIntervalMs) * time.Millisecond)
+	if pods := instancePods(t, clientset); len(pods) != 0 {
+		t.Errorf("instance pods for sessions %v after the lease expired, want none", pods)
+	}
+	if state := s.State().ContainerState("game-a"); state != models.StatusWarm {
+		t.Errorf("game-a is %s after its last lease expired, want WARM", state)
+	}
+}
+
+func TestPooledSlotsScaleTheirDeployment(t *testing.T) {
+	s, _, _, _ := newTestService(t)
+	workloads := &recordingWorkloads{}
+	s.Scale = workloads.scale
+	withInstances(s, models.InstancePooled)
+
+	// Each session's slot counts towards the replicas, bound to a pod or not
+	for _, sessionID := range []string{"s1", "s2", "s3"} {
+		if err := s.Activate(sessionID, "", "game-a"); err != nil {
+			t.Fatalf("Activate %s: %v", sessionID, err)
+		}
+	}
+	if calls := workloads.snapshot(); calls[len(calls)-1] != "scale:game-a:HOT:3" {
+		t.Errorf("scale calls = %v, want game-a scaled HOT for 3 slots", calls)
+	}
+
+	// A freed slot scales down while the content stays HOT
+	if err := s.Deactivate("s3", "game-a"); err != nil {
+		t.Fatalf("Deactivate: %v", err)
+	}
+	if calls := workloads.snapshot(); calls[len(calls)-1] != "scale:game-a:HOT:2" {
+		t.Errorf("scale calls = %v, want game-a scaled HOT for 2 slots", calls)
+	}
+}
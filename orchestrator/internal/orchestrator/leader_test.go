@@ -0,0 +1,260 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gavigo/orchestrator/internal/config"
+	"github.com/gavigo/orchestrator/internal/engine"
+	"github.com/gavigo/orchestrator/internal/k8s"
+	"github.com/gavigo/orchestrator/internal/models"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// recordingWorkloads records the scaling and throttling a replica applies
+type recordingWorkloads struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *recordingWorkloads) scale(ctx context.Context, deployment string, status models.ContainerStatus, slots int) error {
+	if slots > 0 {
+		r.add("scale:%s:%s:%d", deployment, status, slots)
+	} else {
+		r.add("scale:%s:%s", deployment, status)
+	}
+	return nil
+}
+
+func (r *recordingWorkloads) throttle(ctx context.Context, mode models.OperationalMode, activeDeployment string) error {
+	r.add("throttle:%s:%s", mode, activeDeployment)
+	return nil
+}
+
+func (r *recordingWorkloads) add(format string, args ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingWorkloads) snapshot() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.calls...)
+}
+
+// wait fails the test unless every call is recorded in time
+func (r *recordingWorkloads) wait(t *testing.T, calls ...string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		recorded := make(map[string]bool)
+		for _, call := range r.snapshot() {
+			recorded[call] = true
+		}
+		missing := []string{}
+		for _, call := range calls {
+			if !recorded[call] {
+				missing = append(missing, call)
+			}
+		}
+		if len(missing) == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("calls %v missing from %v", missing, r.snapshot())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// newTestElector returns an unstarted elector for a replica on a shared clientset
+func newTestElector(t *testing.T, clientset *fake.Clientset, identity string) *k8s.LeaderElector {
+	t.Helper()
+	electionConfig := k8s.DefaultLeaderElectionConfig()
+	electionConfig.Identity = identity
+	electionConfig.LeaseDuration = time.Second
+	electionConfig.RenewDeadline = 500 * time.Millisecond
+	electionConfig.RetryPeriod = 100 * time.Millisecond
+	elector, err := k8s.NewLeaderElector(k8s.NewClientForClientset(clientset, "gavigo"), electionConfig)
+	if err != nil {
+		t.Fatalf("NewLeaderElector: %v", err)
+	}
+	return elector
+}
+
+// testReplica is a service that scales and throttles through an elector
+type testReplica struct {
+	*Service
+	elector   *k8s.LeaderElector
+	clock     *engine.FakeClock
+	sink      *recordingSink
+	workloads *recordingWorkloads
+}
+
+// newTestReplica returns a started replica whose elector is not started yet,
+// once initial warming has settled
+func newTestReplica(t *testing.T, clientset *fake.Clientset, identity string) *testReplica {
+	t.Helper()
+	r := &testReplica{
+		elector:   newTestElector(t, clientset, identity),
+		clock:     engine.NewFakeClock(testStart),
+		sink:      newRecordingSink(),
+		workloads: &recordingWorkloads{},
+	}
+	r.Service = NewService(config.Load(), testContent(), r.sink, &Options{Clock: r.clock, Rand: engine.NewRand(7)})
+	r.Scale = r.workloads.scale
+	r.Throttle = r.workloads.throttle
+	r.IsLeader = r.elector.IsLeader
+	r.Publish = r.elector.Publish
+	r.Intents = r.elector.Intents
+	r.elector.OnIntents = r.HandleIntents
+	r.elector.OnElected = func(ctx context.Context) { r.HandleElected() }
+	r.Start()
+	t.Cleanup(r.Stop)
+
+	r.clock.Advance(10 * time.Second)
+	return r
+}
+
+// waitFor fails the test unless cond holds in time
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// startTestReplicas starts replica-a, which leads, and replica-b on a shared
+// clientset, once both watch the intent lease
+func startTestReplicas(t *testing.T, clientset *fake.Clientset) (leader, follower *testReplica) {
+	t.Helper()
+	watching := make(chan struct{}, 4)
+	clientset.PrependWatchReactor("leases", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		watching <- struct{}{}
+		return false, nil, nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	leader = newTestReplica(t, clientset, "replica-a")
+	follower = newTestReplica(t, clientset, "replica-b")
+	leader.elector.Start(ctx)
+	t.Cleanup(leader.elector.Stop)
+	waitFor(t, "replica-a to be elected", leader.elector.IsLeader)
+	follower.elector.Start(ctx)
+	t.Cleanup(follower.elector.Stop)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-watching:
+		case <-time.After(5 * time.Second):
+			t.Fatal("intent lease not watched")
+		}
+	}
+	return leader, follower
+}
+
+func TestFollowerScalingAndThrottlingAppliedByLeader(t *testing.T) {
+	leader, follower := startTestReplicas(t, fake.NewSimpleClientset())
+	if follower.elector.IsLeader() {
+		t.Fatal("both replicas lead")
+	}
+	leader.workloads.wait(t, "scale:game-a:WARM")
+
+	// The follower serves the session, and the leader changes the workloads
+	if err := follower.HandleFocus("s1", "game-a", 20000, "puzzle"); err != nil {
+		t.Fatalf("HandleFocus: %v", err)
+	}
+	if err := follower.Activate("s1", "", "game-a"); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	if !follower.sink.has("state:game-a:WARM->HOT") || !follower.sink.has("ready:s1:game-a") {
+		t.Fatalf("follower did not activate game-a: %v", follower.sink.events)
+	}
+	leader.workloads.wait(t, "throttle:GAME_FOCUS_MODE:game-a", "scale:game-a:HOT")
+	if calls := follower.workloads.snapshot(); len(calls) != 0 {
+		t.Errorf("follower changed workloads itself: %v", calls)
+	}
+
+	// Scaled for the follower again after it leaves and comes back
+	if err := follower.Deactivate("s1", "game-a"); err != nil {
+		t.Fatalf("Deactivate: %v", err)
+	}
+	waitFor(t, "game-a to be scaled WARM again", func() bool {
+		return count(leader.workloads.snapshot(), "scale:game-a:WARM") == 2
+	})
+	if err := follower.Activate("s1", "", "game-a"); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	waitFor(t, "game-a to be scaled HOT again", func() bool {
+		return count(leader.workloads.snapshot(), "scale:game-a:HOT") == 2
+	})
+}
+
+func TestLeaderScalesForEveryReplica(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	leader, follower := startTestReplicas(t, clientset)
+
+	// A session on the leader plays game-a while the follower cools it down
+	if err := leader.Activate("s1", "", "game-a"); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	leader.workloads.wait(t, "scale:game-a:HOT")
+	applied := len(leader.workloads.snapshot())
+	follower.clock.Advance(time.Hour)
+	if state := follower.State().ContainerState("game-a"); state != models.StatusCold {
+		t.Fatalf("game-a is %s on the idle follower, want COLD", state)
+	}
+	waitFor(t, "the follower's intent", func() bool {
+		intents := leader.elector.Intents()
+		return len(intents) == 1 && intents[0].States["game-a"] == models.StatusCold
+	})
+	if calls := leader.workloads.snapshot()[applied:]; count(calls, "scale:game-a:COLD") != 0 || count(calls, "scale:game-a:WARM") != 0 {
+		t.Errorf("leader scaled game-a down under its session: %v", calls)
+	}
+
+	// Replicas throttling for different foregrounds restore resources
+	if err := follower.HandleFocus("s2", "ai-c", 20000, "chat"); err != nil {
+		t.Fatalf("HandleFocus: %v", err)
+	}
+	if err := leader.HandleFocus("s1", "game-a", 20000, "puzzle"); err != nil {
+		t.Fatalf("HandleFocus: %v", err)
+	}
+	leader.workloads.wait(t, "throttle:MIXED_STREAM_BROWSING:")
+	if calls := leader.workloads.snapshot(); calls[len(calls)-1] != "throttle:MIXED_STREAM_BROWSING:" {
+		t.Errorf("leader throttled for one replica's foreground: %v", calls)
+	}
+
+	// A third replica plays ai-c; once the leader leaves, the follower takes
+	// over and scales for it from its intent, not only for itself
+	third := newTestReplica(t, clientset, "replica-c")
+	if err := third.Activate("s3", "", "ai-c"); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	leader.workloads.wait(t, "scale:ai-c:HOT")
+	leader.elector.Stop()
+	waitFor(t, "replica-b to be elected", follower.elector.IsLeader)
+	follower.workloads.wait(t, "scale:ai-c:HOT")
+	if calls := follower.workloads.snapshot(); count(calls, "scale:ai-c:COLD") != 0 || count(calls, "scale:ai-c:WARM") != 0 {
+		t.Errorf("new leader scaled ai-c down under another replica's session: %v", calls)
+	}
+}
+
+func count(calls []string, call string) int {
+	n := 0
+	for _, c := range calls {
+		if c == call {
+			n++
+		}
+	}
+	return n
+}
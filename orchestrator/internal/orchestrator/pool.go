@@ -0,0 +1,88 @@
+package orchestrator
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gavigo/orchestrator/internal/engine"
+	"github.com/gavigo/orchestrator/internal/models"
+)
+
+// poolTimeout bounds each call to the warm pool
+const poolTimeout = 30 * time.Second
+
+// WarmPool provisions generic pods per runtime class that cold activations
+// claim and specialize. The Kubernetes warm pool implements it.
+type WarmPool interface {
+	Resize(ctx context.Context, runtimeClass string, size int) error
+	Claim(ctx context.Context, runtimeClass, contentID string) (podName string, err error)
+	Release(ctx context.Context, contentID string) error
+}
+
+// PoolStatus returns the warm pool's sizing per runtime class
+func (s *Service) PoolStatus() []engine.PoolStatus {
+	return s.poolTuner.Status()
+}
+
+// registerRuntime starts sizing the pool for a content item's runtime class
+func (s *Service) registerRuntime(content *models.ContentItem) {
+	if content.Type.UsesWorkload() {
+		s.poolTuner.Register(content.Runtime())
+	}
+}
+
+// claimPooled claims a warm pool pod for content that is not warm, returning
+// the claimed pod's name or "" when the activation starts cold
+func (s *Service) claimPooled(content *models.ContentItem) string {
+	if !content.Type.UsesWorkload() || content.ContainerStatus != models.StatusCold {
+		return ""
+	}
+	s.poolTuner.RecordColdActivation(content.Runtime())
+	if s.Pool == nil {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), poolTimeout)
+	defer cancel()
+	podName, err := s.Pool.Claim(ctx, content.Runtime(), content.ID)
+	if err != nil {
+		log.Printf("Starting %s cold: %v", content.ID, err)
+		return ""
+	}
+
+	s.pooledMu.Lock()
+	s.pooled[content.ID] = podName
+	s.pooledMu.Unlock()
+	return podName
+}
+
+// releasePooled releases the warm pool pod serving content, if any
+func (s *Service) releasePooled(contentID string) {
+	s.pooledMu.Lock()
+	_, ok := s.pooled[contentID]
+	delete(s.pooled, contentID)
+	s.pooledMu.Unlock()
+	if !ok || s.Pool == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), poolTimeout)
+	defer cancel()
+	if err := s.Pool.Release(ctx, contentID); err != nil {
+		log.Printf("Failed to release warm pool pod of %s: %v", contentID, err)
+	}
+}
+
+// resizePool applies a new target size to a runtime class's pool; followers
+// leave pools to the leader
+func (s *Service) resizePool(runtimeClass string, size int) {
+	if s.Pool == nil || !s.leading() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), poolTimeout)
+	defer cancel()
+	if err := s.Pool.Resize(ctx, runtimeClass, size); err != nil {
+		log.Printf("Failed to resize warm pool %s to %d: %v", runtimeClass, size, err)
+	}
+}
@@ -0,0 +1,128 @@
+package orchestrator
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/gavigo/orchestrator/internal/api"
+	"github.com/gavigo/orchestrator/internal/k8s"
+	"github.com/gavigo/orchestrator/internal/models"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// recordingPool hands out one pod per claim and records releases
+type recordingPool struct {
+	mu       sync.Mutex
+	claimed  map[string]string // content ID -> pod
+	released []string
+}
+
+func (p *recordingPool) Resize(ctx context.Context, runtimeClass string, size int) error {
+	return nil
+}
+
+func (p *recordingPool) Claim(ctx context.Context, runtimeClass, contentID string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	podName := "pool-" + runtimeClass + "-" + contentID
+	p.claimed[contentID] = podName
+	return podName, nil
+}
+
+func (p *recordingPool) Release(ctx context.Context, contentID string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.claimed, contentID)
+	p.released = append(p.released, contentID)
+	return nil
+}
+
+func TestServiceResetReleasesPooledPods(t *testing.T) {
+	s, _, sink, _ := newTestService(t)
+	pool := &recordingPool{claimed: make(map[string]string)}
+	s.Pool = pool
+
+	if state := s.State().ContainerState("ai-c"); state != models.StatusCold {
+		t.Fatalf("ai-c is %s, want COLD", state)
+	}
+	if err := s.Activate("s1", "", "ai-c"); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	if ready := sink.activations["s1"][0]; ready.EndpointURL != "/workloads/pool/"+pool.claimed["ai-c"] {
+		t.Fatalf("activation_ready = %+v, want the claimed pool pod", ready)
+	}
+
+	s.Reset()
+	if len(pool.claimed) != 0 || len(pool.released) != 1 {
+		t.Errorf("claimed %v and released %v after Reset, want ai-c released", pool.claimed, pool.released)
+	}
+	s.pooledMu.Lock()
+	defer s.pooledMu.Unlock()
+	if len(s.pooled) != 0 {
+		t.Errorf("pooled = %v after Reset, want none", s.pooled)
+	}
+}
+
+func TestPooledActivationEndpointReachesClaimedPod(t *testing.T) {
+	var got *http.Request
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		io.WriteString(w, "pooled")
+	}))
+	defer upstream.Close()
+	host, port, _ := net.SplitHostPort(upstream.Listener.Addr().String())
+	containerPort, _ := strconv.Atoi(port)
+
+	// One available pool pod, served by the upstream
+	s, _, sink, _ := newTestService(t)
+	content, _ := s.State().ContentByID("ai-c")
+	runtimeClass := content.Runtime()
+	clientset := fake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pool-ai-1",
+			Namespace: "gavigo",
+			Labels:    map[string]string{k8s.LabelPool: runtimeClass, k8s.LabelPoolState: k8s.PoolAvailable},
+		},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name:  "runtime",
+			Ports: []corev1.ContainerPort{{ContainerPort: int32(containerPort)}},
+		}}},
+		Status: corev1.PodStatus{PodIP: host},
+	})
+	pool := k8s.NewWarmPool(k8s.NewClientForClientset(clientset, "gavigo"),
+		k8s.WarmPoolConfig{Templates: map[string]string{runtimeClass: "ai-c"}})
+	s.Pool = pool
+
+	handlers := &api.Handlers{RoutePooled: pool.Route}
+	mux := http.NewServeMux()
+	handlers.RegisterRoutes(mux)
+	get := func(path string) int {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	// Unclaimed pods are not served
+	if code := get("/workloads/pool/pool-ai-1/health"); code != http.StatusNotFound {
+		t.Errorf("unclaimed pod: status %d, want 404", code)
+	}
+
+	if err := s.Activate("s1", "", "ai-c"); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	endpoint := sink.activations["s1"][0].EndpointURL
+	if code := get(endpoint + "/health?q=1"); code != http.StatusOK {
+		t.Fatalf("GET %s: status %d, want 200", endpoint, code)
+	}
+	if got == nil || got.URL.Path != "/health" || got.URL.RawQuery != "q=1" {
+		t.Errorf("claimed pod got %v, want /health?q=1", got)
+	}
+}
@@ -0,0 +1,269 @@
+package orchestrator
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/gavigo/orchestrator/internal/models"
+)
+
+// workloadTimeout bounds each scale, throttle or publish call
+const workloadTimeout = 30 * time.Second
+
+// scaleTarget is what a deployment is scaled for: its content's state and the
+// pooled instance slots sessions hold
+type scaleTarget struct {
+	status models.ContainerStatus
+	slots  int
+}
+
+// throttleIntent is a throttle action: a mode with a deployment in the foreground
+type throttleIntent struct {
+	mode       models.OperationalMode
+	deployment string
+}
+
+// applyResourceMode throttles background workloads for focus mode and restores
+// them for browsing. Without Kubernetes only the visualization is updated.
+func (s *Service) applyResourceMode(activeContentID string, mode models.OperationalMode) error {
+	if s.Throttle == nil {
+		log.Printf("Throttle action requested but K8s not available (simulated mode)")
+		s.broadcastResources(true)
+		return nil
+	}
+
+	deploymentName := ""
+	if content, ok := s.state.ContentByID(activeContentID); ok {
+		deploymentName = content.DeploymentName
+	}
+	s.workloadsMu.Lock()
+	s.throttleFor = throttleIntent{mode: mode, deployment: deploymentName}
+	err := s.syncWorkloadsLocked()
+	s.workloadsMu.Unlock()
+
+	// Broadcast resource allocation update; deployments that failed keep their old limits
+	s.broadcastResources(true)
+	return err
+}
+
+// workloadChanged shares a content item's new state once it changes what its
+// deployment should run
+func (s *Service) workloadChanged(contentID string) {
+	content := s.contentByID(contentID)
+	if content == nil || !content.Type.UsesWorkload() || content.DeploymentName == "" {
+		return
+	}
+	s.workloadsMu.Lock()
+	defer s.workloadsMu.Unlock()
+	if err := s.syncWorkloadsLocked(); err != nil {
+		log.Printf("Failed to apply resources for mode %s: %v", s.throttleFor.mode, err)
+	}
+}
+
+// HandleIntents brings workloads in line with this replica's intent and the
+// other replicas' intents while this replica leads
+func (s *Service) HandleIntents(intents []models.ReplicaIntent) {
+	if !s.leading() {
+		return
+	}
+	s.workloadsMu.Lock()
+	throttled, err := s.reconcileLocked(s.localIntentLocked(), intents)
+	s.workloadsMu.Unlock()
+	if err != nil {
+		log.Printf("Failed to apply resources for the replicas' intents: %v", err)
+	}
+	if throttled {
+		s.broadcastResources(true)
+	}
+}
+
+// HandleElected brings every workload in line with the intents of all
+// replicas once this replica becomes the leader, whatever was applied before
+func (s *Service) HandleElected() {
+	s.workloadsMu.Lock()
+	s.scaled = make(map[string]scaleTarget)
+	s.throttled = nil
+	s.workloadsMu.Unlock()
+
+	var intents []models.ReplicaIntent
+	if s.Intents != nil {
+		intents = s.Intents()
+	}
+	s.HandleIntents(intents)
+}
+
+// leading reports whether this replica scales and throttles workloads
+func (s *Service) leading() bool {
+	return s.IsLeader == nil || s.IsLeader()
+}
+
+// syncWorkloadsLocked shares this replica's intent if it changed and, on the
+// leader, reconciles workloads with every replica's intent. The caller holds
+// s.workloadsMu.
+func (s *Service) syncWorkloadsLocked() error {
+	local := s.localIntentLocked()
+	if s.Publish != nil && (s.published == nil || !sameIntent(*s.published, local)) {
+		ctx, cancel := context.WithTimeout(context.Background(), workloadTimeout)
+		err := s.Publish(ctx, local)
+		cancel()
+		if err != nil {
+			log.Printf("Warning: %v", err)
+		} else {
+			s.published = &local
+		}
+	}
+	if !s.leading() {
+		return nil
+	}
+
+	var intents []models.ReplicaIntent
+	if s.Intents != nil {
+		intents = s.Intents()
+	}
+	_, err := s.reconcileLocked(local, intents)
+	return err
+}
+
+// localIntentLocked returns the workload state this replica wants. The caller
+// holds s.workloadsMu.
+func (s *Service) localIntentLocked() models.ReplicaIntent {
+	intent := models.ReplicaIntent{
+		States:           make(map[string]models.ContainerStatus),
+		Mode:             s.throttleFor.mode,
+		ActiveDeployment: s.throttleFor.deployment,
+	}
+	for _, content := range s.state.Content() {
+		if !content.Type.UsesWorkload() || content.DeploymentName == "" {
+			continue
+		}
+		if status, ok := s.wantedStatus(content.ID); ok {
+			intent.States[content.DeploymentName] = maxStatus(intent.States[content.DeploymentName], status)
+		}
+	}
+	if s.Instances != nil {
+		if slots := s.Instances.Slots(); len(slots) > 0 {
+			intent.Slots = slots
+		}
+	}
+	return intent
+}
+
+// wantedStatus returns what a content item's deployment should run for its
+// state: nothing while COLD, the warm replicas while WARMING or WARM, the hot
+// ones while HOT. Unavailable content keeps a pod trying until its workload
+// is fixed. COOLING and FAILED content has no say.
+func (s *Service) wantedStatus(contentID string) (models.ContainerStatus, bool) {
+	switch s.state.ContainerState(contentID) {
+	case models.StatusCold:
+		if s.isUnavailable(contentID) {
+			return models.StatusWarm, true
+		}
+		return models.StatusCold, true
+	case models.StatusWarming, models.StatusWarm:
+		return models.StatusWarm, true
+	case models.StatusHot:
+		return models.StatusHot, true
+	}
+	return "", false
+}
+
+// reconcileLocked scales each deployment for the most any replica wants of it
+// and the pooled slots all replicas hold, and applies the throttle action the replicas agree on, or restores
+// resources if they disagree. Only changes since the last reconcile are
+// applied. It reports whether it throttled. The caller holds s.workloadsMu.
+func (s *Service) reconcileLocked(local models.ReplicaIntent, remote []models.ReplicaIntent) (bool, error) {
+	intents := append([]models.ReplicaIntent{local}, remote...)
+
+	if s.Scale != nil {
+		targets := make(map[string]scaleTarget)
+		for _, intent := range intents {
+			for deployment, status := range intent.States {
+				target := targets[deployment]
+				target.status = maxStatus(target.status, status)
+				targets[deployment] = target
+			}
+			for deployment, slots := range intent.Slots {
+				target := targets[deployment]
+				target.slots += slots
+				targets[deployment] = target
+			}
+		}
+		deployments := make([]string, 0, len(targets))
+		for deployment := range targets {
+			deployments = append(deployments, deployment)
+		}
+		sort.Strings(deployments)
+
+		for _, deployment := range deployments {
+			target := targets[deployment]
+			if applied, ok := s.scaled[deployment]; ok && applied == target {
+				continue
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), workloadTimeout)
+			err := s.Scale(ctx, deployment, target.status, target.slots)
+			cancel()
+			if err != nil {
+				log.Printf("Failed to scale %s for %s with %d slots: %v", deployment, target.status, target.slots, err)
+				continue // Retried on the next reconcile
+			}
+			s.scaled[deployment] = target
+		}
+	}
+
+	throttle, ok := agreedThrottle(intents)
+	if !ok || s.Throttle == nil || (s.throttled != nil && *s.throttled == throttle) {
+		return false, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), workloadTimeout)
+	defer cancel()
+	if err := s.Throttle(ctx, throttle.mode, throttle.deployment); err != nil {
+		return true, err
+	}
+	s.throttled = &throttle
+	log.Printf("Resource throttling applied for mode: %s", throttle.mode)
+	return true, nil
+}
+
+// agreedThrottle returns the throttle action of every replica that took one,
+// or restores resources if they differ; false if none took one. Throttling
+// for one replica's foreground would starve workloads others are serving.
+func agreedThrottle(intents []models.ReplicaIntent) (throttleIntent, bool) {
+	var agreed *throttleIntent
+	for _, intent := range intents {
+		if intent.Mode == "" {
+			continue
+		}
+		throttle := throttleIntent{mode: intent.Mode, deployment: intent.ActiveDeployment}
+		if agreed != nil && *agreed != throttle {
+			return throttleIntent{mode: models.ModeMixedStreamBrowsing}, true
+		}
+		agreed = &throttle
+	}
+	if agreed == nil {
+		return throttleIntent{}, false
+	}
+	return *agreed, true
+}
+
+// statusRank orders the states a deployment can be scaled for
+var statusRank = map[models.ContainerStatus]int{
+	models.StatusCold: 1,
+	models.StatusWarm: 2,
+	models.StatusHot:  3,
+}
+
+// maxStatus returns the state that needs more replicas; "" is less than any
+func maxStatus(a, b models.ContainerStatus) models.ContainerStatus {
+	if statusRank[b] > statusRank[a] {
+		return b
+	}
+	return a
+}
+
+// sameIntent reports whether two intents want the same workload state
+func sameIntent(a, b models.ReplicaIntent) bool {
+	return a.Mode == b.Mode && a.ActiveDeployment == b.ActiveDeployment && reflect.DeepEqual(a.States, b.States) && reflect.DeepEqual(a.Slots, b.Slots)
+}
@@ -0,0 +1,116 @@
+package orchestrator
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/gavigo/orchestrator/internal/models"
+)
+
+// resourceTimeout bounds reading workload resources from the cluster
+const resourceTimeout = 10 * time.Second
+
+// ResourceAllocation returns how resources are split between the active, warm
+// and background workloads. Without a Resources dependency, or when it fails,
+// the mode's default percentages are returned.
+func (s *Service) ResourceAllocation(ctx context.Context) models.ResourceAllocation {
+	mode := s.state.Mode()
+	if s.Resources == nil {
+		return models.DefaultResourceAllocation(mode)
+	}
+
+	tiers := s.deploymentTiers()
+	names := make([]string, 0, len(tiers))
+	for name := range tiers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	deployments, withUsage, err := s.Resources(ctx, names)
+	if err != nil {
+		log.Printf("Failed to read workload resources: %v", err)
+		return models.DefaultResourceAllocation(mode)
+	}
+	for i := range deployments {
+		deployments[i].Tier = tiers[deployments[i].Deployment]
+	}
+	return models.NewResourceAllocation(mode, deployments, withUsage)
+}
+
+// deploymentTiers returns the tier of each workload deployment: active if it
+// serves HOT content, warm if it serves WARM content, background otherwise
+func (s *Service) deploymentTiers() map[string]string {
+	rank := map[string]int{models.TierBackground: 0, models.TierWarm: 1, models.TierActive: 2}
+	tiers := make(map[string]string)
+	for _, content := range s.state.Content() {
+		if !content.Type.UsesWorkload() || content.DeploymentName == "" {
+			continue
+		}
+		tier := models.TierBackground
+		switch content.ContainerStatus {
+		case models.StatusHot:
+			tier = models.TierActive
+		case models.StatusWarm:
+			tier = models.TierWarm
+		}
+		if current, ok := tiers[content.DeploymentName]; !ok || rank[tier] > rank[current] {
+			tiers[content.DeploymentName] = tier
+		}
+	}
+	return tiers
+}
+
+// broadcastResources sends the current resource allocation as a
+// resource_update if it changed since the last one sent, or always if force
+func (s *Service) broadcastResources(force bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), resourceTimeout)
+	defer cancel()
+	allocation := s.ResourceAllocation(ctx)
+
+	compared := allocation
+	compared.Timestamp = time.Time{}
+	s.resourceMu.Lock()
+	changed := !reflect.DeepEqual(compared, s.lastAllocation)
+	s.lastAllocation = compared
+	s.resourceMu.Unlock()
+
+	if changed || force {
+		s.events.BroadcastResourceUpdate(&allocation)
+	}
+}
+
+// startResourcePolling begins periodic resource_update broadcasts on change
+func (s *Service) startResourcePolling() {
+	s.resourceMu.Lock()
+	defer s.resourceMu.Unlock()
+	if s.resourcePoll <= 0 || s.resourceTimer != nil {
+		return
+	}
+
+	s.resourceRun++
+	run := s.resourceRun
+	var poll func()
+	poll = func() {
+		s.broadcastResources(false)
+
+		s.resourceMu.Lock()
+		defer s.resourceMu.Unlock()
+		if s.resourceTimer != nil && s.resourceRun == run { // Not stopped during the poll
+			s.resourceTimer = s.clock.AfterFunc(s.resourcePoll, poll)
+		}
+	}
+	s.resourceTimer = s.clock.AfterFunc(s.resourcePoll, poll)
+}
+
+// stopResourcePolling halts periodic resource_update broadcasts
+func (s *Service) stopResourcePolling() {
+	s.resourceMu.Lock()
+	defer s.resourceMu.Unlock()
+	if s.resourceTimer != nil {
+		s.resourceTimer.Stop()
+		s.resourceTimer = nil
+	}
+}
@@ -0,0 +1,482 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gavigo/orchestrator/internal/config"
+	"github.com/gavigo/orchestrator/internal/engine"
+	"github.com/gavigo/orchestrator/internal/models"
+)
+
+// ErrUnknownContent is returned for requests about content that is not served
+var ErrUnknownContent = errors.New("unknown content")
+
+// initialWarmCount is how many items are warmed on startup so the first screen
+// shows "Activate" instead of "Not Ready"
+const initialWarmCount = 2
+
+// Options supplies the service's engine configs, time source, randomness and
+// decision log. Nil fields use the default configs, the wall clock, a
+// time-seeded source and an in-memory log.
+type Options struct {
+	Engine    *engine.EngineConfig
+	Scorer    *engine.ScorerConfig
+	Clock     engine.Clock
+	Rand      *engine.Rand
+	Decisions *models.DecisionAuditLog
+}
+
+// Service is the orchestration core: it owns the content state, scorer, rules
+// engine, activation spine, proof signals, idle reaper, activation admission
+// and session leases, applies inbound user
+// events to them and reports everything that happens to an EventSink
+type Service struct {
+	state     *models.StateStore
+	machine   *models.ContainerStateMachine
+	scorer    *engine.Scorer
+	rules     *engine.RulesEngine
+	spine     *engine.ActivationSpine
+	proof     *engine.ProofSignalManager
+	reaper    *engine.IdleReaper
+	lifecycle *engine.ContainerLifecycle
+	admission *engine.AdmissionController
+	leases    *engine.LeaseManager
+	poolTuner *engine.PoolTuner
+	failures  *engine.FailurePolicy
+	decisions *models.DecisionAuditLog
+	clock     engine.Clock
+	rng       *engine.Rand
+	events    EventSink
+
+	// Per-connection user sessions (injection history, focus times, mode).
+	// Disconnected sessions are evicted after sessionTTL.
+	sessionsMu    sync.Mutex
+	sessions      map[string]*models.UserSession
+	sessionTTL    time.Duration
+	sessionExpiry map[string]engine.Timer // session ID -> pending eviction
+
+	// Per-connection engagement tracking for engagement broadcasts
+	engagementMu sync.Mutex
+	engagement   map[string]*engagementState
+
+	// Warm pool pods serving HOT content
+	pooledMu sync.Mutex
+	pooled   map[string]string // content ID -> claimed pod
+
+	// Content whose workload cannot start until it is fixed
+	unavailableMu sync.Mutex
+	unavailable   map[string]string // content ID -> failure reason
+
+	// Resource allocation polling for resource_update broadcasts
+	resourceMu     sync.Mutex
+	resourcePoll   time.Duration
+	resourceTimer  engine.Timer // Next scheduled poll; nil when stopped
+	resourceRun    uint64       // Incremented by every start so a stale poll loop ends
+	lastAllocation models.ResourceAllocation
+
+	// Workload state this replica wants and, on the leader, what was applied
+	workloadsMu sync.Mutex
+	throttleFor throttleIntent         // Last throttle action of this replica
+	published   *models.ReplicaIntent  // Last intent shared with the leader
+	scaled      map[string]scaleTarget // Deployment -> what it was last scaled for
+	throttled   *throttleIntent        // Last throttle applied; nil before the first
+
+	// Dependencies
+	Throttle  func(ctx context.Context, mode models.OperationalMode, activeDeployment string) error        // Applies resource limits for a mode; nil when not running on Kubernetes
+	Instances InstanceAllocator                                                                            // Per-session workload instances; nil serves every session from the shared deployment
+	Pool      WarmPool                                                                                     // Generic pods for cold activations; nil starts them cold
+	Resources func(ctx context.Context, deployments []string) ([]models.DeploymentResources, bool, error)  // Reads workload requests/limits and, if the bool is true, usage; nil reports default percentages
+	Scale     func(ctx context.Context, deployment string, status models.ContainerStatus, slots int) error // Sets a deployment's replicas for its content's state and the pooled instance slots sessions hold; nil leaves replicas alone
+	IsLeader  func() bool                                                                                  // Whether this replica scales and throttles workloads; nil always does
+	Publish   func(ctx context.Context, intent models.ReplicaIntent) error                                 // Shares the workload state this replica wants with the leader; nil when it is the only replica
+	Intents   func() []models.ReplicaIntent                                                                // Workload state the other replicas want; nil when it is the only replica
+}
+
+// NewService creates a service serving the given content as COLD
+func NewService(cfg *config.Config, content []models.ContentItem, events EventSink, opts *Options) *Service {
+	if opts == nil {
+		opts = &Options{}
+	}
+	clock := opts.Clock
+	if clock == nil {
+		clock = engine.RealClock{}
+	}
+	rng := opts.Rand
+	if rng == nil {
+		rng = engine.NewTimeSeededRand()
+	}
+	decisions := opts.Decisions
+	if decisions == nil {
+		decisions, _ = models.NewDecisionAuditLog("", cfg.DecisionAuditMaxEntries) // In-memory logs cannot fail
+	}
+
+	state := models.NewStateStore(content)
+	s := &Service{
+		state:         state,
+		machine:       models.NewContainerStateMachine(state),
+		scorer:        engine.NewScorer(opts.Scorer),
+		rules:         engine.NewRulesEngine(opts.Engine),
+		spine:         engine.NewActivationSpine(events.BroadcastActivationSpine),
+		proof:         engine.NewProofSignalManager(cfg, events.BroadcastProofSignal, events.BroadcastTelemetryUpdate),
+		decisions:     decisions,
+		clock:         clock,
+		rng:           rng,
+		events:        events,
+		sessions:      make(map[string]*models.UserSession),
+		sessionTTL:    time.Duration(cfg.SessionTTLMs) * time.Millisecond,
+		sessionExpiry: make(map[string]engine.Timer),
+		engagement:    make(map[string]*engagementState),
+		pooled:        make(map[string]string),
+		scaled:        make(map[string]scaleTarget),
+		unavailable:   make(map[string]string),
+		resourcePoll:  time.Duration(cfg.ResourcePollIntervalMs) * time.Millisecond,
+		reaper: engine.NewIdleReaper(&engine.ReaperConfig{
+			HotIdleTimeout:  time.Duration(cfg.HotIdleTimeoutMs) * time.Millisecond,
+			WarmIdleTimeout: time.Duration(cfg.WarmIdleTimeoutMs) * time.Millisecond,
+			RestoreWindow:   time.Duration(cfg.RestoreWindowMs) * time.Millisecond,
+			SweepInterval:   time.Duration(cfg.IdleSweepIntervalMs) * time.Millisecond,
+		}),
+		admission: engine.NewAdmissionController(&engine.AdmissionConfig{
+			MaxHotPerCluster: cfg.MaxHotPerCluster,
+			MaxHotPerUser:    cfg.MaxHotPerUser,
+			MaxQueueLength:   cfg.ActivationQueueMax,
+			Policy:           engine.QueuePolicy(cfg.ActivationQueuePolicy),
+			ExpectedHoldTime: time.Duration(cfg.ExpectedHoldMs) * time.Millisecond,
+		}),
+		leases: engine.NewLeaseManager(&engine.LeaseConfig{
+			HeartbeatTimeout: time.Duration(cfg.LeaseTimeoutMs) * time.Millisecond,
+			SweepInterval:    time.Duration(cfg.LeaseSweepIntervalMs) * time.Millisecond,
+		}),
+		poolTuner: engine.NewPoolTuner(&engine.PoolTunerConfig{
+			MinSize:        cfg.PoolMinSize,
+			MaxSize:        cfg.PoolMaxSize,
+			Window:         time.Duration(cfg.PoolWindowMs) * time.Millisecond,
+			ReplenishTime:  time.Duration(cfg.PoolReplenishMs) * time.Millisecond,
+			Headroom:       cfg.PoolHeadroom,
+			ResizeInterval: time.Duration(cfg.PoolResizeIntervalMs) * time.Millisecond,
+		}),
+		failures: engine.NewFailurePolicy(&engine.FailureConfig{
+			MaxRetries:         cfg.FailureRetries,
+			RetryBackoff:       time.Duration(cfg.FailureBackoffMs) * time.Millisecond,
+			MaxRetryBackoff:    time.Duration(cfg.FailureMaxBackoffMs) * time.Millisecond,
+			UnavailableReasons: engine.DefaultFailureConfig().UnavailableReasons,
+			StablePeriod:       time.Duration(cfg.FailureStableMs) * time.Millisecond,
+		}),
+	}
+	s.lifecycle = engine.NewContainerLifecycle(s.machine, s.spine, s.proof)
+
+	s.machine.SetClock(clock)
+	s.scorer.SetClock(clock)
+	s.rules.SetClock(clock)
+	s.rules.SetRelationGraph(models.NewCatalogRelationGraph(content))
+	s.spine.SetClock(clock)
+	s.proof.SetClock(clock)
+	s.reaper.SetClock(clock)
+	s.lifecycle.SetClock(clock)
+	s.lifecycle.SetRand(rng)
+	s.admission.SetClock(clock)
+	s.leases.SetClock(clock)
+	s.poolTuner.SetClock(clock)
+	s.failures.SetClock(clock)
+	for i := range content {
+		s.registerRuntime(&content[i])
+	}
+
+	s.wire()
+	return s
+}
+
+// Scorer returns the content scorer
+func (s *Service) Scorer() *engine.Scorer {
+	return s.scorer
+}
+
+// Rules returns the rules engine
+func (s *Service) Rules() *engine.RulesEngine {
+	return s.rules
+}
+
+// ProofManager returns the proof signal manager
+func (s *Service) ProofManager() *engine.ProofSignalManager {
+	return s.proof
+}
+
+// State returns the store holding content and container states
+func (s *Service) State() *models.StateStore {
+	return s.state
+}
+
+// StateMachine returns the state machine that owns container state changes
+func (s *Service) StateMachine() *models.ContainerStateMachine {
+	return s.machine
+}
+
+// Decisions returns the decision audit log
+func (s *Service) Decisions() *models.DecisionAuditLog {
+	return s.decisions
+}
+
+// Start begins score decay, idle and lease sweeps, pool resizing and resource
+// polling, and warms the first content items once everything is initialized
+func (s *Service) Start() {
+	s.scorer.StartDecay()
+	s.reaper.Start(s.state.ContainerStates)
+	s.leases.Start()
+	s.poolTuner.Start()
+	s.startResourcePolling()
+	s.clock.AfterFunc(100*time.Millisecond, func() {
+		s.rules.ProcessInitialLoad(s.contentPtrs(), initialWarmCount)
+		log.Printf("Initial warming completed for first %d content items", initialWarmCount)
+	})
+}
+
+// Stop halts score decay, idle and lease sweeps, pool resizing and resource polling
+func (s *Service) Stop() {
+	s.scorer.StopDecay()
+	s.reaper.Stop()
+	s.leases.Stop()
+	s.poolTuner.Stop()
+	s.stopResourcePolling()
+}
+
+// LeaseCounts returns how many sessions hold each leased content item
+func (s *Service) LeaseCounts() map[string]int {
+	return s.leases.Counts()
+}
+
+// Reset clears scores, engine state, timelines, proof signals, idle tracking,
+// HOT slots, queued activations, leases, claimed warm pool pods, failure
+// retries, unavailable content and sessions. Content and container states are
+// reset by the caller.
+func (s *Service) Reset() {
+	s.scorer.Reset()
+	s.rules.Reset()
+	s.spine.Reset()
+	s.proof.Reset()
+	s.reaper.Reset()
+	s.admission.Reset()
+	for contentID, sessions := range s.leases.Holders() {
+		for _, sessionID := range sessions {
+			s.reclaimInstance(sessionID, contentID)
+		}
+	}
+	s.leases.Reset()
+	s.pooledMu.Lock()
+	pooled := make([]string, 0, len(s.pooled))
+	for contentID := range s.pooled {
+		pooled = append(pooled, contentID)
+	}
+	s.pooledMu.Unlock()
+	for _, contentID := range pooled {
+		s.releasePooled(contentID)
+	}
+	s.poolTuner.Reset()
+	s.failures.Reset()
+
+	s.unavailableMu.Lock()
+	s.unavailable = make(map[string]string)
+	s.unavailableMu.Unlock()
+
+	s.sessionsMu.Lock()
+	for _, timer := range s.sessionExpiry {
+		timer.Stop()
+	}
+	s.sessions = make(map[string]*models.UserSession)
+	s.sessionExpiry = make(map[string]engine.Timer)
+	s.sessionsMu.Unlock()
+
+	s.engagementMu.Lock()
+	s.engagement = make(map[string]*engagementState)
+	s.engagementMu.Unlock()
+}
+
+// wire connects the engine components to each other and to the event sink
+func (s *Service) wire() {
+	s.lifecycle.GetContent = s.contentByID
+
+	s.rules.OnDecision = s.recordDecision
+	s.rules.OnDecisionUpdated = func(decision *models.AIDecision) {
+		s.audit(models.AuditDecisionUpdated, decision)
+		s.events.BroadcastDecisionUpdated(decision)
+	}
+
+	s.lifecycle.OnTransition = func(t models.StateTransition) {
+		s.events.BroadcastContainerStateChange(t.ContentID, t.From, t.To)
+
+		// Keep the rules engine's view in sync once a state is settled
+		switch {
+		case t.To == models.StatusWarming || t.To == models.StatusCooling:
+		case t.Cause == models.CauseScaleAction || t.Cause == models.CauseReady:
+			s.rules.CompleteScaleAction(t.ContentID, t.To)
+		default:
+			s.rules.ObserveState(t.ContentID, t.To)
+		}
+
+		switch {
+		case t.To == models.StatusCold:
+			s.reaper.Forget(t.ContentID)
+		case t.Cause != models.CauseIdleTimeout && t.To != models.StatusCooling && t.To != models.StatusFailed:
+			s.reaper.Touch(t.ContentID)
+		}
+
+		// Leaving HOT ends every lease and frees a slot for queued activations
+		switch {
+		case t.To == models.StatusHot:
+			s.admission.Hold(t.ContentID, "")
+		case t.From == models.StatusHot:
+			for _, sessionID := range s.leases.Forget(t.ContentID) {
+				s.reclaimInstance(sessionID, t.ContentID)
+			}
+			s.releasePooled(t.ContentID)
+			s.admission.Release(t.ContentID)
+		}
+
+		s.workloadChanged(t.ContentID)
+	}
+
+	s.rules.OnScaleAction = func(contentID string, targetState models.ContainerStatus) error {
+		if targetState != models.StatusCold && s.isUnavailable(contentID) {
+			return fmt.Errorf("scale %s to %s: %w", contentID, targetState, ErrContentUnavailable)
+		}
+		// Leased content stays HOT until its last session leaves
+		if targetState != models.StatusHot && s.lifecycle.Current(contentID) == models.StatusHot && s.leases.Count(contentID) > 0 {
+			return fmt.Errorf("scale %s to %s: %w", contentID, targetState, ErrContentLeased)
+		}
+
+		// Promotions take a HOT slot but never wait for one
+		reserved := false
+		if targetState == models.StatusHot && s.lifecycle.Current(contentID) != models.StatusHot {
+			if err := s.admission.TryHold(contentID); err != nil {
+				return fmt.Errorf("scale %s to HOT: %w", contentID, err)
+			}
+			reserved = true
+		}
+
+		var err error
+		if targetState == models.StatusWarm {
+			err = s.lifecycle.Warm(contentID, models.CauseScaleAction, "")
+		} else {
+			_, err = s.lifecycle.Transition(contentID, targetState, models.CauseScaleAction, "")
+		}
+		if err != nil {
+			if reserved {
+				s.admission.Release(contentID)
+			}
+			return err
+		}
+		// Already in the target state: no transition will confirm the action
+		if s.lifecycle.Current(contentID) == targetState {
+			s.rules.CompleteScaleAction(contentID, targetState)
+		}
+		return nil
+	}
+
+	s.reaper.OnCoolDown = func(contentID string, oldState, newState models.ContainerStatus, idleFor time.Duration) {
+		if oldState == models.StatusHot && s.leases.Count(contentID) > 0 {
+			return // Sessions still hold it; their heartbeats keep it engaged
+		}
+
+		// The sweep works from a snapshot, so only cool items still in that state
+		var err error
+		if newState == models.StatusCold {
+			if _, err = s.lifecycle.TransitionFrom(contentID, oldState, models.StatusCooling, models.CauseIdleTimeout, ""); err == nil {
+				_, err = s.lifecycle.TransitionFrom(contentID, models.StatusCooling, models.StatusCold, models.CauseIdleTimeout, "")
+			}
+		} else {
+			_, err = s.lifecycle.TransitionFrom(contentID, oldState, newState, models.CauseIdleTimeout, "")
+		}
+		if err != nil {
+			log.Printf("Skipping cool-down of %s: %v", contentID, err)
+			return
+		}
+
+		log.Printf("Content cooled down after %s idle: %s (%s -> %s)",
+			idleFor.Round(time.Second), contentID, oldState, newState)
+	}
+
+	s.rules.OnModeChange = func(oldMode, newMode models.OperationalMode, reason string) {
+		s.state.SetMode(newMode)
+		s.events.BroadcastModeChange(oldMode, newMode, reason)
+	}
+
+	s.rules.OnInject = s.events.BroadcastStreamInject
+	s.rules.OnThrottleAction = s.applyResourceMode
+
+	s.leases.OnExpire = func(contentID, sessionID string, remaining int) {
+		s.reclaimInstance(sessionID, contentID)
+		if remaining == 0 {
+			s.releaseUnleased(sessionID, contentID)
+		}
+	}
+
+	s.poolTuner.OnResize = s.resizePool
+
+	s.admission.OnAdmit = s.activateQueued
+	s.admission.OnQueueChanged = func(queued []engine.QueuedActivation) {
+		for _, q := range queued {
+			s.events.SendActivationQueued(q.Request.SessionID, &models.ActivationQueued{
+				ContentID:   q.Request.ContentID,
+				Position:    q.Position,
+				QueueLength: q.QueueLength,
+				EtaMs:       q.ETA.Milliseconds(),
+			})
+		}
+	}
+	s.admission.OnCancel = func(req engine.ActivationRequest, reason string) {
+		s.events.SendActivationCancelled(req.SessionID, &models.ActivationCancelled{
+			ContentID: req.ContentID,
+			Reason:    reason,
+		})
+	}
+
+	s.scorer.OnScoreUpdate = func(contentID string, scores *models.InputScores) {
+		s.rules.ProcessScoreUpdate(contentID, scores, s.state.ContainerState(contentID))
+		s.events.BroadcastScoreUpdate(contentID, scores)
+	}
+}
+
+// recordDecision audits, broadcasts and attributes a new decision
+func (s *Service) recordDecision(decision *models.AIDecision) {
+	s.audit(models.AuditDecisionMade, decision)
+	s.events.BroadcastDecision(decision)
+	s.proof.OnDecisionMade(decision)
+}
+
+// audit appends a decision event to the audit log
+func (s *Service) audit(event string, decision *models.AIDecision) {
+	if err := s.decisions.Append(event, decision); err != nil {
+		log.Printf("Warning: failed to audit decision %s: %v", decision.DecisionID, err)
+	}
+}
+
+// contentByID returns a snapshot of a content item, or nil if unknown
+func (s *Service) contentByID(contentID string) *models.ContentItem {
+	if content, ok := s.state.ContentByID(contentID); ok {
+		return &content
+	}
+	return nil
+}
+
+// lookup returns a snapshot of a content item or ErrUnknownContent
+func (s *Service) lookup(contentID string) (*models.ContentItem, error) {
+	content := s.contentByID(contentID)
+	if content == nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownContent, contentID)
+	}
+	return content, nil
+}
+
+// contentPtrs returns a snapshot of all content as the pointer slice the rules engine takes
+func (s *Service) contentPtrs() []*models.ContentItem {
+	content := s.state.Content()
+	ptrs := make([]*models.ContentItem, len(content))
+	for i := range content {
+		ptrs[i] = &content[i]
+	}
+	return ptrs
+}
@@ -0,0 +1,348 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gavigo/orchestrator/internal/config"
+	"github.com/gavigo/orchestrator/internal/engine"
+	"github.com/gavigo/orchestrator/internal/models"
+)
+
+var testStart = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+// recordingSink records the events a service emits as short strings
+type recordingSink struct {
+	mu          sync.Mutex
+	events      []string
+	activations map[string][]*models.Activation // session ID -> activation_ready payloads
+}
+
+func newRecordingSink() *recordingSink {
+	return &recordingSink{activations: make(map[string][]*models.Activation)}
+}
+
+func (r *recordingSink) add(format string, args ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, fmt.Sprintf(format, args...))
+}
+
+// has reports whether an event was recorded
+func (r *recordingSink) has(event string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range r.events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *recordingSink) BroadcastDecision(decision *models.AIDecision) {
+	r.add("decision:%s:%s", decision.ResultingAction, decision.AffectedContentID)
+}
+func (r *recordingSink) BroadcastDecisionUpdated(decision *models.AIDecision) {}
+func (r *recordingSink) BroadcastScoreUpdate(contentID string, scores *models.InputScores) {
+	r.add("score:%s", contentID)
+}
+func (r *recordingSink) BroadcastContainerStateChange(contentID string, oldState, newState models.ContainerStatus) {
+	r.add("state:%s:%s->%s", contentID, oldState, newState)
+}
+func (r *recordingSink) BroadcastModeChange(oldMode, newMode models.OperationalMode, reason string) {
+	r.add("mode:%s->%s", oldMode, newMode)
+}
+func (r *recordingSink) BroadcastStreamInject(content *models.ContentItem, position int, reason string) {
+	r.add("inject:%s", content.ID)
+}
+func (r *recordingSink) BroadcastResourceUpdate(allocation *models.ResourceAllocation) {}
+func (r *recordingSink) BroadcastEngagement(summary *models.EngagementSummary) {
+	r.add("engagement:%s", summary.SessionID)
+}
+func (r *recordingSink) BroadcastActivationSpine(event *models.ActivationSpineEvent) {}
+func (r *recordingSink) BroadcastProofSignal(event *models.ProofSignalEvent)         {}
+func (r *recordingSink) BroadcastTelemetryUpdate(snapshot *models.TelemetrySnapshot) {}
+func (r *recordingSink) BroadcastContentAdded(content *models.ContentItem)           {}
+func (r *recordingSink) BroadcastContentUpdated(content *models.ContentItem)         {}
+func (r *recordingSink) BroadcastContentRemoved(contentID string)                    {}
+func (r *recordingSink) BroadcastActivationFailed(failed *models.ActivationFailed)   {}
+func (r *recordingSink) SendActivationQueued(sessionID string, queued *models.ActivationQueued) {
+	r.add("queued:%s:%s", sessionID, queued.ContentID)
+}
+func (r *recordingSink) SendActivationCancelled(sessionID string, cancelled *models.ActivationCancelled) {
+	r.add("cancelled:%s:%s", sessionID, cancelled.ContentID)
+}
+func (r *recordingSink) SendActivationReady(sessionID string, activation *models.Activation) {
+	r.add("ready:%s:%s", sessionID, activation.ContentID)
+	r.mu.Lock()
+	r.activations[sessionID] = append(r.activations[sessionID], activation)
+	r.mu.Unlock()
+}
+
+func testContent() []models.ContentItem {
+	return []models.ContentItem{
+		{ID: "game-a", Type: models.ContentTypeGame, Theme: "puzzle", Title: "Game A", DeploymentName: "game-a", ContainerStatus: models.StatusCold},
+		{ID: "video-b", Type: models.ContentTypeVideo, Theme: "puzzle", Title: "Video B", MediaURL: "https://cdn.example/b.mp4", ContainerStatus: models.StatusCold},
+		{ID: "ai-c", Type: models.ContentTypeAIService, Theme: "chat", Title: "AI C", DeploymentName: "ai-c", ContainerStatus: models.StatusCold},
+	}
+}
+
+// newTestService returns a started service on a fake clock with a seeded
+// source, once initial warming has settled
+func newTestService(t *testing.T) (*Service, *engine.FakeClock, *recordingSink, *config.Config) {
+	t.Helper()
+	cfg := config.Load()
+	clock := engine.NewFakeClock(testStart)
+	sink := newRecordingSink()
+	s := NewService(cfg, testContent(), sink, &Options{Clock: clock, Rand: engine.NewRand(7)})
+	s.Start()
+	t.Cleanup(s.Stop)
+
+	clock.Advance(10 * time.Second) // Initial warming and its simulated startup
+	return s, clock, sink, cfg
+}
+
+func TestServiceFocusActivateDeactivateCoolDown(t *testing.T) {
+	s, clock, sink, cfg := newTestService(t)
+	if state := s.State().ContainerState("game-a"); state != models.StatusWarm {
+		t.Fatalf("game-a is %s after initial warming, want WARM", state)
+	}
+
+	// Focus long enough to switch into game focus
+	if err := s.HandleFocus("s1", "game-a", 20000, "puzzle"); err != nil {
+		t.Fatalf("HandleFocus: %v", err)
+	}
+	if !sink.has("mode:MIXED_STREAM_BROWSING->GAME_FOCUS_MODE") {
+		t.Errorf("no game focus mode change in %v", sink.events)
+	}
+	if session := s.Session("s1"); session == nil || session.CurrentMode != models.ModeGameFocus || session.GetContentFocus("game-a") != 20000 {
+		t.Errorf("session after focus = %+v, want game focus with 20000ms on game-a", session)
+	}
+
+	if !sink.has("score:game-a") {
+		t.Errorf("no score update in %v", sink.events)
+	}
+
+	// Activate: WARM -> HOT and the session is told where to connect
+	if err := s.Activate("s1", "", "game-a"); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	if !sink.has("state:game-a:WARM->HOT") || !sink.has("ready:s1:game-a") {
+		t.Fatalf("activation events missing from %v", sink.events)
+	}
+	if ready := sink.activations["s1"][0]; ready.EndpointURL != "/workloads/game-a" || ready.Status != models.StatusHot {
+		t.Errorf("activation_ready = %+v, want HOT at /workloads/game-a", ready)
+	}
+	if counts := s.LeaseCounts(); counts["game-a"] != 1 {
+		t.Errorf("lease counts = %v, want one lease on game-a", counts)
+	}
+
+	// Deactivate: HOT -> WARM, opening the restore window
+	if err := s.Deactivate("s1", "game-a"); err != nil {
+		t.Fatalf("Deactivate: %v", err)
+	}
+	if !sink.has("state:game-a:HOT->WARM") {
+		t.Fatalf("no deactivation in %v", sink.events)
+	}
+
+	// Idle cool-down waits out the restore window, then goes WARM -> COOLING -> COLD
+	restoreWindow := time.Duration(cfg.RestoreWindowMs) * time.Millisecond
+	clock.Advance(restoreWindow - time.Second)
+	if state := s.State().ContainerState("game-a"); state != models.StatusWarm {
+		t.Fatalf("game-a is %s inside its restore window, want WARM", state)
+	}
+	clock.Advance(time.Duration(cfg.WarmIdleTimeoutMs+cfg.IdleSweepIntervalMs) * time.Millisecond)
+	if !sink.has("state:game-a:WARM->COOLING") || !sink.has("state:game-a:COOLING->COLD") {
+		t.Errorf("no idle cool-down in %v", sink.events)
+	}
+	if state := s.State().ContainerState("game-a"); state != models.StatusCold {
+		t.Errorf("game-a is %s after idling, want COLD", state)
+	}
+}
+
+func TestServiceFocusOnUnknownContent(t *testing.T) {
+	s, _, _, _ := newTestService(t)
+
+	if err := s.HandleFocus("s1", "missing", 20000, "puzzle"); !errors.Is(err, ErrUnknownContent) {
+		t.Fatalf("HandleFocus = %v, want ErrUnknownContent", err)
+	}
+	if _, ok := s.reaper.LastEngaged("missing"); ok {
+		t.Error("unknown content kept from idling")
+	}
+	if scores := s.scorer.GetAllScores("s1"); len(scores) != 0 {
+		t.Errorf("scores = %v, want none for unknown content", scores)
+	}
+}
+
+func TestServiceConcurrentFocusKeepsRuleChanges(t *testing.T) {
+	s, _, _, _ := newTestService(t)
+
+	const sessions, rounds = 4, 25
+	var wg sync.WaitGroup
+	for i := 0; i < sessions; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sessionID := fmt.Sprintf("s%d", i%2) // Two sessions, two focusing goroutines each
+			for j := 0; j < rounds; j++ {
+				if err := s.HandleFocus(sessionID, "game-a", 1000, "puzzle"); err != nil {
+					t.Errorf("HandleFocus: %v", err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, sessionID := range []string{"s0", "s1"} {
+		session := s.Session(sessionID)
+		want := 2 * rounds * 1000
+		if session == nil || session.GetContentFocus("game-a") != want || session.GetFocusTime("puzzle") != want {
+			t.Errorf("%s = %+v, want %dms of focus on game-a and puzzle", sessionID, session, want)
+		}
+	}
+}
+
+func TestServiceEvictsDisconnectedSessionsAfterTTL(t *testing.T) {
+	s, clock, _, cfg := newTestService(t)
+	ttl := time.Duration(cfg.SessionTTLMs) * time.Millisecond
+
+	for _, sessionID := range []string{"s1", "s2"} {
+		if err := s.HandleFocus(sessionID, "video-b", 1000, "puzzle"); err != nil {
+			t.Fatalf("HandleFocus: %v", err)
+		}
+		s.Disconnect(sessionID)
+	}
+	clock.Advance(ttl / 2)
+
+	// s2 is active again, which cancels its eviction
+	if err := s.HandleFocus("s2", "video-b", 1000, "puzzle"); err != nil {
+		t.Fatalf("HandleFocus: %v", err)
+	}
+	if s.Session("s1") == nil {
+		t.Fatal("s1 evicted before its TTL")
+	}
+
+	clock.Advance(ttl / 2)
+	if s.Session("s1") != nil {
+		t.Error("s1 kept past its TTL")
+	}
+	if s.Session("s2") == nil {
+		t.Error("s2 evicted after becoming active again")
+	}
+
+	s.Disconnect("s2")
+	clock.Advance(ttl)
+	s.sessionsMu.Lock()
+	defer s.sessionsMu.Unlock()
+	if len(s.sessions) != 0 || len(s.sessionExpiry) != 0 {
+		t.Errorf("%d sessions and %d evictions left, want none", len(s.sessions), len(s.sessionExpiry))
+	}
+}
+
+func TestServiceKeepsLeasedScorePromotedContentHot(t *testing.T) {
+	s, _, sink, _ := newTestService(t)
+
+	// The score promotes game-a, then a session activates it
+	s.rules.ProcessScoreUpdate("game-a", &models.InputScores{CombinedScore: 0.9}, s.State().ContainerState("game-a"))
+	if state := s.State().ContainerState("game-a"); state != models.StatusHot {
+		t.Fatalf("game-a is %s after a hot score, want HOT", state)
+	}
+	if err := s.Activate("s1", "", "game-a"); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+
+	// The score falling no longer releases it, and neither does a scale action
+	s.rules.ProcessScoreUpdate("game-a", &models.InputScores{CombinedScore: 0.1}, models.StatusHot)
+	if err := s.rules.OnScaleAction("game-a", models.StatusWarm); !errors.Is(err, ErrContentLeased) {
+		t.Errorf("scale to WARM = %v, want ErrContentLeased", err)
+	}
+	if state := s.State().ContainerState("game-a"); state != models.StatusHot || sink.has("state:game-a:HOT->WARM") {
+		t.Errorf("game-a is %s with a lease, want HOT", state)
+	}
+	if counts := s.LeaseCounts(); counts["game-a"] != 1 {
+		t.Errorf("lease counts = %v, want the session's lease kept", counts)
+	}
+}
+
+func TestForceColdGuardedByLeases(t *testing.T) {
+	tests := []struct {
+		name     string
+		holders  []string
+		operator string
+		wantErr  error
+	}{
+		{name: "unleased content cools", operator: "op"},
+		{name: "operator's own lease does not block", holders: []string{"op"}, operator: "op"},
+		{name: "another session's lease blocks", holders: []string{"s1"}, operator: "op", wantErr: ErrContentLeased},
+		{name: "others still block with the operator", holders: []string{"op", "s1"}, operator: "op", wantErr: ErrContentLeased},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _, _, _ := newTestService(t)
+			for _, sessionID := range tt.holders {
+				if err := s.Activate(sessionID, "", "game-a"); err != nil {
+					t.Fatalf("Activate: %v", err)
+				}
+			}
+
+			err := s.HandleDemoControl(tt.operator, DemoForceCold, "game-a", 0)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("force_cold = %v, want %v", err, tt.wantErr)
+			}
+			state := s.State().ContainerState("game-a")
+			if tt.wantErr != nil && (state != models.StatusHot || s.LeaseCounts()["game-a"] != len(tt.holders)) {
+				t.Errorf("game-a is %s with leases %v after a refused force_cold", state, s.LeaseCounts())
+			}
+			if tt.wantErr == nil && (state == models.StatusHot || state == models.StatusWarm) {
+				t.Errorf("game-a is %s after force_cold", state)
+			}
+		})
+	}
+}
+
+func TestServiceRelationsFollowCatalog(t *testing.T) {
+	s, _, _, _ := newTestService(t)
+	relations := func() *models.RelationGraph { return s.Rules().Relations() }
+	if w := relations().Weight("game-a", "video-b"); w != 0.3 {
+		t.Fatalf("game-a -> video-b = %v, want the shared theme", w)
+	}
+
+	// An added item is related to the catalog's items by theme
+	s.AddContent(models.ContentItem{ID: "game-d", Type: models.ContentTypeGame, Theme: "chat", Title: "Game D", DeploymentName: "game-d"})
+	if w := relations().Weight("ai-c", "game-d"); w != 0.3 {
+		t.Errorf("ai-c -> game-d = %v after adding game-d, want the shared theme", w)
+	}
+
+	// Updating a theme moves the item's theme relations and keeps learned ones
+	relations().Reinforce("ai-c", "game-a", models.RelationCoEngagement, 0.2)
+	updated, _ := s.State().ContentByID("ai-c")
+	updated.Theme = "puzzle"
+	s.UpdateContent(updated)
+	if w := relations().Weight("ai-c", "game-d"); w != 0 {
+		t.Errorf("ai-c -> game-d = %v after the theme changed, want none", w)
+	}
+	if w := relations().Weight("ai-c", "game-a"); math.Abs(w-0.5) > 1e-9 {
+		t.Errorf("ai-c -> game-a = %v, want the new theme and the learned relation", w)
+	}
+
+	// A removed item is no longer recommended
+	s.RemoveContent("game-a")
+	for _, candidate := range relations().Candidates("video-b", 2, 0.5) {
+		if candidate.ContentID == "game-a" {
+			t.Errorf("removed game-a still a candidate: %+v", candidate)
+		}
+	}
+
+	// A reset forgets learned relations only
+	relations().Reinforce("ai-c", "video-b", models.RelationCoEngagement, 0.2)
+	s.Reset()
+	if w := relations().Weight("ai-c", "video-b"); w != 0.3 {
+		t.Errorf("ai-c -> video-b = %v after a reset, want the shared theme only", w)
+	}
+}
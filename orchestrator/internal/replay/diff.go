@@ -0,0 +1,75 @@
+package replay
+
+import (
+	"fmt"
+	"sort"
+)
+
+// DecisionKey identifies a decision independently of when it was made
+type DecisionKey struct {
+	TriggerType string `json:"trigger_type"`
+	Action      string `json:"action"`
+	ContentID   string `json:"content_id"`
+	RuleID      string `json:"rule_id,omitempty"`
+}
+
+func (k DecisionKey) String() string {
+	return fmt.Sprintf("%s %s %s (%s)", k.Action, k.ContentID, k.TriggerType, k.RuleID)
+}
+
+// DecisionDiff is a decision made a different number of times by two replays
+type DecisionDiff struct {
+	Key      DecisionKey `json:"decision"`
+	Baseline int         `json:"baseline"`
+	Scenario int         `json:"scenario"`
+}
+
+// DiffDecisions compares the decisions of two replays of the same recording,
+// ignoring timing. Differences are ordered by size, largest first.
+func DiffDecisions(baseline, scenario *Result) []DecisionDiff {
+	counts := make(map[DecisionKey]*DecisionDiff)
+	count := func(decisions []SimulatedDecision, inBaseline bool) {
+		for _, d := range decisions {
+			key := DecisionKey{
+				TriggerType: string(d.TriggerType),
+				Action:      string(d.Action),
+				ContentID:   d.ContentID,
+				RuleID:      d.RuleID,
+			}
+			diff, ok := counts[key]
+			if !ok {
+				diff = &DecisionDiff{Key: key}
+				counts[key] = diff
+			}
+			if inBaseline {
+				diff.Baseline++
+			} else {
+				diff.Scenario++
+			}
+		}
+	}
+	count(baseline.Decisions, true)
+	count(scenario.Decisions, false)
+
+	diffs := []DecisionDiff{}
+	for _, diff := range counts {
+		if diff.Baseline != diff.Scenario {
+			diffs = append(diffs, *diff)
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		di, dj := abs(diffs[i].Scenario-diffs[i].Baseline), abs(diffs[j].Scenario-diffs[j].Baseline)
+		if di != dj {
+			return di > dj
+		}
+		return diffs[i].Key.String() < diffs[j].Key.String()
+	})
+	return diffs
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,190 @@
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gavigo/orchestrator/internal/models"
+)
+
+// Recorded event types. Inbound WebSocket messages keep their message type.
+const (
+	EventCatalog        = "catalog" // Payload: the served []models.ContentItem
+	EventFocus          = "focus_event"
+	EventScroll         = "scroll_update"
+	EventActivation     = "activation_request"
+	EventDeactivation   = "deactivation"
+	EventHeartbeat      = "heartbeat"
+	EventDisconnect     = "disconnect"      // The session's client went away; no payload
+	EventContentAdded   = "content_added"   // Payload: the models.ContentItem
+	EventContentUpdated = "content_updated" // Payload: the models.ContentItem
+	EventContentRemoved = "content_removed" // Payload: {"content_id": ...}
+)
+
+// recordedMessages are the inbound WebSocket message types that drive the engine
+var recordedMessages = map[string]bool{
+	EventFocus:        true,
+	EventScroll:       true,
+	EventActivation:   true,
+	EventDeactivation: true,
+	EventHeartbeat:    true,
+}
+
+// Event is one line of a recording
+type Event struct {
+	At        time.Time       `json:"at"`
+	SessionID string          `json:"session_id,omitempty"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+}
+
+// Recorder appends the inbound WebSocket event stream, disconnects and catalog
+// changes to an NDJSON file so it can be replayed offline by Simulate
+type Recorder struct {
+	mu  sync.Mutex
+	f   *os.File
+	enc *json.Encoder
+}
+
+// NewRecorder opens a recording file for appending. Each server run starts with
+// a catalog event so the recording is self-contained.
+func NewRecorder(path string, content []models.ContentItem) (*Recorder, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open recording: %w", err)
+	}
+	r := &Recorder{f: f, enc: json.NewEncoder(f)}
+	if err := r.RecordCatalog(content); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// RecordCatalog appends the whole served catalog, as served after a restart
+// or a demo reset
+func (r *Recorder) RecordCatalog(content []models.ContentItem) error {
+	return r.writePayload("", EventCatalog, content)
+}
+
+// Record appends an inbound message if it is one of the recorded types
+func (r *Recorder) Record(sessionID, messageType string, payload json.RawMessage) error {
+	if !recordedMessages[messageType] {
+		return nil
+	}
+	return r.write(Event{SessionID: sessionID, Type: messageType, Payload: payload})
+}
+
+// RecordDisconnect appends that a session's client went away
+func (r *Recorder) RecordDisconnect(sessionID string) error {
+	return r.write(Event{SessionID: sessionID, Type: EventDisconnect})
+}
+
+// RecordContentAdded appends a catalog item that started being served
+func (r *Recorder) RecordContentAdded(item models.ContentItem) error {
+	return r.writePayload("", EventContentAdded, item)
+}
+
+// RecordContentUpdated appends a catalog item's new definition
+func (r *Recorder) RecordContentUpdated(item models.ContentItem) error {
+	return r.writePayload("", EventContentUpdated, item)
+}
+
+// RecordContentRemoved appends a catalog item that stopped being served
+func (r *Recorder) RecordContentRemoved(contentID string) error {
+	return r.writePayload("", EventContentRemoved, map[string]string{"content_id": contentID})
+}
+
+// writePayload encodes a payload and appends it as an event
+func (r *Recorder) writePayload(sessionID, eventType string, payload any) error {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode %s event: %w", eventType, err)
+	}
+	return r.write(Event{SessionID: sessionID, Type: eventType, Payload: encoded})
+}
+
+// write timestamps and appends an event. Timestamps are taken under the lock so
+// the file is always in time order.
+func (r *Recorder) write(event Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	event.At = time.Now()
+	if err := r.enc.Encode(&event); err != nil {
+		return fmt.Errorf("write %s event: %w", event.Type, err)
+	}
+	return nil
+}
+
+// Close closes the recording file
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
+
+// Recording is a loaded event stream, in time order
+type Recording struct {
+	Events []Event
+}
+
+// Start returns the time of the first event
+func (r *Recording) Start() time.Time {
+	if len(r.Events) == 0 {
+		return time.Time{}
+	}
+	return r.Events[0].At
+}
+
+// End returns the time of the last event
+func (r *Recording) End() time.Time {
+	if len(r.Events) == 0 {
+		return time.Time{}
+	}
+	return r.Events[len(r.Events)-1].At
+}
+
+// LoadRecording reads a recording file. It must start with a catalog event.
+func LoadRecording(path string) (*Recording, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open recording: %w", err)
+	}
+	defer f.Close()
+
+	rec := &Recording{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("recording %s line %d: %w", path, line, err)
+		}
+		if n := len(rec.Events); n > 0 && event.At.Before(rec.Events[n-1].At) {
+			return nil, fmt.Errorf("recording %s line %d: event at %s is out of order", path, line, event.At.Format(time.RFC3339Nano))
+		}
+		rec.Events = append(rec.Events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read recording: %w", err)
+	}
+	if len(rec.Events) == 0 || rec.Events[0].Type != EventCatalog {
+		return nil, fmt.Errorf("recording %s does not start with a catalog event", path)
+	}
+	return rec, nil
+}
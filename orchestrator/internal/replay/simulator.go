@@ -0,0 +1,322 @@
+package replay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gavigo/orchestrator/internal/config"
+	"github.com/gavigo/orchestrator/internal/engine"
+	"github.com/gavigo/orchestrator/internal/models"
+	"github.com/gavigo/orchestrator/internal/orchestrator"
+)
+
+// Scenario is an engine configuration to replay a recording against. Nil
+// configs use the defaults.
+type Scenario struct {
+	Name   string
+	Engine *engine.EngineConfig
+	Scorer *engine.ScorerConfig
+}
+
+// Options control the simulated environment shared by all scenarios
+type Options struct {
+	Seed   int64          // Seeds the startup delay jitter so scenarios see the same delays
+	Config *config.Config // Idle timeouts, restore window and proof settings; nil uses config.Load()
+}
+
+// SimulatedDecision is a decision made during a replay
+type SimulatedDecision struct {
+	Offset      time.Duration      `json:"offset"` // Virtual time since the start of the recording
+	TriggerType models.TriggerType `json:"trigger_type"`
+	ContentID   string             `json:"content_id"`
+	Action      models.ActionType  `json:"action"`
+	RuleID      string             `json:"rule_id,omitempty"`
+	Success     bool               `json:"success"`
+	Error       string             `json:"error,omitempty"`
+}
+
+// Result summarizes one scenario's replay
+type Result struct {
+	Scenario    string              `json:"scenario"`
+	Duration    time.Duration       `json:"duration"`
+	Decisions   []SimulatedDecision `json:"decisions"`
+	Activations int                 `json:"activations"`
+	WarmHits    int                 `json:"warm_hits"` // Activations of content that was already WARM or HOT
+	// Hours containers spent in each running state (COLD is free)
+	ContainerHours map[models.ContainerStatus]float64 `json:"container_hours"`
+	Skipped        int                                `json:"skipped_events"` // Events that were rejected or had bad payloads
+}
+
+// WarmHitRate returns the fraction of activations that found their container ready
+func (r *Result) WarmHitRate() float64 {
+	if r.Activations == 0 {
+		return 0
+	}
+	return float64(r.WarmHits) / float64(r.Activations)
+}
+
+// TotalContainerHours returns the hours containers spent outside COLD
+func (r *Result) TotalContainerHours() float64 {
+	total := 0.0
+	for _, hours := range r.ContainerHours {
+		total += hours
+	}
+	return total
+}
+
+// simulation is the state of one scenario's replay
+type simulation struct {
+	clock    *engine.FakeClock
+	start    time.Time
+	rng      *engine.Rand
+	cfg      *config.Config
+	scenario Scenario
+
+	service    *orchestrator.Service
+	generation int // Incremented per service so a replaced service's late events are ignored
+
+	result    *Result
+	decisions map[string]int       // decision ID -> index in result.Decisions
+	since     map[string]time.Time // content ID -> virtual time of its last state change
+}
+
+// Simulate replays a recording through an orchestration service configured by
+// the scenario. Time is virtual: the replay runs as fast as possible, with
+// decay, warm-up delays, idle sweeps and timeouts all following the recording's
+// timestamps. Containers are simulated; nothing is scaled.
+func Simulate(rec *Recording, scenario Scenario, opts Options) (*Result, error) {
+	if len(rec.Events) == 0 || rec.Events[0].Type != EventCatalog {
+		return nil, fmt.Errorf("recording does not start with a catalog event")
+	}
+	cfg := opts.Config
+	if cfg == nil {
+		cfg = config.Load()
+	}
+
+	start := rec.Start()
+	sim := &simulation{
+		clock:     engine.NewFakeClock(start),
+		start:     start,
+		rng:       engine.NewRand(opts.Seed),
+		cfg:       cfg,
+		scenario:  scenario,
+		decisions: make(map[string]int),
+		since:     make(map[string]time.Time),
+		result: &Result{
+			Scenario:       scenario.Name,
+			Decisions:      []SimulatedDecision{},
+			ContainerHours: make(map[models.ContainerStatus]float64),
+		},
+	}
+
+	for _, event := range rec.Events {
+		sim.clock.AdvanceTo(event.At)
+		if err := sim.apply(event); err != nil {
+			sim.result.Skipped++
+		}
+	}
+
+	end := rec.End()
+	sim.accountAll(end)
+	sim.service.Stop()
+	sim.result.Duration = end.Sub(start)
+	return sim.result, nil
+}
+
+// apply replays one recorded event
+func (s *simulation) apply(event Event) error {
+	switch event.Type {
+	case EventCatalog:
+		var content []models.ContentItem
+		if err := json.Unmarshal(event.Payload, &content); err != nil {
+			return err
+		}
+		s.startService(content)
+
+	case EventFocus:
+		var p struct {
+			ContentID  string `json:"content_id"`
+			DurationMS int    `json:"duration_ms"`
+			Theme      string `json:"theme"`
+		}
+		if err := json.Unmarshal(event.Payload, &p); err != nil {
+			return err
+		}
+		return s.service.HandleFocus(event.SessionID, p.ContentID, p.DurationMS, p.Theme)
+
+	case EventScroll:
+		var p struct {
+			Position       int      `json:"position"`
+			Velocity       float64  `json:"velocity"`
+			VisibleContent []string `json:"visible_content"`
+		}
+		if err := json.Unmarshal(event.Payload, &p); err != nil {
+			return err
+		}
+		s.service.HandleScroll(event.SessionID, p.Position, p.Velocity, p.VisibleContent)
+
+	case EventActivation:
+		contentID, err := contentIDPayload(event.Payload)
+		if err != nil {
+			return err
+		}
+		state := s.service.State().ContainerState(contentID)
+		err = s.service.Activate(event.SessionID, "", contentID)
+		if errors.Is(err, orchestrator.ErrUnknownContent) {
+			return err
+		}
+		s.result.Activations++
+		if state == models.StatusWarm || state == models.StatusHot {
+			s.result.WarmHits++
+		}
+		return err
+
+	case EventDeactivation:
+		contentID, err := contentIDPayload(event.Payload)
+		if err != nil {
+			return err
+		}
+		return s.service.Deactivate(event.SessionID, contentID)
+
+	case EventHeartbeat:
+		s.service.Heartbeat(event.SessionID)
+
+	case EventDisconnect:
+		s.service.Disconnect(event.SessionID)
+
+	case EventContentAdded, EventContentUpdated:
+		var item models.ContentItem
+		if err := json.Unmarshal(event.Payload, &item); err != nil {
+			return err
+		}
+		if event.Type == EventContentAdded {
+			s.service.AddContent(item)
+		} else {
+			s.service.UpdateContent(item)
+		}
+
+	case EventContentRemoved:
+		contentID, err := contentIDPayload(event.Payload)
+		if err != nil {
+			return err
+		}
+		s.service.RemoveContent(contentID)
+	}
+	return nil
+}
+
+// startService starts serving a catalog, as the server does on startup
+func (s *simulation) startService(content []models.ContentItem) {
+	if s.service != nil {
+		// The server restarted: every container it was running is gone
+		s.accountAll(s.clock.Now())
+		s.service.Stop()
+	}
+	for i := range content {
+		content[i].ContainerStatus = models.StatusCold
+	}
+
+	s.generation++
+	s.since = make(map[string]time.Time)
+	s.service = orchestrator.NewService(s.cfg, content, &simulationEvents{sim: s, generation: s.generation}, &orchestrator.Options{
+		Engine: s.scenario.Engine,
+		Scorer: s.scenario.Scorer,
+		Clock:  s.clock,
+		Rand:   s.rng,
+	})
+	s.service.Start()
+}
+
+// account adds the time a container spent in a state up to now
+func (s *simulation) account(contentID string, state models.ContainerStatus, now time.Time) {
+	since, ok := s.since[contentID]
+	if !ok {
+		since = s.start
+	}
+	if state != models.StatusCold {
+		s.result.ContainerHours[state] += now.Sub(since).Hours()
+	}
+	s.since[contentID] = now
+}
+
+// accountAll closes the open interval of every container
+func (s *simulation) accountAll(now time.Time) {
+	for contentID, state := range s.service.State().ContainerStates() {
+		s.account(contentID, state, now)
+	}
+}
+
+// simulationEvents collects the decisions and state changes of one service
+type simulationEvents struct {
+	sim        *simulation
+	generation int
+}
+
+func (e *simulationEvents) current() bool {
+	return e.generation == e.sim.generation
+}
+
+func (e *simulationEvents) BroadcastDecision(d *models.AIDecision) {
+	if !e.current() {
+		return
+	}
+	s := e.sim
+	s.decisions[d.DecisionID] = len(s.result.Decisions)
+	sd := SimulatedDecision{
+		Offset:      d.Timestamp.Sub(s.start),
+		TriggerType: d.TriggerType,
+		ContentID:   d.AffectedContentID,
+		Action:      d.ResultingAction,
+		Success:     d.Success,
+		Error:       d.Error,
+	}
+	if d.Explanation != nil {
+		sd.RuleID = d.Explanation.RuleID
+	}
+	s.result.Decisions = append(s.result.Decisions, sd)
+}
+
+func (e *simulationEvents) BroadcastDecisionUpdated(d *models.AIDecision) {
+	if !e.current() {
+		return
+	}
+	if i, ok := e.sim.decisions[d.DecisionID]; ok {
+		e.sim.result.Decisions[i].Success = d.Success
+		e.sim.result.Decisions[i].Error = d.Error
+	}
+}
+
+func (e *simulationEvents) BroadcastContainerStateChange(contentID string, oldState, _ models.ContainerStatus) {
+	if e.current() {
+		e.sim.account(contentID, oldState, e.sim.clock.Now())
+	}
+}
+
+func (e *simulationEvents) BroadcastModeChange(models.OperationalMode, models.OperationalMode, string) {
+}
+func (e *simulationEvents) BroadcastScoreUpdate(string, *models.InputScores)            {}
+func (e *simulationEvents) BroadcastStreamInject(*models.ContentItem, int, string)      {}
+func (e *simulationEvents) BroadcastResourceUpdate(*models.ResourceAllocation)          {}
+func (e *simulationEvents) BroadcastEngagement(*models.EngagementSummary)               {}
+func (e *simulationEvents) BroadcastActivationSpine(*models.ActivationSpineEvent)       {}
+func (e *simulationEvents) BroadcastProofSignal(*models.ProofSignalEvent)               {}
+func (e *simulationEvents) BroadcastTelemetryUpdate(*models.TelemetrySnapshot)          {}
+func (e *simulationEvents) BroadcastContentAdded(*models.ContentItem)                   {}
+func (e *simulationEvents) BroadcastContentUpdated(*models.ContentItem)                 {}
+func (e *simulationEvents) BroadcastContentRemoved(string)                              {}
+func (e *simulationEvents) SendActivationReady(string, *models.Activation)              {}
+func (e *simulationEvents) SendActivationQueued(string, *models.ActivationQueued)       {}
+func (e *simulationEvents) SendActivationCancelled(string, *models.ActivationCancelled) {}
+func (e *simulationEvents) BroadcastActivationFailed(*models.ActivationFailed)          {}
+
+func contentIDPayload(payload json.RawMessage) (string, error) {
+	var p struct {
+		ContentID string `json:"content_id"`
+	}
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return "", err
+	}
+	return p.ContentID, nil
+}
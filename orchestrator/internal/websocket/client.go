@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -41,6 +42,9 @@ type Client struct {
 	send      chan []byte
 	SessionID string
 	UserID    string // Firebase UID when authenticated
+
+	sendMu sync.Mutex // guards closed and sends on send
+	closed bool
 }
 
 // NewClient creates a new client from an HTTP connection
@@ -139,19 +143,39 @@ func (c *Client) Send(msg Message) {
 		log.Printf("Error marshaling message: %v", err)
 		return
 	}
-	select {
-	case c.send <- data:
-	default:
-		log.Printf("Client %s send buffer full, dropping message", c.SessionID)
-	}
+	c.SendRaw(data)
 }
 
 // SendRaw sends raw bytes to this client
 func (c *Client) SendRaw(data []byte) {
+	if !c.trySend(data) {
+		log.Printf("Client %s send buffer full or closed, dropping message", c.SessionID)
+	}
+}
+
+// trySend queues data without blocking. It returns false if the buffer is full
+// or the client has been closed.
+func (c *Client) trySend(data []byte) bool {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if c.closed {
+		return false
+	}
 	select {
 	case c.send <- data:
+		return true
 	default:
-		log.Printf("Client %s send buffer full, dropping message", c.SessionID)
+		return false
+	}
+}
+
+// closeSend closes the send channel once; later sends are dropped
+func (c *Client) closeSend() {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	if !c.closed {
+		c.closed = true
+		close(c.send)
 	}
 }
 
@@ -9,6 +9,9 @@ import (
 type MessageHandler struct {
 	hub *Hub
 
+	// OnMessage sees every inbound message before it is dispatched
+	OnMessage func(client *Client, messageType string, payload json.RawMessage)
+
 	// Callbacks for different message types
 	OnScrollUpdate      func(client *Client, position int, velocity float64, visibleContent []string)
 	OnFocusEvent        func(client *Client, contentID string, durationMS int, theme string)
@@ -17,6 +20,7 @@ type MessageHandler struct {
 	OnDemoControl       func(client *Client, action string, targetContentID string, value float64)
 	OnScreenView        func(client *Client, screenName string)
 	OnUserAction        func(client *Client, action string, screen string, value string)
+	OnHeartbeat         func(client *Client)
 }
 
 // NewMessageHandler creates a new message handler
@@ -32,6 +36,10 @@ func (h *MessageHandler) Setup() {
 func (h *MessageHandler) handleMessage(client *Client, messageType string, payload json.RawMessage) {
 	log.Printf("Received message type: %s from client: %s", messageType, client.SessionID)
 
+	if h.OnMessage != nil {
+		h.OnMessage(client, messageType, payload)
+	}
+
 	switch messageType {
 	case "scroll_update":
 		var p struct {
@@ -125,6 +133,11 @@ func (h *MessageHandler) handleMessage(client *Client, messageType string, paylo
 			h.OnUserAction(client, p.Action, p.Screen, p.Value)
 		}
 
+	case "heartbeat":
+		if h.OnHeartbeat != nil {
+			h.OnHeartbeat(client)
+		}
+
 	default:
 		log.Printf("Unknown message type: %s", messageType)
 	}
@@ -32,6 +32,9 @@ type Hub struct {
 	// Message handler callback
 	messageHandler func(client *Client, messageType string, payload json.RawMessage)
 
+	// Disconnect handler callback
+	disconnectHandler func(client *Client)
+
 	mu sync.RWMutex
 }
 
@@ -50,6 +53,12 @@ func (h *Hub) SetMessageHandler(handler func(client *Client, messageType string,
 	h.messageHandler = handler
 }
 
+// SetDisconnectHandler sets the callback for clients that disconnected. It runs
+// on its own goroutine so it may send to other clients.
+func (h *Hub) SetDisconnectHandler(handler func(client *Client)) {
+	h.disconnectHandler = handler
+}
+
 // Run starts the hub's main loop
 func (h *Hub) Run() {
 	for {
@@ -62,24 +71,30 @@ func (h *Hub) Run() {
 
 		case client := <-h.unregister:
 			h.mu.Lock()
-			if _, ok := h.clients[client]; ok {
+			_, ok := h.clients[client]
+			if ok {
 				delete(h.clients, client)
-				close(client.send)
+				client.closeSend()
 			}
 			h.mu.Unlock()
 			log.Printf("Client unregistered: %s (total: %d)", client.SessionID, len(h.clients))
+			if ok && h.disconnectHandler != nil {
+				go h.disconnectHandler(client)
+			}
 
 		case message := <-h.broadcast:
-			h.mu.RLock()
+			// Drop clients that cannot keep up (write lock: the map is modified)
+			h.mu.Lock()
 			for client := range h.clients {
-				select {
-				case client.send <- message:
-				default:
-					close(client.send)
+				if !client.trySend(message) {
+					client.closeSend()
 					delete(h.clients, client)
+					if h.disconnectHandler != nil {
+						go h.disconnectHandler(client)
+					}
 				}
 			}
-			h.mu.RUnlock()
+			h.mu.Unlock()
 		}
 	}
 }
@@ -112,9 +127,7 @@ func (h *Hub) SendToClient(sessionID string, message interface{}) {
 
 	for client := range h.clients {
 		if client.SessionID == sessionID {
-			select {
-			case client.send <- data:
-			default:
+			if !client.trySend(data) {
 				log.Printf("Client %s send buffer full", sessionID)
 			}
 			return
@@ -169,6 +182,14 @@ func (h *Hub) BroadcastDecision(decision *models.AIDecision) {
 	})
 }
 
+// BroadcastDecisionUpdated sends a decision whose action has completed to all clients
+func (h *Hub) BroadcastDecisionUpdated(decision *models.AIDecision) {
+	h.Broadcast(Message{
+		Type:    "decision_updated",
+		Payload: decision,
+	})
+}
+
 // BroadcastContainerStateChange sends a container state change to all clients
 func (h *Hub) BroadcastContainerStateChange(contentID string, oldState, newState models.ContainerStatus) {
 	h.Broadcast(Message{
@@ -277,3 +298,62 @@ func (h *Hub) BroadcastTelemetryUpdate(snapshot *models.TelemetrySnapshot) {
 		Payload: snapshot,
 	})
 }
+
+// BroadcastContentAdded sends a newly added catalog item to all clients
+func (h *Hub) BroadcastContentAdded(content *models.ContentItem) {
+	h.Broadcast(Message{
+		Type:    "content_added",
+		Payload: content,
+	})
+}
+
+// BroadcastContentUpdated sends a changed catalog item to all clients
+func (h *Hub) BroadcastContentUpdated(content *models.ContentItem) {
+	h.Broadcast(Message{
+		Type:    "content_updated",
+		Payload: content,
+	})
+}
+
+// BroadcastContentRemoved notifies all clients that a catalog item was removed
+func (h *Hub) BroadcastContentRemoved(contentID string) {
+	h.Broadcast(Message{
+		Type: "content_removed",
+		Payload: map[string]interface{}{
+			"content_id": contentID,
+			"timestamp":  time.Now().Format(time.RFC3339),
+		},
+	})
+}
+
+// SendActivationReady tells a session its content is ready
+func (h *Hub) SendActivationReady(sessionID string, activation *models.Activation) {
+	h.SendToClient(sessionID, Message{
+		Type:    "activation_ready",
+		Payload: activation,
+	})
+}
+
+// SendActivationQueued tells a session its activation is waiting for capacity
+func (h *Hub) SendActivationQueued(sessionID string, queued *models.ActivationQueued) {
+	h.SendToClient(sessionID, Message{
+		Type:    "activation_queued",
+		Payload: queued,
+	})
+}
+
+// SendActivationCancelled tells a session its queued activation was dropped
+func (h *Hub) SendActivationCancelled(sessionID string, cancelled *models.ActivationCancelled) {
+	h.SendToClient(sessionID, Message{
+		Type:    "activation_cancelled",
+		Payload: cancelled,
+	})
+}
+
+// BroadcastActivationFailed tells all clients a content item's workload failed
+func (h *Hub) BroadcastActivationFailed(failed *models.ActivationFailed) {
+	h.Broadcast(Message{
+		Type:    "activation_failed",
+		Payload: failed,
+	})
+}
@@ -0,0 +1,118 @@
+package websocket
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Run with -race: these tests exist to catch unsynchronized access
+
+func testClient(hub *Hub, sessionID string, buffer int) *Client {
+	return &Client{hub: hub, send: make(chan []byte, buffer), SessionID: sessionID}
+}
+
+// drain reads a client's messages until its send channel is closed
+func drain(c *Client, wg *sync.WaitGroup) {
+	defer wg.Done()
+	for range c.send {
+	}
+}
+
+func TestClientConcurrentSendAndClose(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		c := testClient(nil, "s1", 4)
+		var senders, readers sync.WaitGroup
+		readers.Add(1)
+		go drain(c, &readers)
+		for j := 0; j < 8; j++ {
+			senders.Add(1)
+			go func() {
+				defer senders.Done()
+				for k := 0; k < 20; k++ {
+					c.trySend([]byte("m"))
+				}
+			}()
+		}
+		senders.Add(1)
+		go func() {
+			defer senders.Done()
+			c.closeSend()
+			c.closeSend() // Closing twice must not panic
+		}()
+		senders.Wait()
+		readers.Wait()
+
+		if c.trySend([]byte("late")) {
+			t.Fatal("trySend succeeded after closeSend")
+		}
+	}
+}
+
+func TestHubConcurrentBroadcastRegisterUnregister(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	disconnected := make(chan string, 64)
+	hub.SetDisconnectHandler(func(c *Client) { disconnected <- c.SessionID })
+
+	const n = 16
+	clients := make([]*Client, n)
+	var readers sync.WaitGroup
+	for i := range clients {
+		clients[i] = testClient(hub, fmt.Sprintf("session-%d", i), 256)
+		readers.Add(1)
+		go drain(clients[i], &readers)
+		hub.register <- clients[i]
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				hub.Broadcast(Message{Type: "score_update", Payload: j})
+				hub.SendToClient(fmt.Sprintf("session-%d", (i+j)%n), Message{Type: "activation_ready"})
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				hub.GetClientCount()
+				hub.GetClients()
+			}
+		}()
+	}
+	for _, c := range clients {
+		wg.Add(1)
+		go func(c *Client) {
+			defer wg.Done()
+			hub.unregister <- c
+		}(c)
+	}
+	wg.Wait()
+
+	// Every client is closed exactly once, so every drain returns
+	done := make(chan struct{})
+	go func() {
+		readers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("client send channels were not closed")
+	}
+	for i := 0; i < n; i++ {
+		select {
+		case <-disconnected:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d of %d disconnects reported", i, n)
+		}
+	}
+	if count := hub.GetClientCount(); count != 0 {
+		t.Errorf("%d clients still registered", count)
+	}
+}